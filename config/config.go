@@ -1,6 +1,9 @@
 package config
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type Enviroment struct {
 	Jwt struct {
@@ -14,4 +17,78 @@ type Enviroment struct {
 	Database struct {
 		URL string `env:"POSTGRES_URL,required=true"`
 	}
+	// optional, see MediaDefaults
+	Media struct {
+		Dir     string `env:"MEDIA_DIR"`
+		BaseURL string `env:"MEDIA_BASE_URL"`
+		MaxSize int64  `env:"MEDIA_MAX_SIZE"`
+	}
+	// optional, see ExpiryDefaults. Pending inquiries are expired when their
+	// reservation date passes or when nobody answers them within MaxAge
+	Expiry struct {
+		Disabled bool          `env:"INQUIRY_EXPIRY_DISABLED"`
+		Interval time.Duration `env:"INQUIRY_EXPIRY_INTERVAL"`
+		MaxAge   time.Duration `env:"INQUIRY_EXPIRY_MAX_AGE"`
+		Notify   bool          `env:"INQUIRY_EXPIRY_NOTIFY"`
+	}
+	// optional, see SelfServiceDefaults. Customers get signed links to their
+	// reservations, url is link without token
+	SelfService struct {
+		Secret     string        `env:"SELF_SERVICE_SECRET"`
+		URL        string        `env:"SELF_SERVICE_URL"`
+		Expiration time.Duration `env:"SELF_SERVICE_EXPIRATION"`
+	}
+	// optional, notifications are only logged when host is not set
+	Smtp struct {
+		Host     string `env:"SMTP_HOST"`
+		Port     string `env:"SMTP_PORT"`
+		Username string `env:"SMTP_USERNAME"`
+		Password string `env:"SMTP_PASSWORD"`
+		From     string `env:"SMTP_FROM"`
+	}
+}
+
+// MediaDefaults fills media settings which were not set in enviroment
+func (e *Enviroment) MediaDefaults() {
+	if e.Media.Dir == "" {
+		e.Media.Dir = "media"
+	}
+	if e.Media.BaseURL == "" {
+		e.Media.BaseURL = "/media"
+	}
+	if e.Media.MaxSize == 0 {
+		e.Media.MaxSize = 10 << 20
+	}
+}
+
+// ExpiryDefaults fills inquiry expiry and smtp settings which were not set in enviroment.
+// Negative interval or max age is rejected
+func (e *Enviroment) ExpiryDefaults() error {
+	if e.Expiry.Interval < 0 {
+		return errors.New("INQUIRY_EXPIRY_INTERVAL must be positive")
+	}
+	if e.Expiry.MaxAge < 0 {
+		return errors.New("INQUIRY_EXPIRY_MAX_AGE must be positive")
+	}
+
+	if e.Expiry.Interval == 0 {
+		e.Expiry.Interval = time.Hour
+	}
+	if e.Expiry.MaxAge == 0 {
+		e.Expiry.MaxAge = 7 * 24 * time.Hour
+	}
+	if e.Smtp.Port == "" {
+		e.Smtp.Port = "587"
+	}
+	return nil
+}
+
+// SelfServiceDefaults fills self-service link settings which were not set in enviroment
+func (e *Enviroment) SelfServiceDefaults() {
+	if e.SelfService.Secret == "" {
+		e.SelfService.Secret = e.Jwt.Secret
+	}
+	if e.SelfService.Expiration == 0 {
+		e.SelfService.Expiration = 30 * 24 * time.Hour
+	}
 }
@@ -6,12 +6,27 @@ import (
 	"strconv"
 
 	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/hashicorp/go-hclog"
 	"github.com/pkg/errors"
 )
 
+func init() {
+	baseValidate.RegisterStructValidation(validateAcceptedPrice, models.Accepted{})
+}
+
+// reservation without item must have item price, reservation of inquiry
+// is priced by inquiry. Tags of Money fields are not run by validator
+func validateAcceptedPrice(sl validator.StructLevel) {
+	accepted := sl.Current().Interface().(models.Accepted)
+	if accepted.InquiryId == 0 && accepted.ItemId == 0 && accepted.ItemPrice.Amount == 0 {
+		sl.ReportError(accepted.ItemPrice.Amount, "ItemPrice", "ItemPrice", "required_without", "ItemId")
+	}
+}
+
 func NewAcceptedHandler(store stores.AcceptedStore, log hclog.Logger) AcceptedHandler {
 	return &acceptedHandler{
 		store: store,
@@ -22,6 +37,7 @@ func NewAcceptedHandler(store stores.AcceptedStore, log hclog.Logger) AcceptedHa
 type AcceptedHandler interface {
 	GetAll(w http.ResponseWriter, r *http.Request)
 	ProcessInquiry(w http.ResponseWriter, r *http.Request)
+	AssignUnit(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 	NewRouter() *mux.Router
 }
@@ -55,13 +71,76 @@ func (a *acceptedHandler) ProcessInquiry(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := accepted.InquiryOverrideError(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	id, err := a.store.ProcessInquiry(r.Context(), accepted)
 	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if be, ok := errors.Cause(err).(*stores.BookingError); ok {
+			writeBookingError(w, be)
+			return
+		}
+		if te, ok := errors.Cause(err).(*stores.InquiryTransitionError); ok {
+			writeTransitionError(w, te)
+			return
+		}
+		if pe, ok := errors.Cause(err).(*stores.PromoCodeError); ok {
+			writePromoError(w, http.StatusConflict, pe)
+			return
+		}
+		if errors.Cause(err) == models.ErrCurrencyMismatch {
+			http.Error(w, models.ErrCurrencyMismatch.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == services.MissingTaxRateError {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		a.log.Error("Error processing inquiry", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	models.NewIdResponse(id).ToJSON(w)
+	models.NewReferenceResponse(id, accepted.Reference).ToJSON(w)
+}
+
+// AssignUnit reassigns accepted reservation to other unit of its item
+func (a *acceptedHandler) AssignUnit(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	assignment := &models.UnitAssignment{}
+	if err := assignment.FromJSON(r.Body); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := baseValidate.Struct(assignment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := a.store.AssignUnit(r.Context(), id, assignment.UnitId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnitUnavailable {
+			http.Error(w, stores.ErrUnitUnavailable.Error(), http.StatusConflict)
+			return
+		}
+		a.log.Error("Error assigning unit to accepted", "id", id, "unit", assignment.UnitId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
 }
 
 func (a *acceptedHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -90,6 +169,9 @@ func (a *acceptedHandler) NewRouter() *mux.Router {
 	postSubrouter := r.Methods(http.MethodPost).Subrouter()
 	postSubrouter.HandleFunc("/accepted/process", a.ProcessInquiry)
 
+	putSubrouter := r.Methods(http.MethodPut).Subrouter()
+	putSubrouter.HandleFunc("/accepted/{id:[\\d]+}/unit", a.AssignUnit)
+
 	deleteSubrouter := r.Methods(http.MethodDelete).Subrouter()
 	deleteSubrouter.HandleFunc("/accepted/{id:[\\d+]}", a.Delete)
 
@@ -33,9 +33,16 @@ func (h *MyFakeAcceptedStore) ProcessInquiry(ctx context.Context, accepted *mode
 	// removed controller parameters because not necessary in this test
 	// i would be using mock.Anything anyways
 	args := h.Called()
+	// saved reservation gets reference code
+	accepted.Reference = "K7F-92QX"
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (h *MyFakeAcceptedStore) AssignUnit(ctx context.Context, id int64, unitId int64) error {
+	args := h.Called(ctx, id, unitId)
+	return args.Error(0)
+}
+
 func (h *MyFakeAcceptedStore) Delete(ctx context.Context, id int64) error {
 	args := h.Called(ctx, id)
 	return args.Error(0)
@@ -65,7 +72,7 @@ func TestAccepted_GetAll_JSONFromDb(t *testing.T) {
 			InquirerComment:    "some comment",
 			ItemId:             1,
 			ItemTitle:          "Item Title",
-			ItemPrice:          200,
+			ItemPrice:          models.NewMoney(200, "EUR"),
 			Notes:              "he needs something special",
 			DateReservation:    &now,
 			DateInquiryCreated: &someday,
@@ -79,7 +86,7 @@ func TestAccepted_GetAll_JSONFromDb(t *testing.T) {
 			InquirerComment:    "some buck",
 			ItemId:             1,
 			ItemTitle:          "buck Title",
-			ItemPrice:          200,
+			ItemPrice:          models.NewMoney(200, "EUR"),
 			Notes:              "he needs something buck",
 			DateReservation:    &someday,
 			DateInquiryCreated: &now,
@@ -145,7 +152,7 @@ func TestAccepted_ProcessInquiry_Success(t *testing.T) {
 		InquirerPhone:      "+38641666666",
 		InquirerComment:    "My Comment",
 		ItemTitle:          "Some item",
-		ItemPrice:          4000,
+		ItemPrice:          models.NewMoney(4000, "EUR"),
 		Notes:              "Some notes",
 		DateReservation:    &someTime,
 		DateInquiryCreated: &someTime,
@@ -169,12 +176,166 @@ func TestAccepted_ProcessInquiry_Success(t *testing.T) {
 		t.Errorf("Get all status code should be 200 but got %v", res.Result().StatusCode)
 	}
 
-	responseExpectation, _ := json.Marshal(models.NewIdResponse(1))
+	responseExpectation, _ := json.Marshal(models.NewReferenceResponse(1, "K7F-92QX"))
 	if strings.TrimSpace(res.Body.String()) != string(responseExpectation) {
 		t.Errorf("Response body should be %#v but got %#v", responseExpectation, res.Body.String())
 	}
 }
 
+func TestAccepted_ProcessInquiry_BadRequest_MissingItemPrice(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	someTime := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	mockedBody := &models.Accepted{
+		Inquirer:        "john Doe",
+		InquirerEmail:   "john.doe@doe.com",
+		ItemTitle:       "Some item",
+		DateReservation: &someTime,
+	}
+
+	acceptedStore := &MyFakeAcceptedStore{}
+	router := acceptedTestRouter(acceptedStore, logMock, t)
+
+	body, err := json.Marshal(mockedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/accepted/process", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Process status code should be 400 but got %v", res.Result().StatusCode)
+	}
+	acceptedStore.AssertNotCalled(t, "ProcessInquiry")
+}
+
+func TestAccepted_ProcessInquiry_FullyBooked(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	someTime := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	mockedBody := &models.Accepted{
+		ItemId:             1,
+		Inquirer:           "john Doe",
+		InquirerEmail:      "john.doe@doe.com",
+		DateReservation:    &someTime,
+		DateInquiryCreated: &someTime,
+	}
+
+	bookingErr := &stores.BookingError{
+		Code:    stores.BookingFullyBooked,
+		Message: "Item is fully booked on requested date",
+		ItemId:  1,
+		Dates:   []time.Time{someTime},
+	}
+
+	acceptedStore := &MyFakeAcceptedStore{}
+	acceptedStore.On("ProcessInquiry").Return(int64(0), bookingErr)
+	router := acceptedTestRouter(acceptedStore, logMock, t)
+
+	body, _ := json.Marshal(mockedBody)
+	req, _ := http.NewRequest("POST", "/accepted/process", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Process inquiry status code should be 409 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Item is fully booked on requested date","code":"fully_booked","details":{"itemId":1,"dates":["2021-08-01"]}}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Response body should be %v but got %v", expected, res.Body.String())
+	}
+}
+
+func TestAccepted_ProcessInquiry_InquiryAlreadyDecided(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	mockedBody := &models.Accepted{
+		InquiryId: 4,
+		Notes:     "Some notes",
+	}
+
+	acceptedStore := &MyFakeAcceptedStore{}
+	acceptedStore.On("ProcessInquiry").Return(int64(0), &stores.InquiryTransitionError{
+		InquiryId: 4,
+		Status:    models.InquiryRejected,
+		Target:    models.InquiryAccepted,
+	})
+	router := acceptedTestRouter(acceptedStore, logMock, t)
+
+	body, _ := json.Marshal(mockedBody)
+	req, _ := http.NewRequest("POST", "/accepted/process", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Process inquiry status code should be 409 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Inquiry is rejected and can not be accepted","code":"invalid_transition","details":{"inquiryId":4,"status":"rejected","target":"accepted"}}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Response body should be %v but got %v", expected, res.Body.String())
+	}
+}
+
+func TestAccepted_ProcessInquiry_BadRequest_InquiryPriceOverride(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	acceptedStore := &MyFakeAcceptedStore{}
+	router := acceptedTestRouter(acceptedStore, logMock, t)
+
+	jsonStr := []byte(`{"inquiryId":4,"totalPrice":{"amount":1,"currency":"EUR"}}`)
+	req, _ := http.NewRequest("POST", "/accepted/process", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Process inquiry with own price should be 400 but got %v", res.Result().StatusCode)
+	}
+	acceptedStore.AssertNotCalled(t, "ProcessInquiry")
+}
+
+func TestAccepted_AssignUnit_Success(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	acceptedStore := &MyFakeAcceptedStore{}
+	acceptedStore.On("AssignUnit", mock.Anything, int64(4), int64(7)).Return(nil)
+	router := acceptedTestRouter(acceptedStore, logMock, t)
+
+	req, _ := http.NewRequest("PUT", "/accepted/4/unit", strings.NewReader(`{"unitId":7}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Assign unit status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	acceptedStore.AssertExpectations(t)
+}
+
+func TestAccepted_AssignUnit_Unavailable(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	acceptedStore := &MyFakeAcceptedStore{}
+	acceptedStore.On("AssignUnit", mock.Anything, int64(4), int64(7)).Return(stores.ErrUnitUnavailable)
+	router := acceptedTestRouter(acceptedStore, logMock, t)
+
+	req, _ := http.NewRequest("PUT", "/accepted/4/unit", strings.NewReader(`{"unitId":7}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Assign unit status code should be 409 but got %v", res.Result().StatusCode)
+	}
+}
+
 // func TestTenant_GetOne_GetResult(t *testing.T) {
 // 	// setup mocking
 // 	// returned from "db"
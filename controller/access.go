@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/hashicorp/go-hclog"
+)
+
+// NewAccessIssuer issues self-service links valid for expiration. Links are
+// sent to email of reservation when self-service url is configured
+func NewAccessIssuer(store stores.AccessStore, links services.AccessLinks, notifier services.Notifier,
+	expiration time.Duration, log hclog.Logger) AccessIssuer {
+	return &accessIssuer{
+		store:      store,
+		links:      links,
+		notifier:   notifier,
+		expiration: expiration,
+		log:        log,
+	}
+}
+
+// AccessIssuer issues self-service links of inquiries and accepted reservations.
+// Missing inquiry or reservation is reported as sql.ErrNoRows
+type AccessIssuer interface {
+	IssueInquiry(ctx context.Context, inquiryId int64) (*models.AccessLink, error)
+	IssueAccepted(ctx context.Context, acceptedId int64) (*models.AccessLink, error)
+}
+
+type accessIssuer struct {
+	log        hclog.Logger
+	store      stores.AccessStore
+	links      services.AccessLinks
+	notifier   services.Notifier
+	expiration time.Duration
+}
+
+func (a *accessIssuer) IssueInquiry(ctx context.Context, inquiryId int64) (*models.AccessLink, error) {
+	access, err := a.store.IssueInquiryAccess(ctx, inquiryId, time.Now().Add(a.expiration))
+	if err != nil {
+		return nil, err
+	}
+	return a.link(ctx, access), nil
+}
+
+func (a *accessIssuer) IssueAccepted(ctx context.Context, acceptedId int64) (*models.AccessLink, error) {
+	access, err := a.store.IssueAcceptedAccess(ctx, acceptedId, time.Now().Add(a.expiration))
+	if err != nil {
+		return nil, err
+	}
+	return a.link(ctx, access), nil
+}
+
+// signs access and sends its link to customer. Link is issued even when
+// notification fails, staff can pass it on
+func (a *accessIssuer) link(ctx context.Context, access *models.ReservationAccess) *models.AccessLink {
+	token := a.links.Sign(access.Id, access.ExpiresAt)
+	link := &models.AccessLink{
+		Id:        access.Id,
+		Token:     token,
+		Link:      a.links.Link(token),
+		ExpiresAt: access.ExpiresAt,
+	}
+
+	if link.Link != "" && access.Email != "" {
+		if err := a.notifier.Notify(ctx, accessNotification(access.Email, link)); err != nil {
+			a.log.Error("Error sending access link", "access", access.Id, "error", err)
+		}
+	}
+	return link
+}
+
+func accessNotification(email string, link *models.AccessLink) *services.Notification {
+	body := fmt.Sprintf("Hello,\n\nyou can check status of your reservation, change your contact details "+
+		"or cancel it at:\n%s\n\nLink is valid until %s.\n", link.Link, link.ExpiresAt.Format("2006-01-02"))
+
+	return &services.Notification{
+		To:      email,
+		Subject: "Your reservation",
+		Body:    body,
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/alesbrelih/go-reservation-api/middleware"
 	"github.com/alesbrelih/go-reservation-api/models"
 	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
@@ -121,3 +123,18 @@ func (a *authHandler) NewRouter() *mux.Router {
 
 	return r
 }
+
+// returns id of authenticated user (subject of validated jwt).
+// Requests without jwt or with non numeric subject have no user
+func requestUserId(r *http.Request) *int64 {
+	claims, ok := r.Context().Value(&middleware.JwtClaimsContextKey{}).(*jwt.StandardClaims)
+	if !ok || claims == nil {
+		return nil
+	}
+
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+)
+
+func NewAvailabilityHandler(store stores.ItemStore, pricing services.PricingService, log hclog.Logger) AvailabilityHandler {
+	return &availabilityHandler{
+		store:   store,
+		pricing: pricing,
+		log:     log,
+		now:     time.Now,
+	}
+}
+
+// AvailabilityHandler searches free items across catalog without authentication
+type AvailabilityHandler interface {
+	Search(w http.ResponseWriter, r *http.Request)
+	NewRouter() *mux.Router
+}
+
+type availabilityHandler struct {
+	log     hclog.Logger
+	store   stores.ItemStore
+	pricing services.PricingService
+	now     func() time.Time
+}
+
+// Search lists items free for stay with their price. Stay is given either by
+// single night (date) or by check-in (from) and check-out (to). Optional guests
+// (default 1) is number of places needed, category limits search to category subtree
+func (a *availabilityHandler) Search(w http.ResponseWriter, r *http.Request) {
+	search, err := parseAvailabilitySearch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	found, err := a.store.SearchAvailable(r.Context(), search, a.now())
+	if err != nil {
+		a.log.Error("Error searching available items", "from", search.From, "to", search.To, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	matches := models.AvailabilityMatches{}
+	for idx := range found {
+		// item that can not be quoted is left out so it does not fail whole search
+		quote, err := a.pricing.QuoteStay(&found[idx].Item, search.From, search.To)
+		if err != nil {
+			a.log.Error("Error quoting available item", "item", found[idx].Item.Id, "error", err)
+			continue
+		}
+		matches = append(matches, models.NewAvailabilityMatch(&found[idx], quote))
+	}
+
+	matches.ToJSON(w)
+}
+
+func (a *availabilityHandler) NewRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/availability/search", a.Search)
+
+	return r
+}
+
+// parses stay, party size and category of availability search from query
+func parseAvailabilitySearch(r *http.Request) (*models.AvailabilitySearch, error) {
+	search := &models.AvailabilitySearch{Guests: 1}
+
+	if r.URL.Query().Get("date") != "" {
+		date, err := parseDateQuery(r, "date", time.Time{})
+		if err != nil {
+			return nil, fmt.Errorf("Invalid date")
+		}
+		search.From = models.TruncateDay(date)
+		search.To = search.From.AddDate(0, 0, 1)
+	} else {
+		from, err := parseDateQuery(r, "from", time.Time{})
+		if err != nil || from.IsZero() {
+			return nil, fmt.Errorf("Date or from date is required")
+		}
+		to, err := parseDateQuery(r, "to", from.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid to date")
+		}
+		search.From = models.TruncateDay(from)
+		search.To = models.TruncateDay(to)
+	}
+
+	if !search.To.After(search.From) || search.To.Sub(search.From) > maxAvailabilityDays*24*time.Hour {
+		return nil, fmt.Errorf("Stay must be between 1 and %v nights long", maxAvailabilityDays)
+	}
+
+	guests, err := parseIntQuery(r, "guests")
+	if err != nil {
+		return nil, err
+	}
+	if guests != nil {
+		if *guests < 1 {
+			return nil, fmt.Errorf("Invalid guests")
+		}
+		search.Guests = *guests
+	}
+
+	if search.CategoryId, err = parseIntQuery(r, "category"); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
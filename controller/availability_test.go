@@ -0,0 +1,145 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+)
+
+func availabilityTestRouter(store stores.ItemStore, log *test_util.HcLogMock) *mux.Router {
+	r := mux.NewRouter()
+
+	handler := controller.NewAvailabilityHandler(store, services.NewPricingService(), log)
+	r.PathPrefix("/availability").Handler(handler.NewRouter())
+	return r
+}
+
+func TestAvailability_Search_SingleDateQuoted(t *testing.T) {
+	title := "Lake house"
+	found := []models.AvailableItem{
+		{Item: models.Item{Id: 4, Title: &title, Price: models.NewMoney(120, "EUR"), BookingMode: models.BookingModeDaily}, Remaining: 2},
+	}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("SearchAvailable", mock.Anything, mock.Anything, mock.Anything).Return(found, nil)
+	router := availabilityTestRouter(itemStore, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/availability/search?date=2021-08-12&guests=6&category=3", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Availability search status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	body := res.Body.String()
+	if !strings.Contains(body, `"itemId":4`) || !strings.Contains(body, `"remaining":2`) ||
+		!strings.Contains(body, `"total":{"amount":120,"currency":"EUR"}`) {
+		t.Errorf("Unexpected availability search body %v", body)
+	}
+
+	search := itemStore.Calls[0].Arguments.Get(1).(*models.AvailabilitySearch)
+	from := time.Date(2021, 8, 12, 0, 0, 0, 0, time.UTC)
+	if !search.From.Equal(from) || !search.To.Equal(from.AddDate(0, 0, 1)) || search.Guests != 6 ||
+		search.CategoryId == nil || *search.CategoryId != 3 {
+		t.Errorf("Unexpected search %#v", search)
+	}
+}
+
+func TestAvailability_Search_StayPricedByNights(t *testing.T) {
+	title := "Lake house"
+	found := []models.AvailableItem{
+		{Item: models.Item{Id: 4, Title: &title, Price: models.NewMoney(100, "EUR"), BookingMode: models.BookingModeDaily}, Remaining: 1},
+	}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("SearchAvailable", mock.Anything, mock.Anything, mock.Anything).Return(found, nil)
+	router := availabilityTestRouter(itemStore, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/availability/search?from=2021-08-12&to=2021-08-15", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if !strings.Contains(res.Body.String(), `"total":{"amount":300,"currency":"EUR"}`) {
+		t.Errorf("Expected three nights total but got %v", res.Body.String())
+	}
+
+	search := itemStore.Calls[0].Arguments.Get(1).(*models.AvailabilitySearch)
+	if search.Guests != 1 || search.CategoryId != nil {
+		t.Errorf("Expected default guests without category but got %#v", search)
+	}
+}
+
+func TestAvailability_Search_UnquotableItemSkipped(t *testing.T) {
+	title := "Lake house"
+	found := []models.AvailableItem{
+		{Item: models.Item{Id: 4, Title: &title, Price: models.NewMoney(100, "EUR"), BookingMode: models.BookingModeDaily,
+			DatePrices: []models.ItemDatePrice{{
+				Id:       7,
+				DateFrom: time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+				DateTo:   time.Date(2021, 8, 31, 0, 0, 0, 0, time.UTC),
+				Price:    models.NewMoney(90, "USD"),
+			}}}, Remaining: 1},
+		{Item: models.Item{Id: 5, Title: &title, Price: models.NewMoney(80, "EUR"), BookingMode: models.BookingModeDaily}, Remaining: 3},
+	}
+
+	logMock := &test_util.HcLogMock{}
+	logMock.On("Error", "Error quoting available item", mock.Anything).Return()
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("SearchAvailable", mock.Anything, mock.Anything, mock.Anything).Return(found, nil)
+	router := availabilityTestRouter(itemStore, logMock)
+
+	req, _ := http.NewRequest("GET", "/availability/search?date=2021-08-12", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Availability search status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	body := res.Body.String()
+	if strings.Contains(body, `"itemId":4`) || !strings.Contains(body, `"itemId":5`) {
+		t.Errorf("Expected only quotable item but got %v", body)
+	}
+	logMock.AssertExpectations(t)
+}
+
+func TestAvailability_Search_InvalidQuery(t *testing.T) {
+	queries := []string{
+		"",
+		"date=tomorrow",
+		"from=2021-08-12&to=2021-08-12",
+		"from=2021-08-12&to=2021-08-10",
+		"date=2021-08-12&guests=0",
+		"date=2021-08-12&guests=many",
+	}
+
+	for _, query := range queries {
+		itemStore := &MyFakeItemStore{}
+		router := availabilityTestRouter(itemStore, &test_util.HcLogMock{})
+
+		req, _ := http.NewRequest("GET", "/availability/search?"+query, nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if res.Result().StatusCode != 400 {
+			t.Errorf("Availability search with %q should be 400 but got %v", query, res.Result().StatusCode)
+		}
+		itemStore.AssertNotCalled(t, "SearchAvailable", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewBookingRuleHandler(store stores.BookingRuleStore, log hclog.Logger) BookingRuleHandler {
+	return &bookingRuleHandler{
+		store: store,
+		log:   log,
+	}
+}
+
+type BookingRuleHandler interface {
+	GetItemRules(w http.ResponseWriter, r *http.Request)
+	SetItemRules(w http.ResponseWriter, r *http.Request)
+	GetTenantRules(w http.ResponseWriter, r *http.Request)
+	SetTenantRules(w http.ResponseWriter, r *http.Request)
+	NewItemRouter() *mux.Router
+	NewTenantRouter() *mux.Router
+}
+
+type bookingRuleHandler struct {
+	log   hclog.Logger
+	store stores.BookingRuleStore
+}
+
+func (b *bookingRuleHandler) GetItemRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rules, err := b.store.GetItemRules(r.Context(), id)
+	if err != nil {
+		b.log.Error("Error retrieving item booking rules", "item", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	rules.ToJSON(w)
+}
+
+// SetItemRules replaces booking rules of item. Rules left out are taken from tenant
+func (b *bookingRuleHandler) SetItemRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rules, ok := readBookingRules(w, r)
+	if !ok {
+		return
+	}
+
+	if err := b.store.SetItemRules(r.Context(), id, rules); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		b.log.Error("Error saving item booking rules", "item", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (b *bookingRuleHandler) GetTenantRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rules, err := b.store.GetTenantRules(r.Context(), id)
+	if err != nil {
+		b.log.Error("Error retrieving tenant booking rules", "tenant", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	rules.ToJSON(w)
+}
+
+// SetTenantRules replaces default booking rules of tenant items
+func (b *bookingRuleHandler) SetTenantRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rules, ok := readBookingRules(w, r)
+	if !ok {
+		return
+	}
+
+	if err := b.store.SetTenantRules(r.Context(), id, rules); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		b.log.Error("Error saving tenant booking rules", "tenant", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// reads and validates booking rules from request body. Writes bad request
+// response and returns false when they are invalid
+func readBookingRules(w http.ResponseWriter, r *http.Request) (*models.BookingRules, bool) {
+	rules := &models.BookingRules{}
+	err := rules.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := baseValidate.Struct(rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := rules.StayError(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return rules, true
+}
+
+func (b *bookingRuleHandler) NewItemRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/item/{id:[\\d]+}/booking-rules", b.GetItemRules).Methods(http.MethodGet)
+	r.HandleFunc("/item/{id:[\\d]+}/booking-rules", b.SetItemRules).Methods(http.MethodPut)
+
+	return r
+}
+
+// tenant rules are defaults of tenant items
+func (b *bookingRuleHandler) NewTenantRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/tenant/{id:[\\d]+}/booking-rules", b.GetTenantRules).Methods(http.MethodGet)
+	r.HandleFunc("/tenant/{id:[\\d]+}/booking-rules", b.SetTenantRules).Methods(http.MethodPut)
+
+	return r
+}
@@ -0,0 +1,101 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeBookingRuleStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeBookingRuleStore) GetItemRules(ctx context.Context, itemId int64) (*models.BookingRules, error) {
+	args := h.Called(ctx, itemId)
+	return args.Get(0).(*models.BookingRules), args.Error(1)
+}
+
+func (h *MyFakeBookingRuleStore) SetItemRules(ctx context.Context, itemId int64, rules *models.BookingRules) error {
+	args := h.Called(ctx, itemId, rules)
+	return args.Error(0)
+}
+
+func (h *MyFakeBookingRuleStore) GetTenantRules(ctx context.Context, tenantId int64) (*models.BookingRules, error) {
+	args := h.Called(ctx, tenantId)
+	return args.Get(0).(*models.BookingRules), args.Error(1)
+}
+
+func (h *MyFakeBookingRuleStore) SetTenantRules(ctx context.Context, tenantId int64, rules *models.BookingRules) error {
+	args := h.Called(ctx, tenantId, rules)
+	return args.Error(0)
+}
+
+func bookingRuleTestRouter(store stores.BookingRuleStore, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	handler := controller.NewBookingRuleHandler(store, log)
+	r.PathPrefix("/item/{id:[\\d]+}/booking-rules").Handler(handler.NewItemRouter())
+	r.PathPrefix("/tenant/{id:[\\d]+}/booking-rules").Handler(handler.NewTenantRouter())
+	return r
+}
+
+func TestBookingRules_SetItemRules_Success(t *testing.T) {
+	store := &MyFakeBookingRuleStore{}
+	store.On("SetItemRules", mock.Anything, int64(3), mock.Anything).Return(nil)
+	router := bookingRuleTestRouter(store, &test_util.HcLogMock{})
+
+	body := `{"minNoticeHours":24,"minStay":2,"maxStay":14,"arrivalWeekdays":[5,6]}`
+	req, _ := http.NewRequest("PUT", "/item/3/booking-rules", strings.NewReader(body))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Set rules status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	rules := store.Calls[0].Arguments.Get(2).(*models.BookingRules)
+	if *rules.MinNoticeHours != 24 || rules.MaxAdvanceDays != nil || len(rules.ArrivalWeekdays) != 2 {
+		t.Errorf("Expected rules from body but got %#v", rules)
+	}
+}
+
+func TestBookingRules_SetTenantRules_StayConflict(t *testing.T) {
+	store := &MyFakeBookingRuleStore{}
+	router := bookingRuleTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("PUT", "/tenant/1/booking-rules", strings.NewReader(`{"minStay":7,"maxStay":2}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Set rules status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	store.AssertNotCalled(t, "SetTenantRules", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBookingRules_SetItemRules_InvalidWeekday(t *testing.T) {
+	store := &MyFakeBookingRuleStore{}
+	router := bookingRuleTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("PUT", "/item/3/booking-rules", strings.NewReader(`{"arrivalWeekdays":[7]}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Set rules status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewCategoryHandler(store stores.CategoryStore, log hclog.Logger) CategoryHandler {
+	return &categoryHandler{
+		store: store,
+		log:   log,
+	}
+}
+
+type CategoryHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	NewRouter() *mux.Router
+}
+
+type categoryHandler struct {
+	log   hclog.Logger
+	store stores.CategoryStore
+}
+
+func (c *categoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.store.GetAll(r.Context())
+	if err != nil {
+		c.log.Error("Error retrieving categories", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	categories.ToJSON(w)
+}
+
+func (c *categoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	category := &models.Category{}
+	err := category.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := createValidate.Struct(category); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.store.Create(r.Context(), category)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		c.log.Error("Error creating category", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(id).ToJSON(w)
+}
+
+func (c *categoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	category := &models.Category{}
+	err := category.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	category.Id = id
+
+	if err := updateValidate.Struct(category); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = c.store.Update(r.Context(), category)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrCategoryCycle {
+			http.Error(w, stores.ErrCategoryCycle.Error(), http.StatusBadRequest)
+			return
+		}
+		c.log.Error("Error updating category", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *categoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	err := c.store.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		c.log.Error("Error deleting category", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *categoryHandler) NewRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/category", c.GetAll)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/category", c.Create)
+
+	put := r.Methods(http.MethodPut).Subrouter()
+	put.HandleFunc("/category/{id:[\\d]+}", c.Update)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/category/{id:[\\d]+}", c.Delete)
+
+	return r
+}
@@ -0,0 +1,131 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeCategoryStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeCategoryStore) GetAll(ctx context.Context) (models.Categories, error) {
+	args := h.Called(ctx)
+	return args.Get(0).(models.Categories), args.Error(1)
+}
+
+func (h *MyFakeCategoryStore) Create(ctx context.Context, category *models.Category) (int64, error) {
+	args := h.Called(ctx, category)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeCategoryStore) Update(ctx context.Context, category *models.Category) error {
+	args := h.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (h *MyFakeCategoryStore) Delete(ctx context.Context, id int64) error {
+	args := h.Called(ctx, id)
+	return args.Error(0)
+}
+
+func categoryTestRouter(store stores.CategoryStore, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	categoryHandler := controller.NewCategoryHandler(store, log)
+	r.PathPrefix("/category").Handler(categoryHandler.NewRouter())
+	return r
+}
+
+func TestCategory_GetAll_JSONFromDb(t *testing.T) {
+	parentId := int64(1)
+	mockedCategories := models.Categories{
+		{Id: 1, Title: "Sports"},
+		{Id: 2, ParentId: &parentId, Title: "Tennis"},
+	}
+
+	categoryStore := &MyFakeCategoryStore{}
+	categoryStore.On("GetAll", mock.Anything).Return(mockedCategories, nil)
+	router := categoryTestRouter(categoryStore, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/category", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Get all status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	expected := &bytes.Buffer{}
+	json.NewEncoder(expected).Encode(mockedCategories)
+	if res.Body.String() != expected.String() {
+		t.Errorf("Expected body %v but got %v", expected.String(), res.Body.String())
+	}
+}
+
+func TestCategory_Create_Success(t *testing.T) {
+	categoryStore := &MyFakeCategoryStore{}
+	categoryStore.On("Create", mock.Anything, mock.Anything).Return(int64(3), nil)
+	router := categoryTestRouter(categoryStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"parentId":1,"title":"Padel"}`)
+	req, _ := http.NewRequest("POST", "/category", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	category := categoryStore.Calls[0].Arguments.Get(1).(*models.Category)
+	if *category.ParentId != 1 || category.Title != "Padel" {
+		t.Errorf("Expected Padel under category 1 but got %#v", category)
+	}
+}
+
+func TestCategory_Create_BadRequest_TitleMissing(t *testing.T) {
+	categoryStore := &MyFakeCategoryStore{}
+	router := categoryTestRouter(categoryStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"parentId":1}`)
+	req, _ := http.NewRequest("POST", "/category", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	categoryStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCategory_Update_BadRequest_Cycle(t *testing.T) {
+	categoryStore := &MyFakeCategoryStore{}
+	categoryStore.On("Update", mock.Anything, mock.Anything).Return(stores.ErrCategoryCycle)
+	router := categoryTestRouter(categoryStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"parentId":2,"title":"Sports"}`)
+	req, _ := http.NewRequest("PUT", "/category/1", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Update status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
@@ -2,40 +2,55 @@ package controller
 
 import (
 	"database/sql"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/alesbrelih/go-reservation-api/middleware"
 	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/alesbrelih/go-reservation-api/stores"
 	"github.com/gorilla/mux"
 	"github.com/hashicorp/go-hclog"
 	"github.com/pkg/errors"
 )
 
-func NewInquiryHandler(store stores.InquiryStore, jwt middleware.Jwt, log hclog.Logger) InquiryHandler {
+func NewInquiryHandler(store stores.InquiryStore, access AccessIssuer, jwt middleware.Jwt, log hclog.Logger) InquiryHandler {
 	return &inquiryHandler{
-		store: store,
-		jwt:   jwt,
-		log:   log,
+		store:  store,
+		access: access,
+		jwt:    jwt,
+		log:    log,
 	}
 }
 
 type InquiryHandler interface {
 	GetAll(w http.ResponseWriter, r *http.Request)
 	Create(w http.ResponseWriter, r *http.Request)
+	Accept(w http.ResponseWriter, r *http.Request)
+	Reject(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 	NewRouter() *mux.Router
 }
 
 type inquiryHandler struct {
-	log   hclog.Logger
-	jwt   middleware.Jwt
-	store stores.InquiryStore
+	log    hclog.Logger
+	jwt    middleware.Jwt
+	store  stores.InquiryStore
+	access AccessIssuer
 }
 
+// GetAll lists inquiries, optional status query param limits them to single status
 func (i *inquiryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	inquiries, err := i.store.GetAll(r.Context())
+	status := r.URL.Query().Get("status")
+	if status != "" && !models.IsInquiryStatus(status) {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	inquiries, err := i.store.GetAll(r.Context(), status)
 	if err != nil {
 		i.log.Error("Error retrieving inquiries", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -63,18 +78,133 @@ func (i *inquiryHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = i.store.Create(r.Context(), ic)
+	// slots are validated against item opening hours in store. Stays are
+	// limited the same as stay quotes, they are priced night by night
+	if !ic.IsSlotReservation() {
+		from, to := ic.Stay()
+		if !models.TruncateDay(to).After(models.TruncateDay(from)) {
+			http.Error(w, "Check-out (dateTo) must be at least one day after check-in (dateFrom)", http.StatusBadRequest)
+			return
+		}
+		if to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+			http.Error(w, fmt.Sprintf("Stay must be between 1 and %v nights long", maxAvailabilityDays), http.StatusBadRequest)
+			return
+		}
+	}
+
+	created, err := i.store.Create(r.Context(), ic)
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
+		if be, ok := errors.Cause(err).(*stores.BookingError); ok {
+			writeBookingError(w, be)
+			return
+		}
+		if pe, ok := errors.Cause(err).(*stores.PromoCodeError); ok {
+			writePromoError(w, http.StatusBadRequest, pe)
+			return
+		}
+		if errors.Cause(err) == services.InvalidAddonError {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrItemArchived {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == services.MissingTaxRateError {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == models.ErrCurrencyMismatch {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		i.log.Debug("Error saving inquiry in database. Request body", ic, " Error: ", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	// customer gets self-service link of inquiry, inquiry is saved even
+	// when link can not be issued, staff can issue it later
+	if created.Access, err = i.access.IssueInquiry(r.Context(), created.Id); err != nil {
+		i.log.Error("Error issuing access of created inquiry", "inquiry", created.Id, "error", err)
+	}
+
 	w.WriteHeader(http.StatusCreated)
+	created.ToJSON(w)
+}
+
+// Accept turns pending inquiry into accepted reservation. Optional note
+// becomes notes of reservation. Responds with id of accepted reservation
+func (i *inquiryHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	decision, err := readInquiryDecision(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	acceptedId, err := i.store.Accept(r.Context(), id, decision.Note)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if te, ok := errors.Cause(err).(*stores.InquiryTransitionError); ok {
+			writeTransitionError(w, te)
+			return
+		}
+		if be, ok := errors.Cause(err).(*stores.BookingError); ok {
+			writeBookingError(w, be)
+			return
+		}
+		// code was used up by other accepted inquiries in the meantime
+		if pe, ok := errors.Cause(err).(*stores.PromoCodeError); ok {
+			writePromoError(w, http.StatusConflict, pe)
+			return
+		}
+		if errors.Cause(err) == services.MissingTaxRateError {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		i.log.Error("Error accepting inquiry", "id", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	models.NewIdResponse(acceptedId).ToJSON(w)
+}
+
+// Reject marks pending inquiry as rejected. Optional note is reason of rejection
+func (i *inquiryHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	decision, err := readInquiryDecision(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = i.store.Reject(r.Context(), id, decision.Note)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if te, ok := errors.Cause(err).(*stores.InquiryTransitionError); ok {
+			writeTransitionError(w, te)
+			return
+		}
+		i.log.Error("Error rejecting inquiry", "id", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
 }
 
 func (i *inquiryHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -104,9 +234,30 @@ func (i *inquiryHandler) NewRouter() *mux.Router {
 	post := r.Methods(http.MethodPost).Subrouter()
 	post.HandleFunc("/inquiry", i.Create)
 
+	transition := r.Methods(http.MethodPost).Subrouter()
+	transition.HandleFunc("/inquiry/{id:[\\d]+}/accept", i.Accept)
+	transition.HandleFunc("/inquiry/{id:[\\d]+}/reject", i.Reject)
+	transition.Use(i.jwt.ValidateUser)
+
 	delete := r.Methods(http.MethodDelete).Subrouter()
 	delete.HandleFunc("/inquiry/{id:[\\d]+}", i.Delete)
-	get.Use(i.jwt.ValidateUser)
+	delete.Use(i.jwt.ValidateUser)
 
 	return r
 }
+
+// reads optional decision body, empty body is decision without note
+func readInquiryDecision(r *http.Request) (*models.InquiryDecision, error) {
+	decision := &models.InquiryDecision{}
+	err := decision.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil && err != io.EOF {
+		return nil, errors.New("Bad request")
+	}
+
+	if err := baseValidate.Struct(decision); err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
@@ -0,0 +1,564 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/middleware"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeInquiryStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeInquiryStore) GetAll(ctx context.Context, status string) (models.Inquiries, error) {
+	args := h.Called(ctx, status)
+	return args.Get(0).(models.Inquiries), args.Error(1)
+}
+
+func (h *MyFakeInquiryStore) Accept(ctx context.Context, id int64, notes string) (int64, error) {
+	args := h.Called(ctx, id, notes)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeInquiryStore) Reject(ctx context.Context, id int64, reason string) error {
+	args := h.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (h *MyFakeInquiryStore) Create(ctx context.Context, inquiry *models.InquiryCreate) (*models.InquiryCreated, error) {
+	args := h.Called(ctx, inquiry)
+	return args.Get(0).(*models.InquiryCreated), args.Error(1)
+}
+
+func (h *MyFakeInquiryStore) Expire(ctx context.Context, createdBefore time.Time, day time.Time, now time.Time) (models.Inquiries, error) {
+	args := h.Called(ctx, createdBefore, day, now)
+	return args.Get(0).(models.Inquiries), args.Error(1)
+}
+
+func (h *MyFakeInquiryStore) Delete(ctx context.Context, id int64) error {
+	args := h.Called(ctx, id)
+	return args.Error(0)
+}
+
+func inquiryTestRouter(store stores.InquiryStore, log hclog.Logger, t *testing.T) *mux.Router {
+	r := mux.NewRouter()
+
+	authService := services.NewAuthService("test-secret", time.Minute, time.Hour)
+	jwt := middleware.NewJwt(authService, hclog.NewNullLogger())
+
+	access := &MyFakeAccessIssuer{}
+	access.On("IssueInquiry", mock.Anything, mock.Anything).Return(&models.AccessLink{Id: 1, Token: "token"}, nil)
+
+	inquiryHandler := controller.NewInquiryHandler(store, access, jwt, log)
+	r.PathPrefix("/inquiry").Handler(inquiryHandler.NewRouter())
+	return r
+}
+
+// returns authorization header accepted by inquiry test router
+func inquiryTestAuthorization(t *testing.T) string {
+	pair, err := services.NewAuthService("test-secret", time.Minute, time.Hour).GenerateJwtPair("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + pair.Access
+}
+
+func TestInquiry_Create_Success(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return(&models.InquiryCreated{Id: 1, Reference: "K7F-92QX"}, nil)
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"date":"2021-08-01T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v", res.Result().StatusCode)
+	}
+	body := res.Body.String()
+	if !strings.Contains(body, `"id":1`) || !strings.Contains(body, `"reference":"K7F-92QX"`) || !strings.Contains(body, `"token":"token"`) {
+		t.Errorf("Expected inquiry id, reference and access link in response but got %v", body)
+	}
+
+	inquiryStore.AssertExpectations(t)
+}
+
+func TestInquiry_Create_BadRequest_MissingDate(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+	logMock.On("Debug", mock.Anything, mock.Anything)
+
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	inquiryStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInquiry_Create_FullyBooked(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	date := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	bookingErr := &stores.BookingError{
+		Code:    stores.BookingFullyBooked,
+		Message: "Item is fully booked on requested date",
+		ItemId:  1,
+		Dates:   []time.Time{date},
+	}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return((*models.InquiryCreated)(nil), bookingErr)
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"date":"2021-08-01T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Create status code should be 409 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Item is fully booked on requested date","code":"fully_booked","details":{"itemId":1,"dates":["2021-08-01"]}}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Response body should be %v but got %v", expected, res.Body.String())
+	}
+}
+
+func TestInquiry_Create_PromoCodeExhausted(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return((*models.InquiryCreated)(nil), &stores.PromoCodeError{
+		Code:      models.PromoExhausted,
+		Message:   "Promo code was already used up",
+		PromoCode: "SUMMER22",
+	})
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"date":"2021-08-01T00:00:00Z","promoCode":"summer22"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Promo code was already used up","code":"promo_exhausted","details":{"promoCode":"SUMMER22"}}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Response body should be %v but got %v", expected, res.Body.String())
+	}
+
+	ic := inquiryStore.Calls[0].Arguments.Get(1).(*models.InquiryCreate)
+	if ic.PromoCode != "summer22" {
+		t.Errorf("Expected promo code to be passed to store but got %#v", ic)
+	}
+}
+
+func TestInquiry_GetAll_Unauthorized(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	req, _ := http.NewRequest("GET", "/inquiry", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Get all status code should be 401 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestInquiry_Create_StaySuccess(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return(&models.InquiryCreated{Id: 1, Reference: "K7F-92QX"}, nil)
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"dateFrom":"2021-08-01T00:00:00Z","dateTo":"2021-08-05T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	ic := inquiryStore.Calls[0].Arguments.Get(1).(*models.InquiryCreate)
+	from, to := ic.Stay()
+	if from.Day() != 1 || to.Day() != 5 {
+		t.Errorf("Expected stay from 1st to 5th but got %v - %v", from, to)
+	}
+}
+
+func TestInquiry_Create_BadRequest_CheckOutBeforeCheckIn(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"dateFrom":"2021-08-05T00:00:00Z","dateTo":"2021-08-05T10:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	inquiryStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInquiry_Create_BadRequest_StayTooLong(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"dateFrom":"2021-08-05T00:00:00Z","dateTo":"2031-08-05T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	inquiryStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInquiry_Create_SlotSuccess(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return(&models.InquiryCreated{Id: 1, Reference: "K7F-92QX"}, nil)
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"slot":"2021-08-01T10:00:00Z","slots":2}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	ic := inquiryStore.Calls[0].Arguments.Get(1).(*models.InquiryCreate)
+	if !ic.IsSlotReservation() || ic.SlotCount() != 2 {
+		t.Errorf("Expected reservation of 2 slots but got %#v", ic)
+	}
+}
+
+func TestInquiry_Create_InvalidSlot(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return((*models.InquiryCreated)(nil), &stores.BookingError{
+		Code:    stores.BookingInvalidSlot,
+		Message: "Slot must start on slot boundary inside opening hours",
+		ItemId:  1,
+	})
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"slot":"2021-08-01T10:15:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Slot must start on slot boundary inside opening hours","code":"invalid_slot","details":{"itemId":1}}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Response body should be %v but got %v", expected, res.Body.String())
+	}
+}
+
+func TestInquiry_Create_Blackout(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return((*models.InquiryCreated)(nil), &stores.BookingError{
+		Code:    stores.BookingBlackout,
+		Message: "Item is unavailable in requested period (Maintenance)",
+		ItemId:  1,
+		Dates:   []time.Time{time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC)},
+	})
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"dateFrom":"2021-08-01T00:00:00Z","dateTo":"2021-08-04T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Item is unavailable in requested period (Maintenance)","code":"blackout","details":{"itemId":1,"dates":["2021-08-02"]}}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Response body should be %v but got %v", expected, res.Body.String())
+	}
+}
+
+func TestInquiry_Create_InvalidAddon(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Create", mock.Anything, mock.Anything).Return((*models.InquiryCreated)(nil), errors.Wrap(services.InvalidAddonError, "Add-on 9 is not offered with item 1"))
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"date":"2021-08-01T00:00:00Z","addons":[{"addonId":9,"quantity":2}]}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	ic := inquiryStore.Calls[0].Arguments.Get(1).(*models.InquiryCreate)
+	if len(ic.Addons) != 1 || ic.Addons[0].AddonId != 9 || ic.Addons[0].Quantity != 2 {
+		t.Errorf("Expected add-on selection to be passed to store but got %#v", ic.Addons)
+	}
+}
+
+func TestInquiry_Create_BadRequest_AddonQuantity(t *testing.T) {
+	logMock := &test_util.HcLogMock{}
+	logMock.On("Debug", mock.Anything, mock.Anything)
+
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, logMock, t)
+
+	jsonStr := []byte(`{"inquirer":"John Doe","email":"john.doe@doe.com","itemId":1,"date":"2021-08-01T00:00:00Z","addons":[{"addonId":1,"quantity":-1}]}`)
+	req, _ := http.NewRequest("POST", "/inquiry", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+	inquiryStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInquiry_GetAll_StatusFilter(t *testing.T) {
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("GetAll", mock.Anything, models.InquiryPending).Return(models.Inquiries{{Id: 3, Status: models.InquiryPending}}, nil)
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("GET", "/inquiry?status=pending", nil)
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 || !strings.Contains(res.Body.String(), `"status":"pending"`) {
+		t.Errorf("Expected pending inquiries but got %v %v", res.Result().StatusCode, res.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/inquiry?status=done", nil)
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res = httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get all with unknown status should be 400 but got %v", res.Result().StatusCode)
+	}
+	inquiryStore.AssertNumberOfCalls(t, "GetAll", 1)
+}
+
+func TestInquiry_Accept_Success(t *testing.T) {
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Accept", mock.Anything, int64(3), "Late check-in").Return(int64(12), nil)
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("POST", "/inquiry/3/accept", strings.NewReader(`{"note":"Late check-in"}`))
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Accept status code should be 200 but got %v", res.Result().StatusCode)
+	}
+	if !strings.Contains(res.Body.String(), `"id":12`) {
+		t.Errorf("Expected id of accepted reservation but got %v", res.Body.String())
+	}
+	inquiryStore.AssertExpectations(t)
+}
+
+func TestInquiry_Accept_SingleUsePromoCodeAcceptedTwice(t *testing.T) {
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Accept", mock.Anything, int64(3), "").Return(int64(12), nil)
+	inquiryStore.On("Accept", mock.Anything, int64(4), "").Return(int64(0), &stores.PromoCodeError{
+		Code:      models.PromoExhausted,
+		Message:   "Promo code was used too many times",
+		PromoCode: "SUMMER",
+	})
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	statuses := map[string]int{"/inquiry/3/accept": 200, "/inquiry/4/accept": 409}
+	for path, status := range statuses {
+		req, _ := http.NewRequest("POST", path, http.NoBody)
+		req.Header.Set("Authorization", inquiryTestAuthorization(t))
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if res.Result().StatusCode != status {
+			t.Errorf("Accept %v status code should be %v but got %v", path, status, res.Result().StatusCode)
+		}
+		if status == 409 && !strings.Contains(res.Body.String(), `"code":"promo_exhausted"`) {
+			t.Errorf("Expected exhausted promo code but got %v", res.Body.String())
+		}
+	}
+	inquiryStore.AssertExpectations(t)
+}
+
+func TestInquiry_Accept_Unauthorized(t *testing.T) {
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("POST", "/inquiry/3/accept", http.NoBody)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Accept status code should be 401 but got %v", res.Result().StatusCode)
+	}
+	inquiryStore.AssertNotCalled(t, "Accept", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestInquiry_Accept_AlreadyAccepted(t *testing.T) {
+	transitionErr := &stores.InquiryTransitionError{InquiryId: 3, Status: models.InquiryAccepted, Target: models.InquiryAccepted}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Accept", mock.Anything, int64(3), "").Return(int64(0), transitionErr)
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("POST", "/inquiry/3/accept", http.NoBody)
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Fatalf("Accept status code should be 409 but got %v", res.Result().StatusCode)
+	}
+	body := res.Body.String()
+	if !strings.Contains(body, `"code":"invalid_transition"`) || !strings.Contains(body, `"status":"accepted"`) {
+		t.Errorf("Unexpected transition error body %v", body)
+	}
+}
+
+func TestInquiry_Accept_FullyBooked(t *testing.T) {
+	bookingErr := &stores.BookingError{Code: stores.BookingFullyBooked, Message: "Item is fully booked on requested dates", ItemId: 1}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Accept", mock.Anything, int64(3), "").Return(int64(0), errors.WithStack(bookingErr))
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("POST", "/inquiry/3/accept", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 || !strings.Contains(res.Body.String(), `"code":"fully_booked"`) {
+		t.Errorf("Expected fully booked conflict but got %v %v", res.Result().StatusCode, res.Body.String())
+	}
+}
+
+func TestInquiry_Reject_WithReason(t *testing.T) {
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Reject", mock.Anything, int64(3), "Closed for renovation").Return(nil)
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("POST", "/inquiry/3/reject", strings.NewReader(`{"note":"Closed for renovation"}`))
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Reject status code should be 200 but got %v", res.Result().StatusCode)
+	}
+	inquiryStore.AssertExpectations(t)
+}
+
+func TestInquiry_Reject_NotPending(t *testing.T) {
+	transitionErr := &stores.InquiryTransitionError{InquiryId: 3, Status: models.InquiryExpired, Target: models.InquiryRejected}
+
+	inquiryStore := &MyFakeInquiryStore{}
+	inquiryStore.On("Reject", mock.Anything, int64(3), "").Return(transitionErr)
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("POST", "/inquiry/3/reject", http.NoBody)
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Reject status code should be 409 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestInquiry_Delete_Unauthorized(t *testing.T) {
+	inquiryStore := &MyFakeInquiryStore{}
+	router := inquiryTestRouter(inquiryStore, &test_util.HcLogMock{}, t)
+
+	req, _ := http.NewRequest("DELETE", "/inquiry/3", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Delete status code should be 401 but got %v", res.Result().StatusCode)
+	}
+	inquiryStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
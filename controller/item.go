@@ -7,33 +7,56 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/alesbrelih/go-reservation-api/middleware"
 	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/alesbrelih/go-reservation-api/stores"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
 )
 
-var baseValidate *validator.Validate
-var createValidate *validator.Validate
-var updateValidate *validator.Validate
+const defaultAvailabilityDays = 30
+const maxAvailabilityDays = 366
 
-func init() {
-	baseValidate = validator.New()
+// validators are created before init functions, handlers register their
+// struct checks in init
+var baseValidate = validator.New()
+var createValidate = tagValidate("create")
+var updateValidate = tagValidate("update")
 
-	createValidate = validator.New()
-	createValidate.SetTagName("create")
+func tagValidate(tag string) *validator.Validate {
+	v := validator.New()
+	v.SetTagName(tag)
+	return v
+}
 
-	updateValidate = validator.New()
-	updateValidate.SetTagName("update")
+func init() {
+	createValidate.RegisterStructValidation(validateItemDatePrices, models.Item{})
+	updateValidate.RegisterStructValidation(validateItemDatePrices, models.Item{})
+}
+
+// every date price range of item must have price, tags of Money fields are
+// not run by validator (they are structs). Amount 0 is allowed (free range)
+// when currency is sent with it
+func validateItemDatePrices(sl validator.StructLevel) {
+	item := sl.Current().Interface().(models.Item)
+	for i, dp := range item.DatePrices {
+		if dp.Price.IsZero() {
+			field := fmt.Sprintf("DatePrices[%d].Price", i)
+			sl.ReportError(dp.Price.Amount, field, field, "required", "")
+		}
+	}
 }
 
-func NewItemHandler(store stores.ItemStore, log *log.Logger) ItemHandler {
+func NewItemHandler(store stores.ItemStore, pricing services.PricingService, log *log.Logger) ItemHandler {
 	return &itemHandler{
-		store: store,
-		log:   log,
+		store:   store,
+		pricing: pricing,
+		log:     log,
 	}
 }
 
@@ -43,16 +66,49 @@ type ItemHandler interface {
 	Create(w http.ResponseWriter, req *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
+	GetHistory(w http.ResponseWriter, r *http.Request)
+	GetComponents(w http.ResponseWriter, r *http.Request)
+	SetComponents(w http.ResponseWriter, r *http.Request)
+	GetUnits(w http.ResponseWriter, r *http.Request)
+	CreateUnit(w http.ResponseWriter, r *http.Request)
+	UpdateUnit(w http.ResponseWriter, r *http.Request)
+	DeleteUnit(w http.ResponseWriter, r *http.Request)
+	GetAvailability(w http.ResponseWriter, r *http.Request)
+	GetQuote(w http.ResponseWriter, r *http.Request)
+	GetStayQuote(w http.ResponseWriter, r *http.Request)
+	GetSlots(w http.ResponseWriter, r *http.Request)
+	GetPriceRules(w http.ResponseWriter, r *http.Request)
+	CreatePriceRule(w http.ResponseWriter, r *http.Request)
+	UpdatePriceRule(w http.ResponseWriter, r *http.Request)
+	DeletePriceRule(w http.ResponseWriter, r *http.Request)
+	GetSchedule(w http.ResponseWriter, r *http.Request)
+	SetSchedule(w http.ResponseWriter, r *http.Request)
+	GetBlackouts(w http.ResponseWriter, r *http.Request)
+	CreateBlackout(w http.ResponseWriter, r *http.Request)
+	DeleteBlackout(w http.ResponseWriter, r *http.Request)
 	NewItemRouter() *mux.Router
 }
 
 type itemHandler struct {
-	log   *log.Logger
-	store stores.ItemStore
+	log     *log.Logger
+	store   stores.ItemStore
+	pricing services.PricingService
 }
 
 func (h *itemHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	items, err := h.store.GetAll(r.Context())
+	filter, err := parseItemFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var items models.Items
+	if filter.IsEmpty() {
+		items, err = h.store.GetAll(r.Context())
+	} else {
+		items, err = h.store.Filter(r.Context(), filter)
+	}
 	if err != nil {
 		h.log.Printf("Error retrieving items: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -92,9 +148,38 @@ func (h *itemHandler) Create(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	id, err := h.store.Create(req.Context(), item)
+	if !validateDatePrices(w, item) {
+		return
+	}
+
+	if err := item.SlotConfigError(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.store.Create(req.Context(), item, requestUserId(req))
 
 	if err != nil {
+		if errors.Cause(err) == stores.ErrDatePriceConflict {
+			http.Error(w, stores.ErrDatePriceConflict.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnknownCategory {
+			http.Error(w, stores.ErrUnknownCategory.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnknownTenant {
+			http.Error(w, stores.ErrUnknownTenant.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnknownTaxClass {
+			http.Error(w, stores.ErrUnknownTaxClass.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == models.ErrCurrencyMismatch {
+			http.Error(w, models.ErrCurrencyMismatch.Error(), http.StatusBadRequest)
+			return
+		}
 		h.log.Printf("Error creating item: %#v. Error: %v", item, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -115,12 +200,41 @@ func (h *itemHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.store.Update(r.Context(), item)
+	if !validateDatePrices(w, item) {
+		return
+	}
+
+	if err := item.SlotConfigError(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.store.Update(r.Context(), item, requestUserId(r))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
+		if errors.Cause(err) == stores.ErrDatePriceConflict {
+			http.Error(w, stores.ErrDatePriceConflict.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnknownCategory {
+			http.Error(w, stores.ErrUnknownCategory.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnknownTenant {
+			http.Error(w, stores.ErrUnknownTenant.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnknownTaxClass {
+			http.Error(w, stores.ErrUnknownTaxClass.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == models.ErrCurrencyMismatch {
+			http.Error(w, models.ErrCurrencyMismatch.Error(), http.StatusBadRequest)
+			return
+		}
 		h.log.Printf("Error updating item: %#v. Error: %v", item, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -128,23 +242,298 @@ func (h *itemHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Delete archives item. With ?permanent=true item is removed for good,
+// which is only possible while no reservations refer to it
 func (h *itemHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
 	id, _ := strconv.Atoi(params["id"])
 
-	err := h.store.Delete(int64(id))
+	var err error
+	if r.URL.Query().Get("permanent") == "true" {
+		err = h.store.Purge(r.Context(), int64(id))
+	} else {
+		err = h.store.Delete(int64(id))
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Cause(err) == sql.ErrNoRows {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
+		if errors.Cause(err) == stores.ErrItemHasReservations {
+			http.Error(w, stores.ErrItemHasReservations.Error(), http.StatusConflict)
+			return
+		}
 		h.log.Printf("Error deleting item with id: %v. Error: %v", id, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// Restore brings archived item back to listings
+func (h *itemHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.Atoi(params["id"]) // validated by regex already
+
+	err := h.store.Restore(r.Context(), int64(id))
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error restoring item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetHistory returns versions of item title and prices. With ?at=<RFC 3339 time>
+// only version valid at that time is returned
+func (h *itemHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.Atoi(params["id"]) // validated by regex already
+
+	var at *time.Time
+	if query := r.URL.Query().Get("at"); query != "" {
+		parsed, err := time.Parse(time.RFC3339, query)
+		if err != nil {
+			http.Error(w, "Invalid at time", http.StatusBadRequest)
+			return
+		}
+		at = &parsed
+	}
+
+	history, err := h.store.GetHistory(r.Context(), int64(id))
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error retrieving history of item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if at == nil {
+		history.ToJSON(w)
+		return
+	}
+
+	version, ok := history.At(*at)
+	if !ok {
+		http.Error(w, "Item did not exist at given time", http.StatusNotFound)
+		return
+	}
+	version.ToJSON(w)
+}
+
+func (h *itemHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.Atoi(params["id"]) // validated by regex already
+
+	from, err := parseDateQuery(r, "from", models.TruncateDay(time.Now()))
+	if err != nil {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseDateQuery(r, "to", from.AddDate(0, 0, defaultAvailabilityDays))
+	if err != nil {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+
+	if to.Before(from) || to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		http.Error(w, fmt.Sprintf("Date range must be positive and at most %v days long", maxAvailabilityDays), http.StatusBadRequest)
+		return
+	}
+
+	availability, err := h.store.GetAvailability(r.Context(), int64(id), from, to)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error retrieving availability for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	availability.ToJSON(w)
+}
+
+func (h *itemHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.Atoi(params["id"]) // validated by regex already
+
+	date, err := parseDateQuery(r, "date", time.Now().UTC())
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.store.GetOne(r.Context(), int64(id))
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error retrieving item with id: %v for quote. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	quote, err := h.pricing.Quote(item, date)
+	if err != nil {
+		h.log.Printf("Error quoting item with id: %v on %v. Error: %v", id, date, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	quote.ToJSON(w)
+}
+
+func (h *itemHandler) GetStayQuote(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.Atoi(params["id"]) // validated by regex already
+
+	from, err := parseDateQuery(r, "from", models.TruncateDay(time.Now()))
+	if err != nil {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseDateQuery(r, "to", from.AddDate(0, 0, 1))
+	if err != nil {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+
+	if !models.TruncateDay(to).After(models.TruncateDay(from)) || to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		http.Error(w, fmt.Sprintf("Stay must be between 1 and %v nights long", maxAvailabilityDays), http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.store.GetOne(r.Context(), int64(id))
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error retrieving item with id: %v for stay quote. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	quote, err := h.pricing.QuoteStay(item, from, to)
+	if err != nil {
+		h.log.Printf("Error quoting stay of item with id: %v from %v to %v. Error: %v", id, from, to, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	quote.ToJSON(w)
+}
+
+// lists free slots of slot booked item on given day together with slot price
+func (h *itemHandler) GetSlots(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.Atoi(params["id"]) // validated by regex already
+
+	date, err := parseDateQuery(r, "date", models.TruncateDay(time.Now()))
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.store.GetOne(r.Context(), int64(id))
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error retrieving item with id: %v for slots. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !item.IsSlotBooked() {
+		http.Error(w, "Item is not booked by time slots", http.StatusBadRequest)
+		return
+	}
+
+	slots, err := h.store.GetSlots(r.Context(), item, date)
+	if err != nil {
+		h.log.Printf("Error retrieving slots for item with id: %v on %v. Error: %v", id, date, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	free := models.ItemSlots{}
+	for _, slot := range slots {
+		if slot.Remaining == 0 {
+			continue
+		}
+
+		quote, err := h.pricing.Quote(item, slot.Start)
+		if err != nil {
+			h.log.Printf("Error quoting slot of item with id: %v at %v. Error: %v", id, slot.Start, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		slot.Price = quote.Price
+		free = append(free, slot)
+	}
+
+	free.ToJSON(w)
+}
+
+// reads catalog filter from query parameters (category, tag, minPrice,
+// maxPrice, currency, search, availableOn). Tag can be repeated
+func parseItemFilter(r *http.Request) (*models.ItemFilter, error) {
+	query := r.URL.Query()
+	filter := &models.ItemFilter{
+		Tags:     query["tag"],
+		Currency: query.Get("currency"),
+		Search:   query.Get("search"),
+		Archived: query.Get("archived") == "true",
+	}
+
+	var err error
+	if filter.CategoryId, err = parseIntQuery(r, "category"); err != nil {
+		return nil, err
+	}
+	if filter.MinPrice, err = parseIntQuery(r, "minPrice"); err != nil {
+		return nil, err
+	}
+	if filter.MaxPrice, err = parseIntQuery(r, "maxPrice"); err != nil {
+		return nil, err
+	}
+
+	if query.Get("availableOn") != "" {
+		date, err := parseDateQuery(r, "availableOn", time.Time{})
+		if err != nil {
+			return nil, errors.New("Invalid availableOn date")
+		}
+		filter.AvailableOn = &date
+	}
+
+	return filter, nil
+}
+
+// checks that date price ranges of item are not inverted and do not overlap.
+// Writes list of conflicting ranges and returns false otherwise
+func validateDatePrices(w http.ResponseWriter, item *models.Item) bool {
+	conflicts := item.DatePriceConflicts()
+	if len(conflicts) == 0 {
+		return true
+	}
+
+	res := models.NewErrorResponse("Date price ranges are inverted or overlap", "date_price_conflict", conflicts)
+	writeErrorResponse(w, http.StatusBadRequest, res)
+	return false
+}
+
 func (h *itemHandler) NewItemRouter() *mux.Router {
 	r := mux.NewRouter()
 
@@ -153,6 +542,17 @@ func (h *itemHandler) NewItemRouter() *mux.Router {
 	getSubrouter := r.Methods(http.MethodGet).Subrouter()
 	getSubrouter.HandleFunc("/item", h.GetAll)
 	getSubrouter.HandleFunc("/item/{id:[\\d]+}", h.GetOne)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/availability", h.GetAvailability)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/quote", h.GetQuote)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/quote/stay", h.GetStayQuote)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/slots", h.GetSlots)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/rules", h.GetPriceRules)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/schedule", h.GetSchedule)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/blackouts", h.GetBlackouts)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/addons", h.GetAddons)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/history", h.GetHistory)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/components", h.GetComponents)
+	getSubrouter.HandleFunc("/item/{id:[\\d]+}/units", h.GetUnits)
 
 	postSubrouter := r.Methods(http.MethodPost).Subrouter()
 	postSubrouter.HandleFunc("/item", h.Create)
@@ -164,6 +564,28 @@ func (h *itemHandler) NewItemRouter() *mux.Router {
 
 	deleteSubgrouter := r.Methods(http.MethodDelete).Subrouter()
 	deleteSubgrouter.HandleFunc("/item/{id:[\\d]+}", h.Delete)
+	deleteSubgrouter.HandleFunc("/item/{id:[\\d]+}/rules/{ruleId:[\\d]+}", h.DeletePriceRule)
+	deleteSubgrouter.HandleFunc("/item/{id:[\\d]+}/blackouts/{blackoutId:[\\d]+}", h.DeleteBlackout)
+	deleteSubgrouter.HandleFunc("/item/{id:[\\d]+}/addons/{addonId:[\\d]+}", h.DeleteAddon)
+	deleteSubgrouter.HandleFunc("/item/{id:[\\d]+}/units/{unitId:[\\d]+}", h.DeleteUnit)
+
+	// price rules, schedule, blackouts, add-ons, components and units read their own body (not item)
+	rulesSubrouter := r.PathPrefix("/item/{id:[\\d]+}/rules").Subrouter()
+	rulesSubrouter.HandleFunc("", h.CreatePriceRule).Methods(http.MethodPost)
+	rulesSubrouter.HandleFunc("/{ruleId:[\\d]+}", h.UpdatePriceRule).Methods(http.MethodPut)
+
+	r.HandleFunc("/item/{id:[\\d]+}/restore", h.Restore).Methods(http.MethodPost)
+	r.HandleFunc("/item/{id:[\\d]+}/schedule", h.SetSchedule).Methods(http.MethodPut)
+	r.HandleFunc("/item/{id:[\\d]+}/components", h.SetComponents).Methods(http.MethodPut)
+	r.HandleFunc("/item/{id:[\\d]+}/blackouts", h.CreateBlackout).Methods(http.MethodPost)
+
+	addonsSubrouter := r.PathPrefix("/item/{id:[\\d]+}/addons").Subrouter()
+	addonsSubrouter.HandleFunc("", h.CreateAddon).Methods(http.MethodPost)
+	addonsSubrouter.HandleFunc("/{addonId:[\\d]+}", h.UpdateAddon).Methods(http.MethodPut)
+
+	unitsSubrouter := r.PathPrefix("/item/{id:[\\d]+}/units").Subrouter()
+	unitsSubrouter.HandleFunc("", h.CreateUnit).Methods(http.MethodPost)
+	unitsSubrouter.HandleFunc("/{unitId:[\\d]+}", h.UpdateUnit).Methods(http.MethodPut)
 
 	return r
 }
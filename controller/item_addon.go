@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (h *itemHandler) GetAddons(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	addons, err := h.store.GetAddons(r.Context(), id)
+	if err != nil {
+		h.log.Printf("Error retrieving add-ons for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	addons.ToJSON(w)
+}
+
+func (h *itemHandler) CreateAddon(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	addon, ok := h.readAddon(w, r)
+	if !ok {
+		return
+	}
+	addon.ItemId = id
+
+	addonId, err := h.store.CreateAddon(r.Context(), addon)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == models.ErrCurrencyMismatch {
+			http.Error(w, "Add-on must be priced in item currency", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error creating add-on: %#v. Error: %v", addon, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(addonId).ToJSON(w)
+}
+
+func (h *itemHandler) UpdateAddon(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)           // validated by regex already
+	addonId, _ := strconv.ParseInt(params["addonId"], 10, 64) // validated by regex already
+
+	addon, ok := h.readAddon(w, r)
+	if !ok {
+		return
+	}
+	addon.Id = addonId
+	addon.ItemId = id
+
+	err := h.store.UpdateAddon(r.Context(), addon)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == models.ErrCurrencyMismatch {
+			http.Error(w, "Add-on must be priced in item currency", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error updating add-on: %#v. Error: %v", addon, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *itemHandler) DeleteAddon(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)           // validated by regex already
+	addonId, _ := strconv.ParseInt(params["addonId"], 10, 64) // validated by regex already
+
+	err := h.store.DeleteAddon(r.Context(), id, addonId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error deleting add-on with id: %v. Error: %v", addonId, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// reads and validates add-on from request body. Writes error response
+// and returns false if body is invalid
+func (h *itemHandler) readAddon(w http.ResponseWriter, r *http.Request) (*models.ItemAddon, bool) {
+	addon := &models.ItemAddon{}
+	err := addon.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := baseValidate.Struct(addon); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if addon.Price.Amount < 0 {
+		http.Error(w, "Add-on price can not be negative", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return addon, true
+}
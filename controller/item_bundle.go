@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (h *itemHandler) GetComponents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	components, err := h.store.GetComponents(r.Context(), id)
+	if err != nil {
+		h.log.Printf("Error retrieving components of bundle with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	components.ToJSON(w)
+}
+
+// SetComponents replaces components of bundle. Empty list makes bundle plain item again
+func (h *itemHandler) SetComponents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	components := models.BundleComponents{}
+	err := components.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	for i := range components {
+		if err := baseValidate.Struct(&components[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.store.SetComponents(r.Context(), id, components)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrInvalidBundle {
+			http.Error(w, stores.ErrInvalidBundle.Error(), http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error updating components of bundle with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
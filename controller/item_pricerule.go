@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (h *itemHandler) GetPriceRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rules, err := h.store.GetPriceRules(r.Context(), id)
+	if err != nil {
+		h.log.Printf("Error retrieving price rules for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rules.ToJSON(w)
+}
+
+func (h *itemHandler) CreatePriceRule(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rule, ok := h.readPriceRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ItemId = id
+
+	ruleId, err := h.store.CreatePriceRule(r.Context(), rule)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error creating price rule: %#v. Error: %v", rule, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(ruleId).ToJSON(w)
+}
+
+func (h *itemHandler) UpdatePriceRule(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)         // validated by regex already
+	ruleId, _ := strconv.ParseInt(params["ruleId"], 10, 64) // validated by regex already
+
+	rule, ok := h.readPriceRule(w, r)
+	if !ok {
+		return
+	}
+	rule.Id = ruleId
+	rule.ItemId = id
+
+	err := h.store.UpdatePriceRule(r.Context(), rule)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error updating price rule: %#v. Error: %v", rule, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *itemHandler) DeletePriceRule(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)         // validated by regex already
+	ruleId, _ := strconv.ParseInt(params["ruleId"], 10, 64) // validated by regex already
+
+	err := h.store.DeletePriceRule(r.Context(), id, ruleId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error deleting price rule with id: %v. Error: %v", ruleId, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// reads and validates price rule from request body. Writes error response
+// and returns false if body is invalid
+func (h *itemHandler) readPriceRule(w http.ResponseWriter, r *http.Request) (*models.ItemPriceRule, bool) {
+	rule := &models.ItemPriceRule{}
+	err := rule.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := baseValidate.Struct(rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if rule.MinLeadDays != nil && rule.MaxLeadDays != nil && *rule.MinLeadDays > *rule.MaxLeadDays {
+		http.Error(w, fmt.Sprintf("minLeadDays (%v) is greater than maxLeadDays (%v)", *rule.MinLeadDays, *rule.MaxLeadDays), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if rule.DateFrom != nil && rule.DateTo != nil && rule.DateFrom.After(*rule.DateTo) {
+		http.Error(w, "dateFrom is after dateTo", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return rule, true
+}
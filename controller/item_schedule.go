@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (h *itemHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	schedule, err := h.store.GetSchedule(r.Context(), id)
+	if err != nil {
+		h.log.Printf("Error retrieving schedule for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	schedule.ToJSON(w)
+}
+
+func (h *itemHandler) SetSchedule(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	schedule := models.ItemScheduleList{}
+	err := schedule.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	for i := range schedule {
+		if err := baseValidate.Struct(&schedule[i]); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, _, err := schedule[i].Hours(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid hours of schedule entry %v: %v", i, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.store.SetSchedule(r.Context(), id, schedule)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error updating schedule for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *itemHandler) GetBlackouts(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	blackouts, err := h.store.GetBlackouts(r.Context(), id)
+	if err != nil {
+		h.log.Printf("Error retrieving blackouts for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	blackouts.ToJSON(w)
+}
+
+func (h *itemHandler) CreateBlackout(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	blackout := &models.ItemBlackout{}
+	err := blackout.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := baseValidate.Struct(blackout); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	blackout.ItemId = id
+
+	blackoutId, err := h.store.CreateBlackout(r.Context(), blackout)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error creating blackout: %#v. Error: %v", blackout, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(blackoutId).ToJSON(w)
+}
+
+func (h *itemHandler) DeleteBlackout(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)                 // validated by regex already
+	blackoutId, _ := strconv.ParseInt(params["blackoutId"], 10, 64) // validated by regex already
+
+	err := h.store.DeleteBlackout(r.Context(), id, blackoutId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error deleting blackout with id: %v. Error: %v", blackoutId, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
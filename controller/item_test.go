@@ -3,16 +3,22 @@ package controller_test
 import (
 	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"code.soquee.net/testlog"
 	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/middleware"
 	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
 )
@@ -26,30 +32,174 @@ func (h *MyFakeItemStore) GetAll(ctx context.Context) (models.Items, error) {
 	return args.Get(0).(models.Items), args.Error(1)
 }
 
+func (h *MyFakeItemStore) Filter(ctx context.Context, filter *models.ItemFilter) (models.Items, error) {
+	args := h.Called(ctx, filter)
+	return args.Get(0).(models.Items), args.Error(1)
+}
+
+func (h *MyFakeItemStore) GetVisible(ctx context.Context, filter *models.ItemFilter, at time.Time) (models.Items, error) {
+	args := h.Called(ctx, filter, at)
+	return args.Get(0).(models.Items), args.Error(1)
+}
+
 func (h *MyFakeItemStore) GetOne(ctx context.Context, id int64) (*models.Item, error) {
 	args := h.Called(ctx, id)
 	return args.Get(0).(*models.Item), args.Error(1)
 }
 
-func (h *MyFakeItemStore) Create(ctx context.Context, item *models.Item) (int64, error) {
-	args := h.Called(ctx, item)
+func (h *MyFakeItemStore) Create(ctx context.Context, item *models.Item, changedBy *int64) (int64, error) {
+	args := h.Called(ctx, item, changedBy)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeItemStore) Update(ctx context.Context, item *models.Item, changedBy *int64) error {
+	args := h.Called(ctx, item, changedBy)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) GetComponents(ctx context.Context, bundleId int64) (models.BundleComponents, error) {
+	args := h.Called(ctx, bundleId)
+	if components, ok := args.Get(0).(models.BundleComponents); ok {
+		return components, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (h *MyFakeItemStore) SetComponents(ctx context.Context, bundleId int64, components models.BundleComponents) error {
+	args := h.Called(ctx, bundleId, components)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) GetUnits(ctx context.Context, itemId int64) (models.ItemUnits, error) {
+	args := h.Called(ctx, itemId)
+	if units, ok := args.Get(0).(models.ItemUnits); ok {
+		return units, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (h *MyFakeItemStore) CreateUnit(ctx context.Context, unit *models.ItemUnit) (int64, error) {
+	args := h.Called(ctx, unit)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (h *MyFakeItemStore) Update(ctx context.Context, item *models.Item) error {
-	args := h.Called(ctx, item)
+func (h *MyFakeItemStore) UpdateUnit(ctx context.Context, unit *models.ItemUnit) error {
+	args := h.Called(ctx, unit)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) DeleteUnit(ctx context.Context, itemId int64, unitId int64) error {
+	args := h.Called(ctx, itemId, unitId)
 	return args.Error(0)
 }
 
+func (h *MyFakeItemStore) GetHistory(ctx context.Context, id int64) (models.ItemHistory, error) {
+	args := h.Called(ctx, id)
+	if history, ok := args.Get(0).(models.ItemHistory); ok {
+		return history, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (h *MyFakeItemStore) Delete(id int64) error {
 	args := h.Called(id)
 	return args.Error(0)
 }
 
+func (h *MyFakeItemStore) Restore(ctx context.Context, id int64) error {
+	args := h.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) Purge(ctx context.Context, id int64) error {
+	args := h.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) GetAvailability(ctx context.Context, id int64, from time.Time, to time.Time) (models.ItemAvailabilityList, error) {
+	args := h.Called(ctx, id, from, to)
+	return args.Get(0).(models.ItemAvailabilityList), args.Error(1)
+}
+
+func (h *MyFakeItemStore) SearchAvailable(ctx context.Context, search *models.AvailabilitySearch, at time.Time) ([]models.AvailableItem, error) {
+	args := h.Called(ctx, search, at)
+	return args.Get(0).([]models.AvailableItem), args.Error(1)
+}
+
+func (h *MyFakeItemStore) GetPriceRules(ctx context.Context, itemId int64) (models.ItemPriceRules, error) {
+	args := h.Called(ctx, itemId)
+	return args.Get(0).(models.ItemPriceRules), args.Error(1)
+}
+
+func (h *MyFakeItemStore) CreatePriceRule(ctx context.Context, rule *models.ItemPriceRule) (int64, error) {
+	args := h.Called(ctx, rule)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeItemStore) UpdatePriceRule(ctx context.Context, rule *models.ItemPriceRule) error {
+	args := h.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) DeletePriceRule(ctx context.Context, itemId int64, ruleId int64) error {
+	args := h.Called(ctx, itemId, ruleId)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) GetSlots(ctx context.Context, item *models.Item, date time.Time) (models.ItemSlots, error) {
+	args := h.Called(ctx, item, date)
+	return args.Get(0).(models.ItemSlots), args.Error(1)
+}
+
+func (h *MyFakeItemStore) GetSchedule(ctx context.Context, itemId int64) (models.ItemScheduleList, error) {
+	args := h.Called(ctx, itemId)
+	return args.Get(0).(models.ItemScheduleList), args.Error(1)
+}
+
+func (h *MyFakeItemStore) SetSchedule(ctx context.Context, itemId int64, schedule models.ItemScheduleList) error {
+	args := h.Called(ctx, itemId, schedule)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) GetBlackouts(ctx context.Context, itemId int64) (models.ItemBlackouts, error) {
+	args := h.Called(ctx, itemId)
+	return args.Get(0).(models.ItemBlackouts), args.Error(1)
+}
+
+func (h *MyFakeItemStore) CreateBlackout(ctx context.Context, blackout *models.ItemBlackout) (int64, error) {
+	args := h.Called(ctx, blackout)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeItemStore) DeleteBlackout(ctx context.Context, itemId int64, blackoutId int64) error {
+	args := h.Called(ctx, itemId, blackoutId)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) GetAddons(ctx context.Context, itemId int64) (models.ItemAddons, error) {
+	args := h.Called(ctx, itemId)
+	return args.Get(0).(models.ItemAddons), args.Error(1)
+}
+
+func (h *MyFakeItemStore) CreateAddon(ctx context.Context, addon *models.ItemAddon) (int64, error) {
+	args := h.Called(ctx, addon)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeItemStore) UpdateAddon(ctx context.Context, addon *models.ItemAddon) error {
+	args := h.Called(ctx, addon)
+	return args.Error(0)
+}
+
+func (h *MyFakeItemStore) DeleteAddon(ctx context.Context, itemId int64, addonId int64) error {
+	args := h.Called(ctx, itemId, addonId)
+	return args.Error(0)
+}
+
 func testRouter(store stores.ItemStore, t *testing.T) *mux.Router {
 	r := mux.NewRouter()
 
-	itemHandler := controller.NewItemHandler(store, testlog.New(t))
+	itemHandler := controller.NewItemHandler(store, services.NewPricingService(), testlog.New(t))
 	r.PathPrefix("/item").Handler(itemHandler.NewItemRouter())
 	return r
 }
@@ -178,7 +328,7 @@ func TestItem_GetOne_RoutingOnMixedParameter(t *testing.T) {
 func TestItem_Create_Success(t *testing.T) {
 
 	itemStore := &MyFakeItemStore{}
-	itemStore.On("Create", mock.Anything, mock.Anything).Return(int64(1), nil)
+	itemStore.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(int64(1), nil)
 	router := testRouter(itemStore, t)
 
 	jsonStr := []byte(`{"title":"MyTitle","ShowFrom":"2020-11-07T13:37:09.511Z","ShowTo":"2021-11-07T13:37:09.511Z"}`)
@@ -193,13 +343,13 @@ func TestItem_Create_Success(t *testing.T) {
 
 	json := &models.Item{}
 	json.FromJSON(bytes.NewBuffer(jsonStr))
-	itemStore.AssertCalled(t, "Create", mock.Anything, json)
+	itemStore.AssertCalled(t, "Create", mock.Anything, json, (*int64)(nil))
 }
 
 func TestItem_Create_BadRequest_TitleLength(t *testing.T) {
 
 	itemStore := &MyFakeItemStore{}
-	itemStore.On("Create", mock.Anything, mock.Anything).Return(int64(1), nil)
+	itemStore.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(int64(1), nil)
 	router := testRouter(itemStore, t)
 
 	jsonStr := []byte(`{"title":"My","ShowFrom":"2020-11-07T13:37:09.511Z","ShowTo":"2021-11-07T13:37:09.511Z"}`)
@@ -221,7 +371,7 @@ func TestItem_Create_BadRequest_TitleLength(t *testing.T) {
 func TestItem_Create_BadRequest_TitleMissing(t *testing.T) {
 
 	itemStore := &MyFakeItemStore{}
-	itemStore.On("Create", mock.Anything, mock.Anything).Return(int64(1), nil)
+	itemStore.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(int64(1), nil)
 	router := testRouter(itemStore, t)
 
 	jsonStr := []byte(`{"ShowFrom":"2020-11-07T13:37:09.511Z","ShowTo":"2021-11-07T13:37:09.511Z"}`)
@@ -243,7 +393,7 @@ func TestItem_Create_BadRequest_TitleMissing(t *testing.T) {
 func TestItem_Create_DbError(t *testing.T) {
 
 	itemStore := &MyFakeItemStore{}
-	itemStore.On("Create", mock.Anything, mock.Anything).Return(int64(0), errors.New("Some error"))
+	itemStore.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), errors.New("Some error"))
 	router := testRouter(itemStore, t)
 
 	jsonStr := []byte(`{"title":"MyTitle","ShowFrom":"2020-11-07T13:37:09.511Z","ShowTo":"2021-11-07T13:37:09.511Z"}`)
@@ -264,7 +414,7 @@ func TestItem_Create_DbError(t *testing.T) {
 func TestItem_Update_Success(t *testing.T) {
 
 	itemStore := &MyFakeItemStore{}
-	itemStore.On("Update", mock.Anything, mock.Anything).Return(nil)
+	itemStore.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	router := testRouter(itemStore, t)
 
 	jsonStr := []byte(`{"id":1,"title":"MyTitle","ShowFrom":"2020-11-07T13:37:09.511Z","ShowTo":"2021-11-07T13:37:09.511Z"}`)
@@ -283,7 +433,50 @@ func TestItem_Update_Success(t *testing.T) {
 
 	json := &models.Item{}
 	json.FromJSON(bytes.NewBuffer(jsonStr))
-	itemStore.AssertCalled(t, "Update", mock.Anything, json)
+	itemStore.AssertCalled(t, "Update", mock.Anything, json, (*int64)(nil))
+}
+
+func TestItem_Update_ChangedByJwtSubject(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Update", mock.Anything, mock.Anything, mock.MatchedBy(func(changedBy *int64) bool {
+		return changedBy != nil && *changedBy == 7
+	})).Return(nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"id":1,"title":"MyTitle"}`)
+	req, _ := http.NewRequest("PUT", "/item", bytes.NewBuffer(jsonStr))
+	claims := &jwt.StandardClaims{Subject: "7"}
+	req = req.WithContext(context.WithValue(req.Context(), &middleware.JwtClaimsContextKey{}, claims))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Update status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertExpectations(t)
+}
+
+func TestItem_Update_ClearsCategoryAndTaxClass(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Update", mock.Anything, mock.MatchedBy(func(item *models.Item) bool {
+		return item.CategoryId != nil && *item.CategoryId == 0 && item.TaxClassId != nil && *item.TaxClassId == 0 &&
+			item.TenantId == nil
+	}), mock.Anything).Return(nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"id":1,"title":"MyTitle","categoryId":0,"taxClassId":0}`)
+	req, _ := http.NewRequest("PUT", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Update status code should be 200 but got %v: %v", res.Result().StatusCode, res.Body.String())
+	}
+
+	itemStore.AssertExpectations(t)
 }
 
 func TestItem_Update_BadRequest_TitleLength(t *testing.T) {
@@ -352,7 +545,7 @@ func TestItem_Update_BadRequest_IdMissing(t *testing.T) {
 func TestItem_Update_DbError(t *testing.T) {
 
 	itemStore := &MyFakeItemStore{}
-	itemStore.On("Update", mock.Anything, mock.Anything).Return(errors.New("Some error"))
+	itemStore.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("Some error"))
 	router := testRouter(itemStore, t)
 
 	jsonStr := []byte(`{"id":5,"title":"MyTitle","ShowFrom":"2020-11-07T13:37:09.511Z","ShowTo":"2021-11-07T13:37:09.511Z"}`)
@@ -441,3 +634,835 @@ func TestItem_Delete_DbError(t *testing.T) {
 		t.Errorf("Response body should be %#v but got %#v", "Internal server error", res.Body.String())
 	}
 }
+
+func TestItem_Delete_PermanentWithReservations(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Purge", mock.Anything, int64(1)).Return(stores.ErrItemHasReservations)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("DELETE", "/item/1?permanent=true", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Delete status code should be 409 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertExpectations(t)
+	itemStore.AssertNotCalled(t, "Delete", int64(1))
+}
+
+func TestItem_Restore_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Restore", mock.Anything, int64(1)).Return(nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("POST", "/item/1/restore", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Restore status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertExpectations(t)
+}
+
+func TestItem_Restore_NotArchived(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Restore", mock.Anything, int64(1)).Return(sql.ErrNoRows)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("POST", "/item/1/restore", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Restore status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func itemHistory() models.ItemHistory {
+	first, second := "Room", "Large room"
+	history := models.ItemHistory{
+		{ItemId: 1, Version: 1, ChangedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			Title: &first, Price: models.NewMoney(100, "EUR")},
+		{ItemId: 1, Version: 2, ChangedAt: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+			Title: &second, Price: models.NewMoney(120, "EUR")},
+	}
+	history.SetChanges()
+	return history
+}
+
+func TestItem_GetHistory_JSONFromDb(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetHistory", mock.Anything, int64(1)).Return(itemHistory(), nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/history", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("History status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	var history models.ItemHistory
+	if err := json.NewDecoder(res.Body).Decode(&history); err != nil {
+		t.Fatalf("Error decoding history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 versions but got %v", len(history))
+	}
+	if len(history[0].Changes) != 0 {
+		t.Errorf("First version should have no changes but got %v", history[0].Changes)
+	}
+	expected := []string{models.ItemHistoryTitle, models.ItemHistoryPrice}
+	if strings.Join(history[1].Changes, ",") != strings.Join(expected, ",") {
+		t.Errorf("Second version changes should be %v but got %v", expected, history[1].Changes)
+	}
+}
+
+func TestItem_GetHistory_VersionAtTime(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetHistory", mock.Anything, int64(1)).Return(itemHistory(), nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/history?at=2021-03-15T10:00:00Z", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("History status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	version := &models.ItemVersion{}
+	if err := json.NewDecoder(res.Body).Decode(version); err != nil {
+		t.Fatalf("Error decoding version: %v", err)
+	}
+	if version.Version != 1 || version.Price.Amount != 100 {
+		t.Errorf("Expected version 1 with price 100 but got version %v with price %v", version.Version, version.Price.Amount)
+	}
+}
+
+func TestItem_GetHistory_BeforeCreated(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetHistory", mock.Anything, int64(1)).Return(itemHistory(), nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/history?at=2020-03-15T10:00:00Z", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 404 {
+		t.Errorf("History status code should be 404 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_GetAvailability_JSONFromDb(t *testing.T) {
+	from := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC)
+
+	mockedAvailability := models.ItemAvailabilityList{
+		{Date: from, Capacity: 2, Booked: 2, Remaining: 0},
+		{Date: to, Capacity: 2, Booked: 1, Remaining: 1},
+	}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetAvailability", mock.Anything, int64(1), from, to).Return(mockedAvailability, nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/availability?from=2021-08-01&to=2021-08-02", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Get availability status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	buf := new(bytes.Buffer)
+	mockedAvailability.ToJSON(buf)
+	if res.Body.String() != buf.String() {
+		t.Errorf("Response body should be %#v but got %#v", buf.String(), res.Body.String())
+	}
+
+	itemStore.AssertExpectations(t)
+}
+
+func TestItem_GetAvailability_InvalidDate(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/availability?from=yesterday", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get availability status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_GetAvailability_ReversedRange(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/availability?from=2021-08-10&to=2021-08-01", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get availability status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "GetAvailability", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_GetAvailability_NoItem(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetAvailability", mock.Anything, int64(1), mock.Anything, mock.Anything).
+		Return(models.ItemAvailabilityList(nil), sql.ErrNoRows)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/availability", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get availability status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_GetQuote_DatePriceApplied(t *testing.T) {
+	title := "Hello"
+	mockedItem := &models.Item{
+		Id:    1,
+		Title: &title,
+		Price: models.NewMoney(100, "EUR"),
+		DatePrices: []models.ItemDatePrice{
+			{
+				Id:       3,
+				DateFrom: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+				DateTo:   time.Date(2022, 8, 31, 0, 0, 0, 0, time.UTC),
+				Price:    models.NewMoney(250, "EUR"),
+			},
+		},
+	}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetOne", mock.Anything, int64(1)).Return(mockedItem, nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/quote?date=2022-07-15", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Get quote status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	quote := &models.PriceQuote{}
+	if err := json.NewDecoder(res.Body).Decode(quote); err != nil {
+		t.Fatal(err)
+	}
+
+	if quote.Price.Amount != 250 || quote.Source != models.PriceSourceDateRange || quote.DatePrice.Id != 3 {
+		t.Errorf("Expected date range price 250 from range 3 but got %#v", quote)
+	}
+}
+
+func TestItem_GetQuote_InvalidDate(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/quote?date=next-summer", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get quote status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_CreatePriceRule_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("CreatePriceRule", mock.Anything, mock.Anything).Return(int64(7), nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"Weekend surcharge","priority":1,"weekdays":[0,6],"modifierType":"percent","modifier":20}`)
+	req, _ := http.NewRequest("POST", "/item/3/rules", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create price rule status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	expected := &models.ItemPriceRule{}
+	expected.FromJSON(bytes.NewBuffer(jsonStr))
+	expected.ItemId = 3
+	itemStore.AssertCalled(t, "CreatePriceRule", mock.Anything, expected)
+}
+
+func TestItem_CreatePriceRule_BadRequest_ModifierType(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"Weekend surcharge","modifierType":"double","modifier":20}`)
+	req, _ := http.NewRequest("POST", "/item/3/rules", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create price rule status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	expectedErr := "Key: 'ItemPriceRule.ModifierType' Error:Field validation for 'ModifierType' failed on the 'oneof' tag\n"
+	if res.Body.String() != expectedErr {
+		t.Errorf("Expectes body to be: %v but got: %v", expectedErr, res.Body.String())
+	}
+}
+
+func TestItem_UpdatePriceRule_NoRows(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("UpdatePriceRule", mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"Early bird","minLeadDays":60,"modifierType":"percent","modifier":-10}`)
+	req, _ := http.NewRequest("PUT", "/item/3/rules/9", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Update price rule status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_DeletePriceRule_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("DeletePriceRule", mock.Anything, int64(3), int64(9)).Return(nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("DELETE", "/item/3/rules/9", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Delete price rule status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertExpectations(t)
+}
+
+func TestItem_Create_BadRequest_OverlappingDatePrices(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"MyTitle","datePrices":[
+		{"dateFrom":"2022-06-01T00:00:00Z","dateTo":"2022-06-30T00:00:00Z","price":100},
+		{"dateFrom":"2022-07-01T00:00:00Z","dateTo":"2022-07-31T00:00:00Z","price":200},
+		{"dateFrom":"2022-06-30T00:00:00Z","dateTo":"2022-07-05T00:00:00Z","price":300}]}`)
+	req, _ := http.NewRequest("POST", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	body := struct {
+		Code    string                     `json:"code"`
+		Details []models.DatePriceConflict `json:"details"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Code != "date_price_conflict" || len(body.Details) != 2 {
+		t.Fatalf("Expected two date price conflicts but got %#v", body)
+	}
+
+	if body.Details[0].Indexes[0] != 0 || body.Details[0].Indexes[1] != 2 ||
+		body.Details[1].Indexes[0] != 1 || body.Details[1].Indexes[1] != 2 {
+		t.Errorf("Expected conflicting pairs [0 2] and [1 2] but got %#v", body.Details)
+	}
+
+	itemStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_Create_BadRequest_DatePriceWithoutPrice(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"MyTitle","datePrices":[
+		{"dateFrom":"2022-06-01T00:00:00Z","dateTo":"2022-06-30T00:00:00Z"}]}`)
+	req, _ := http.NewRequest("POST", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_Create_FreeDatePrice(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Create", mock.Anything, mock.MatchedBy(func(item *models.Item) bool {
+		return len(item.DatePrices) == 1 && item.DatePrices[0].Price == models.NewMoney(0, "EUR")
+	}), mock.Anything).Return(int64(1), nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"MyTitle","datePrices":[
+		{"dateFrom":"2022-06-01T00:00:00Z","dateTo":"2022-06-30T00:00:00Z","price":{"amount":0,"currency":"EUR"}}]}`)
+	req, _ := http.NewRequest("POST", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v: %v", res.Result().StatusCode, res.Body.String())
+	}
+
+	itemStore.AssertExpectations(t)
+}
+
+func TestItem_Update_BadRequest_InvertedDatePrice(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"id":1,"title":"MyTitle","datePrices":[
+		{"id":4,"dateFrom":"2022-06-30T00:00:00Z","dateTo":"2022-06-01T00:00:00Z","price":100}]}`)
+	req, _ := http.NewRequest("PUT", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Update status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	expected := `{"error":"Date price ranges are inverted or overlap","code":"date_price_conflict","details":[{"reason":"inverted","indexes":[0],"ranges":[{"id":4,"dateFrom":"2022-06-30T00:00:00Z","dateTo":"2022-06-01T00:00:00Z","price":{"amount":100}}]}]}`
+	if strings.TrimSpace(res.Body.String()) != expected {
+		t.Errorf("Expected body %v but got %v", expected, res.Body.String())
+	}
+}
+
+func TestItem_Update_DatePriceConflictInDb(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(stores.ErrDatePriceConflict)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"id":1,"title":"MyTitle","datePrices":[
+		{"id":4,"dateFrom":"2022-06-01T00:00:00Z","dateTo":"2022-06-30T00:00:00Z","price":100}]}`)
+	req, _ := http.NewRequest("PUT", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Update status code should be 409 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_GetStayQuote_Total(t *testing.T) {
+	title := "Hello"
+	mockedItem := &models.Item{Id: 1, Title: &title, Price: models.NewMoney(100, "EUR")}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetOne", mock.Anything, int64(1)).Return(mockedItem, nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/quote/stay?from=2022-07-01&to=2022-07-04", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Get stay quote status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	quote := &models.StayQuote{}
+	if err := json.NewDecoder(res.Body).Decode(quote); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(quote.Nights) != 3 || quote.Total.Amount != 300 {
+		t.Errorf("Expected 3 nights with total 300 but got %#v", quote)
+	}
+}
+
+func TestItem_GetSlots_FreeSlotsWithPrice(t *testing.T) {
+	title := "Meeting room"
+	opensAt := "09:00"
+	closesAt := "12:00"
+	mockedItem := &models.Item{
+		Id:          1,
+		Title:       &title,
+		Price:       models.NewMoney(40, "EUR"),
+		Capacity:    1,
+		BookingMode: models.BookingModeSlot,
+		SlotMinutes: 60,
+		OpensAt:     &opensAt,
+		ClosesAt:    &closesAt,
+	}
+
+	day := time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC)
+	slot := func(hour int, booked int64) models.ItemSlot {
+		start := day.Add(time.Duration(hour) * time.Hour)
+		return models.ItemSlot{Start: start, End: start.Add(time.Hour), Capacity: 1, Booked: booked, Remaining: 1 - booked}
+	}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetOne", mock.Anything, int64(1)).Return(mockedItem, nil)
+	itemStore.On("GetSlots", mock.Anything, mockedItem, day).Return(models.ItemSlots{slot(9, 0), slot(10, 1), slot(11, 0)}, nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/slots?date=2022-07-15", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Get slots status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	slots := models.ItemSlots{}
+	if err := json.NewDecoder(res.Body).Decode(&slots); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(slots) != 2 || slots[0].Start.Hour() != 9 || slots[1].Start.Hour() != 11 {
+		t.Fatalf("Expected free slots at 9 and 11 but got %#v", slots)
+	}
+
+	if slots[0].Price.Amount != 40 {
+		t.Errorf("Expected slot price 40 but got %v", slots[0].Price)
+	}
+}
+
+func TestItem_GetSlots_DailyItem(t *testing.T) {
+	title := "Apartment"
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetOne", mock.Anything, int64(1)).Return(&models.Item{Id: 1, Title: &title, BookingMode: models.BookingModeDaily}, nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item/1/slots?date=2022-07-15", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get slots status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "GetSlots", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_Create_BadRequest_SlotConfigMissing(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"Meeting room","bookingMode":"slot","slotMinutes":60,"opensAt":"09:00"}`)
+	req, _ := http.NewRequest("POST", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_Create_SlotItemSuccess(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(int64(1), nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"Meeting room","bookingMode":"slot","slotMinutes":30,"opensAt":"08:00","closesAt":"18:30"}`)
+	req, _ := http.NewRequest("POST", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_SetSchedule_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("SetSchedule", mock.Anything, int64(3), mock.Anything).Return(nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`[{"weekday":1,"opensAt":"08:00","closesAt":"12:00"},{"weekday":1,"opensAt":"13:00","closesAt":"17:00"},{"weekday":6}]`)
+	req, _ := http.NewRequest("PUT", "/item/3/schedule", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Set schedule status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	schedule := itemStore.Calls[0].Arguments.Get(2).(models.ItemScheduleList)
+	if len(schedule) != 3 || schedule[2].Weekday != 6 || schedule[2].OpensAt != nil {
+		t.Errorf("Expected 3 schedule entries with whole saturday but got %#v", schedule)
+	}
+}
+
+func TestItem_SetSchedule_BadRequest_Hours(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`[{"weekday":1,"opensAt":"18:00","closesAt":"08:00"}]`)
+	req, _ := http.NewRequest("PUT", "/item/3/schedule", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Set schedule status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "SetSchedule", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_SetComponents_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("SetComponents", mock.Anything, int64(3), mock.Anything).Return(nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`[{"itemId":1,"quantity":1},{"itemId":2,"quantity":2}]`)
+	req, _ := http.NewRequest("PUT", "/item/3/components", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Set components status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	components := itemStore.Calls[0].Arguments.Get(2).(models.BundleComponents)
+	if len(components) != 2 || components[1].ItemId != 2 || components[1].Quantity != 2 {
+		t.Errorf("Expected 2 components with 2 units of item 2 but got %#v", components)
+	}
+}
+
+func TestItem_SetComponents_BadRequest_Quantity(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`[{"itemId":1,"quantity":0}]`)
+	req, _ := http.NewRequest("PUT", "/item/3/components", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Set components status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "SetComponents", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestItem_SetComponents_InvalidBundle(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("SetComponents", mock.Anything, int64(3), mock.Anything).Return(stores.ErrInvalidBundle)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`[{"itemId":4,"quantity":1}]`)
+	req, _ := http.NewRequest("PUT", "/item/3/components", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Set components status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	expected := stores.ErrInvalidBundle.Error() + "\n"
+	if res.Body.String() != expected {
+		t.Errorf("Response body should be %#v but got %#v", expected, res.Body.String())
+	}
+}
+
+func TestItem_CreateUnit_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("CreateUnit", mock.Anything, mock.Anything).Return(int64(9), nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("POST", "/item/3/units", strings.NewReader(`{"label":"Kayak 01"}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create unit status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	unit := itemStore.Calls[0].Arguments.Get(1).(*models.ItemUnit)
+	if unit.ItemId != 3 || unit.Label != "Kayak 01" {
+		t.Errorf("Expected unit Kayak 01 of item 3 but got %#v", unit)
+	}
+}
+
+func TestItem_UpdateUnit_BadRequest_Status(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("PUT", "/item/3/units/9", strings.NewReader(`{"label":"Kayak 01","status":"broken"}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Update unit status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "UpdateUnit", mock.Anything, mock.Anything)
+}
+
+func TestItem_DeleteUnit_InUse(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("DeleteUnit", mock.Anything, int64(3), int64(9)).Return(stores.ErrUnitInUse)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("DELETE", "/item/3/units/9", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Delete unit status code should be 409 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_CreateBlackout_Success(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("CreateBlackout", mock.Anything, mock.Anything).Return(int64(5), nil)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"dateFrom":"2022-12-24T00:00:00Z","dateTo":"2022-12-27T00:00:00Z","reason":"Holidays"}`)
+	req, _ := http.NewRequest("POST", "/item/3/blackouts", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create blackout status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	blackout := itemStore.Calls[0].Arguments.Get(1).(*models.ItemBlackout)
+	if blackout.ItemId != 3 || blackout.Reason != "Holidays" {
+		t.Errorf("Expected holidays blackout of item 3 but got %#v", blackout)
+	}
+}
+
+func TestItem_CreateBlackout_BadRequest_Inverted(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"dateFrom":"2022-12-27T00:00:00Z","dateTo":"2022-12-24T00:00:00Z","reason":"Holidays"}`)
+	req, _ := http.NewRequest("POST", "/item/3/blackouts", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create blackout status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "CreateBlackout", mock.Anything, mock.Anything)
+}
+
+func TestItem_DeleteBlackout_NoRows(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("DeleteBlackout", mock.Anything, int64(3), int64(5)).Return(sql.ErrNoRows)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("DELETE", "/item/3/blackouts/5", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Delete blackout status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestItem_GetAll_Filtered(t *testing.T) {
+	title := "Tennis court"
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Filter", mock.Anything, mock.Anything).Return(models.Items{{Id: 4, Title: &title}}, nil)
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item?category=2&tag=outdoor&tag=Clay&minPrice=10&maxPrice=50&search=court&availableOn=2022-07-15", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Get all status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	filter := itemStore.Calls[0].Arguments.Get(1).(*models.ItemFilter)
+	if *filter.CategoryId != 2 || len(filter.Tags) != 2 || *filter.MinPrice != 10 || *filter.MaxPrice != 50 ||
+		filter.Search != "court" || !filter.AvailableOn.Equal(time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Filter not parsed from query: %#v", filter)
+	}
+
+	itemStore.AssertNotCalled(t, "GetAll", mock.Anything)
+}
+
+func TestItem_GetAll_BadRequest_InvalidFilter(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	router := testRouter(itemStore, t)
+
+	req, _ := http.NewRequest("GET", "/item?minPrice=cheap", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Get all status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	itemStore.AssertNotCalled(t, "Filter", mock.Anything, mock.Anything)
+}
+
+func TestItem_Create_BadRequest_UnknownCategory(t *testing.T) {
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), stores.ErrUnknownCategory)
+	router := testRouter(itemStore, t)
+
+	jsonStr := []byte(`{"title":"MyTitle","categoryId":99,"tags":["outdoor"]}`)
+	req, _ := http.NewRequest("POST", "/item", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (h *itemHandler) GetUnits(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	units, err := h.store.GetUnits(r.Context(), id)
+	if err != nil {
+		h.log.Printf("Error retrieving units for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	units.ToJSON(w)
+}
+
+func (h *itemHandler) CreateUnit(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	unit := &models.ItemUnit{}
+	err := unit.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	unit.ItemId = id
+
+	if err := baseValidate.Struct(unit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	unitId, err := h.store.CreateUnit(r.Context(), unit)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnitLabelExists {
+			http.Error(w, stores.ErrUnitLabelExists.Error(), http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error creating unit for item with id: %v. Error: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(unitId).ToJSON(w)
+}
+
+func (h *itemHandler) UpdateUnit(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)         // validated by regex already
+	unitId, _ := strconv.ParseInt(params["unitId"], 10, 64) // validated by regex already
+
+	unit := &models.ItemUnit{}
+	err := unit.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	unit.Id = unitId
+	unit.ItemId = id
+
+	if err := baseValidate.Struct(unit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.store.UpdateUnit(r.Context(), unit)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnitLabelExists {
+			http.Error(w, stores.ErrUnitLabelExists.Error(), http.StatusBadRequest)
+			return
+		}
+		h.log.Printf("Error updating unit with id: %v. Error: %v", unitId, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *itemHandler) DeleteUnit(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)         // validated by regex already
+	unitId, _ := strconv.ParseInt(params["unitId"], 10, 64) // validated by regex already
+
+	err := h.store.DeleteUnit(r.Context(), id, unitId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrUnitInUse {
+			http.Error(w, stores.ErrUnitInUse.Error(), http.StatusConflict)
+			return
+		}
+		h.log.Printf("Error deleting unit with id: %v. Error: %v", unitId, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
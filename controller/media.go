@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"database/sql"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+// name of multipart form field holding uploaded file
+const mediaFormField = "file"
+
+func NewMediaHandler(store stores.MediaStore, media services.MediaService, log hclog.Logger) MediaHandler {
+	return &mediaHandler{
+		store: store,
+		media: media,
+		log:   log,
+	}
+}
+
+type MediaHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	Upload(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	NewRouter() *mux.Router
+}
+
+type mediaHandler struct {
+	log   hclog.Logger
+	store stores.MediaStore
+	media services.MediaService
+}
+
+func (m *mediaHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	list, err := m.store.GetAll(r.Context(), id)
+	if err != nil {
+		m.log.Error("Error retrieving item media", "item", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	list.ToJSON(w)
+}
+
+// Upload reads single file from multipart form field "file"
+func (m *mediaHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var fileName string
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != mediaFormField {
+			continue
+		}
+
+		// read one byte over limit to detect too large files
+		fileName = part.FileName()
+		data, err = ioutil.ReadAll(io.LimitReader(part, m.media.MaxSize()+1))
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		break
+	}
+
+	if data == nil {
+		http.Error(w, "Missing file form field", http.StatusBadRequest)
+		return
+	}
+
+	media, err := m.media.Upload(r.Context(), id, fileName, data)
+	if err != nil {
+		switch errors.Cause(err) {
+		case services.MediaTooLargeError, services.ImageTooLargeError:
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case services.UnsupportedMediaError:
+			http.Error(w, services.UnsupportedMediaError.Error(), http.StatusUnsupportedMediaType)
+		default:
+			m.log.Error("Error uploading item media", "item", id, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if _, err := m.store.Create(r.Context(), media); err != nil {
+		m.media.Remove(r.Context(), media)
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		m.log.Error("Error saving item media", "item", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	media.ToJSON(w)
+}
+
+func (m *mediaHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64)           // validated by regex already
+	mediaId, _ := strconv.ParseInt(params["mediaId"], 10, 64) // validated by regex already
+
+	media, err := m.store.Delete(r.Context(), id, mediaId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		m.log.Error("Error deleting item media", "item", id, "media", mediaId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// record is gone already, leftover files are only logged
+	if err := m.media.Remove(r.Context(), media); err != nil {
+		m.log.Error("Error removing item media files", "media", mediaId, "error", err)
+	}
+}
+
+func (m *mediaHandler) NewRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/item/{id:[\\d]+}/media", m.GetAll)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/item/{id:[\\d]+}/media", m.Upload)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/item/{id:[\\d]+}/media/{mediaId:[\\d]+}", m.Delete)
+
+	return r
+}
+
+// serves files of blob store from dir on prefix (e.g. /media/).
+// Json content type set by router middleware is dropped so files
+// are served with their own type. Directories are not listed
+func NewMediaFileHandler(prefix string, dir string) http.Handler {
+	files := http.StripPrefix(prefix, http.FileServer(http.Dir(dir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Del("Content-Type")
+		files.ServeHTTP(w, r)
+	})
+}
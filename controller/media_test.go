@@ -0,0 +1,139 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeMediaStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeMediaStore) GetAll(ctx context.Context, itemId int64) (models.ItemMediaList, error) {
+	args := h.Called(ctx, itemId)
+	return args.Get(0).(models.ItemMediaList), args.Error(1)
+}
+
+func (h *MyFakeMediaStore) Create(ctx context.Context, media *models.ItemMedia) (int64, error) {
+	args := h.Called(ctx, media)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeMediaStore) Delete(ctx context.Context, itemId int64, mediaId int64) (*models.ItemMedia, error) {
+	args := h.Called(ctx, itemId, mediaId)
+	media, _ := args.Get(0).(*models.ItemMedia)
+	return media, args.Error(1)
+}
+
+func mediaTestRouter(store stores.MediaStore, dir string, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	media := services.NewMediaService(services.NewLocalBlobStore(dir, "/media"), 1<<20)
+	mediaHandler := controller.NewMediaHandler(store, media, log)
+	r.PathPrefix("/item/{id:[\\d]+}/media").Handler(mediaHandler.NewRouter())
+	return r
+}
+
+func multipartFile(t *testing.T, fileName string, data []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestMedia_Upload_Success(t *testing.T) {
+	img := &bytes.Buffer{}
+	png.Encode(img, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+
+	mediaStore := &MyFakeMediaStore{}
+	mediaStore.On("Create", mock.Anything, mock.Anything).Return(int64(3), nil)
+	router := mediaTestRouter(mediaStore, t.TempDir(), &test_util.HcLogMock{})
+
+	body, contentType := multipartFile(t, "room.png", img.Bytes())
+	req, _ := http.NewRequest("POST", "/item/5/media", body)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Fatalf("Upload status code should be 201 but got %v: %v", res.Result().StatusCode, res.Body.String())
+	}
+
+	media := &models.ItemMedia{}
+	json.NewDecoder(res.Body).Decode(media)
+	if media.ItemId != 5 || media.Kind != models.MediaKindImage || media.FileName != "room.png" || media.ThumbnailURL == "" {
+		t.Errorf("Unexpected uploaded media: %#v", media)
+	}
+}
+
+func TestMedia_Upload_UnsupportedType(t *testing.T) {
+	mediaStore := &MyFakeMediaStore{}
+	router := mediaTestRouter(mediaStore, t.TempDir(), &test_util.HcLogMock{})
+
+	body, contentType := multipartFile(t, "notes.png", []byte("just some plain text"))
+	req, _ := http.NewRequest("POST", "/item/5/media", body)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 415 {
+		t.Errorf("Upload status code should be 415 but got %v", res.Result().StatusCode)
+	}
+	mediaStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMedia_Upload_UnknownItem(t *testing.T) {
+	mediaStore := &MyFakeMediaStore{}
+	mediaStore.On("Create", mock.Anything, mock.Anything).Return(int64(0), sql.ErrNoRows)
+	router := mediaTestRouter(mediaStore, t.TempDir(), &test_util.HcLogMock{})
+
+	body, contentType := multipartFile(t, "terms.pdf", []byte("%PDF-1.4\n"))
+	req, _ := http.NewRequest("POST", "/item/5/media", body)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Upload status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestMedia_Delete_NotFound(t *testing.T) {
+	mediaStore := &MyFakeMediaStore{}
+	mediaStore.On("Delete", mock.Anything, int64(5), int64(9)).Return(nil, sql.ErrNoRows)
+	router := mediaTestRouter(mediaStore, t.TempDir(), &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("DELETE", "/item/5/media/9", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Delete status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
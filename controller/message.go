@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewMessageHandler(store stores.MessageStore, log hclog.Logger) MessageHandler {
+	return &messageHandler{
+		store: store,
+		log:   log,
+	}
+}
+
+type MessageHandler interface {
+	GetInquiryMessages(w http.ResponseWriter, r *http.Request)
+	CreateInquiryMessage(w http.ResponseWriter, r *http.Request)
+	GetAcceptedMessages(w http.ResponseWriter, r *http.Request)
+	CreateAcceptedMessage(w http.ResponseWriter, r *http.Request)
+	NewInquiryRouter() *mux.Router
+	NewAcceptedRouter() *mux.Router
+}
+
+type messageHandler struct {
+	log   hclog.Logger
+	store stores.MessageStore
+}
+
+func (m *messageHandler) GetInquiryMessages(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	messages, err := m.store.GetInquiryMessages(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		m.log.Error("Error retrieving inquiry messages", "inquiry", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	messages.ToJSON(w)
+}
+
+// CreateInquiryMessage adds staff reply or internal note to inquiry,
+// author is authenticated user
+func (m *messageHandler) CreateInquiryMessage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	msg, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	msg.InquiryId = &id
+
+	msgId, err := m.store.CreateInquiryMessage(r.Context(), msg)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		m.log.Error("Error creating inquiry message", "inquiry", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(msgId).ToJSON(w)
+}
+
+func (m *messageHandler) GetAcceptedMessages(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	messages, err := m.store.GetAcceptedMessages(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		m.log.Error("Error retrieving accepted messages", "accepted", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	messages.ToJSON(w)
+}
+
+// CreateAcceptedMessage adds staff reply or internal note to accepted
+// reservation, author is authenticated user
+func (m *messageHandler) CreateAcceptedMessage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	msg, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	msg.AcceptedId = &id
+
+	msgId, err := m.store.CreateAcceptedMessage(r.Context(), msg)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		m.log.Error("Error creating accepted message", "accepted", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(msgId).ToJSON(w)
+}
+
+// reads and validates staff message from request body. Owner, author and
+// time are set by server. Writes bad request when message is invalid
+func readMessage(w http.ResponseWriter, r *http.Request) (*models.Message, bool) {
+	msg := &models.Message{}
+	err := msg.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if err := baseValidate.Struct(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	msg.Id = 0
+	msg.InquiryId = nil
+	msg.AcceptedId = nil
+	msg.AuthorId = requestUserId(r)
+	msg.AuthorName = ""
+	return msg, true
+}
+
+func (m *messageHandler) NewInquiryRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/inquiry/{id:[\\d]+}/messages", m.GetInquiryMessages)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/inquiry/{id:[\\d]+}/messages", m.CreateInquiryMessage)
+
+	return r
+}
+
+func (m *messageHandler) NewAcceptedRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/accepted/{id:[\\d]+}/messages", m.GetAcceptedMessages)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/accepted/{id:[\\d]+}/messages", m.CreateAcceptedMessage)
+
+	return r
+}
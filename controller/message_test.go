@@ -0,0 +1,156 @@
+package controller_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/middleware"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeMessageStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeMessageStore) GetInquiryMessages(ctx context.Context, inquiryId int64) (models.Messages, error) {
+	args := h.Called(ctx, inquiryId)
+	return args.Get(0).(models.Messages), args.Error(1)
+}
+
+func (h *MyFakeMessageStore) CreateInquiryMessage(ctx context.Context, msg *models.Message) (int64, error) {
+	args := h.Called(ctx, msg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeMessageStore) GetAcceptedMessages(ctx context.Context, acceptedId int64) (models.Messages, error) {
+	args := h.Called(ctx, acceptedId)
+	return args.Get(0).(models.Messages), args.Error(1)
+}
+
+func (h *MyFakeMessageStore) CreateAcceptedMessage(ctx context.Context, msg *models.Message) (int64, error) {
+	args := h.Called(ctx, msg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func messageTestRouter(store stores.MessageStore, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	authService := services.NewAuthService("test-secret", time.Minute, time.Hour)
+	jwt := middleware.NewJwt(authService, hclog.NewNullLogger())
+
+	handler := controller.NewMessageHandler(store, log)
+	inquiryRouter := handler.NewInquiryRouter()
+	inquiryRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/inquiry/{id:[\\d]+}/messages").Handler(inquiryRouter)
+	acceptedRouter := handler.NewAcceptedRouter()
+	acceptedRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/accepted/{id:[\\d]+}/messages").Handler(acceptedRouter)
+	return r
+}
+
+func TestMessage_CreateInquiryMessage_Success(t *testing.T) {
+	store := &MyFakeMessageStore{}
+	store.On("CreateInquiryMessage", mock.Anything, mock.Anything).Return(int64(9), nil)
+	router := messageTestRouter(store, &test_util.HcLogMock{})
+
+	body := `{"kind":"reply","body":"Room is free, see you soon","authorId":5,"authorName":"Someone else"}`
+	req, _ := http.NewRequest("POST", "/inquiry/4/messages", strings.NewReader(body))
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Fatalf("Create message status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	msg := store.Calls[0].Arguments.Get(1).(*models.Message)
+	if *msg.InquiryId != 4 || msg.AcceptedId != nil {
+		t.Errorf("Expected message of inquiry 4 but got %#v", msg)
+	}
+	if msg.AuthorId == nil || *msg.AuthorId != 1 || msg.AuthorName != "" {
+		t.Errorf("Expected author from jwt subject but got %#v", msg)
+	}
+}
+
+func TestMessage_CreateInquiryMessage_CustomerKind(t *testing.T) {
+	store := &MyFakeMessageStore{}
+	router := messageTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("POST", "/inquiry/4/messages", strings.NewReader(`{"kind":"customer","body":"Hello"}`))
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Staff can not write customer messages, expected 400 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "CreateInquiryMessage", mock.Anything, mock.Anything)
+}
+
+func TestMessage_CreateAcceptedMessage_Missing(t *testing.T) {
+	store := &MyFakeMessageStore{}
+	store.On("CreateAcceptedMessage", mock.Anything, mock.Anything).Return(int64(0), sql.ErrNoRows)
+	router := messageTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("POST", "/accepted/7/messages", strings.NewReader(`{"kind":"note","body":"Late arrival"}`))
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Message of missing reservation should return 400 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestMessage_GetInquiryMessages(t *testing.T) {
+	inquiryId := int64(4)
+	store := &MyFakeMessageStore{}
+	store.On("GetInquiryMessages", mock.Anything, inquiryId).Return(models.Messages{
+		{Id: 1, InquiryId: &inquiryId, Kind: models.MessageCustomer, AuthorName: "John Doe", Body: "Is parking included?"},
+		{Id: 2, InquiryId: &inquiryId, Kind: models.MessageNote, Body: "Ask reception"},
+	}, nil)
+	router := messageTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/inquiry/4/messages", nil)
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Get messages status code should be 200 but got %v", res.Result().StatusCode)
+	}
+	if !strings.Contains(res.Body.String(), `"kind":"note"`) {
+		t.Errorf("Expected notes in staff thread but got %v", res.Body.String())
+	}
+}
+
+func TestMessage_GetInquiryMessages_Unauthorized(t *testing.T) {
+	store := &MyFakeMessageStore{}
+	router := messageTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/inquiry/4/messages", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Messages without jwt should return 401 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "GetInquiryMessages", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewPromoHandler(store stores.PromoStore, log hclog.Logger) PromoHandler {
+	return &promoHandler{
+		store: store,
+		log:   log,
+	}
+}
+
+type PromoHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	GetRedemptions(w http.ResponseWriter, r *http.Request)
+	NewRouter() *mux.Router
+}
+
+type promoHandler struct {
+	log   hclog.Logger
+	store stores.PromoStore
+}
+
+func (p *promoHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	promos, err := p.store.GetAll(r.Context())
+	if err != nil {
+		p.log.Error("Error retrieving promo codes", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	promos.ToJSON(w)
+}
+
+func (p *promoHandler) Create(w http.ResponseWriter, r *http.Request) {
+	promo, ok := p.readPromoCode(w, r, createValidate, 0)
+	if !ok {
+		return
+	}
+
+	id, err := p.store.Create(r.Context(), promo)
+	if err != nil {
+		p.writeStoreError(w, err, "Error creating promo code")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(id).ToJSON(w)
+}
+
+func (p *promoHandler) Update(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	promo, ok := p.readPromoCode(w, r, updateValidate, id)
+	if !ok {
+		return
+	}
+
+	if err := p.store.Update(r.Context(), promo); err != nil {
+		p.writeStoreError(w, err, "Error updating promo code")
+		return
+	}
+}
+
+func (p *promoHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	err := p.store.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == stores.ErrPromoCodeRedeemed {
+			http.Error(w, stores.ErrPromoCodeRedeemed.Error(), http.StatusBadRequest)
+			return
+		}
+		p.writeStoreError(w, err, "Error deleting promo code")
+		return
+	}
+}
+
+func (p *promoHandler) GetRedemptions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	redemptions, err := p.store.GetRedemptions(r.Context(), id)
+	if err != nil {
+		p.log.Error("Error retrieving promo code redemptions", "promo", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	redemptions.ToJSON(w)
+}
+
+// reads and validates promo code with id (zero on create) from request body.
+// Writes bad request response and returns false if promo code is not valid
+func (p *promoHandler) readPromoCode(w http.ResponseWriter, r *http.Request, validate *validator.Validate, id int64) (*models.PromoCode, bool) {
+	promo := &models.PromoCode{}
+	err := promo.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, false
+	}
+	promo.Id = id
+
+	if err := validate.Struct(promo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	switch {
+	case promo.DiscountType == models.PromoDiscountPercent && promo.Discount > 100:
+		http.Error(w, "Percent discount can not be over 100", http.StatusBadRequest)
+		return nil, false
+	case promo.DiscountType == models.PromoDiscountFixed && promo.Currency == "":
+		http.Error(w, "Fixed discount requires currency", http.StatusBadRequest)
+		return nil, false
+	case promo.ValidFrom != nil && promo.ValidTo != nil && promo.ValidTo.Before(*promo.ValidFrom):
+		http.Error(w, "Promo code validity (validFrom, validTo) is inverted", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return promo, true
+}
+
+// maps store errors shared by promo code create, update and delete to responses
+func (p *promoHandler) writeStoreError(w http.ResponseWriter, err error, msg string) {
+	switch errors.Cause(err) {
+	case sql.ErrNoRows:
+		http.Error(w, "Bad request", http.StatusBadRequest)
+	case stores.ErrPromoCodeExists:
+		http.Error(w, stores.ErrPromoCodeExists.Error(), http.StatusBadRequest)
+	default:
+		p.log.Error(msg, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (p *promoHandler) NewRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/promo-code", p.GetAll)
+	get.HandleFunc("/promo-code/{id:[\\d]+}/redemptions", p.GetRedemptions)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/promo-code", p.Create)
+
+	put := r.Methods(http.MethodPut).Subrouter()
+	put.HandleFunc("/promo-code/{id:[\\d]+}", p.Update)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/promo-code/{id:[\\d]+}", p.Delete)
+
+	return r
+}
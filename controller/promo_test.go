@@ -0,0 +1,115 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakePromoStore struct {
+	mock.Mock
+}
+
+func (h *MyFakePromoStore) GetAll(ctx context.Context) (models.PromoCodes, error) {
+	args := h.Called(ctx)
+	return args.Get(0).(models.PromoCodes), args.Error(1)
+}
+
+func (h *MyFakePromoStore) Create(ctx context.Context, promo *models.PromoCode) (int64, error) {
+	args := h.Called(ctx, promo)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakePromoStore) Update(ctx context.Context, promo *models.PromoCode) error {
+	args := h.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (h *MyFakePromoStore) Delete(ctx context.Context, id int64) error {
+	args := h.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (h *MyFakePromoStore) GetRedemptions(ctx context.Context, id int64) (models.PromoRedemptions, error) {
+	args := h.Called(ctx, id)
+	return args.Get(0).(models.PromoRedemptions), args.Error(1)
+}
+
+func promoTestRouter(store stores.PromoStore, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	promoHandler := controller.NewPromoHandler(store, log)
+	r.PathPrefix("/promo-code").Handler(promoHandler.NewRouter())
+	return r
+}
+
+func TestPromo_Create_Success(t *testing.T) {
+	promoStore := &MyFakePromoStore{}
+	promoStore.On("Create", mock.Anything, mock.Anything).Return(int64(5), nil)
+	router := promoTestRouter(promoStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"code":"SUMMER22","discountType":"percent","discount":10,"maxUses":100,"itemIds":[1,2]}`)
+	req, _ := http.NewRequest("POST", "/promo-code", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	promo := promoStore.Calls[0].Arguments.Get(1).(*models.PromoCode)
+	if promo.Code != "SUMMER22" || *promo.MaxUses != 100 || len(promo.ItemIds) != 2 {
+		t.Errorf("Expected SUMMER22 limited to 100 uses of 2 items but got %#v", promo)
+	}
+}
+
+func TestPromo_Create_BadRequest(t *testing.T) {
+	bodies := []string{
+		`{"code":"HALF","discountType":"percent","discount":150}`,
+		`{"code":"FIVE","discountType":"fixed","discount":500}`,
+		`{"code":"LATE","discountType":"percent","discount":5,"validFrom":"2022-02-01T00:00:00Z","validTo":"2022-01-01T00:00:00Z"}`,
+		`{"code":"NO CODE","discountType":"percent","discount":5}`,
+	}
+
+	for _, body := range bodies {
+		promoStore := &MyFakePromoStore{}
+		router := promoTestRouter(promoStore, &test_util.HcLogMock{})
+
+		req, _ := http.NewRequest("POST", "/promo-code", bytes.NewBuffer([]byte(body)))
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if res.Result().StatusCode != 400 {
+			t.Errorf("Create status code should be 400 for %v but got %v", body, res.Result().StatusCode)
+		}
+		promoStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	}
+}
+
+func TestPromo_Delete_Redeemed(t *testing.T) {
+	promoStore := &MyFakePromoStore{}
+	promoStore.On("Delete", mock.Anything, int64(5)).Return(errors.Wrap(stores.ErrPromoCodeRedeemed, "fk"))
+	router := promoTestRouter(promoStore, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("DELETE", "/promo-code/5", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Delete status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+// how long (in seconds) clients and proxies may cache public catalog responses
+const publicCacheMaxAge = 60
+
+func NewPublicHandler(store stores.ItemStore, pricing services.PricingService, log hclog.Logger) PublicHandler {
+	return &publicHandler{
+		store:   store,
+		pricing: pricing,
+		log:     log,
+		now:     time.Now,
+	}
+}
+
+// PublicHandler serves read-only catalog of currently visible items
+// without authentication
+type PublicHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	GetOne(w http.ResponseWriter, r *http.Request)
+	NewRouter() *mux.Router
+}
+
+type publicHandler struct {
+	log     hclog.Logger
+	store   stores.ItemStore
+	pricing services.PricingService
+	now     func() time.Time
+}
+
+// GetAll accepts the same filter query params as item list
+func (p *publicHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseItemFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := p.now()
+	items, err := p.store.GetVisible(r.Context(), filter, now)
+	if err != nil {
+		p.log.Error("Error retrieving public items", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	list := models.PublicItems{}
+	for i := range items {
+		public, err := p.publicItem(&items[i], now)
+		if err != nil {
+			p.log.Error("Error quoting public item", "item", items[i].Id, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		list = append(list, public)
+	}
+
+	writeCached(w, r, list)
+}
+
+// GetOne responds with not found for missing items and items outside of their visibility window
+func (p *publicHandler) GetOne(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	now := p.now()
+	item, err := p.store.GetOne(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		p.log.Error("Error retrieving public item", "item", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !item.IsVisible(now) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	public, err := p.publicItem(item, now)
+	if err != nil {
+		p.log.Error("Error quoting public item", "item", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeCached(w, r, &public)
+}
+
+// converts item to public item priced for today
+func (p *publicHandler) publicItem(item *models.Item, now time.Time) (models.PublicItem, error) {
+	quote, err := p.pricing.Quote(item, now)
+	if err != nil {
+		return models.PublicItem{}, err
+	}
+	return models.NewPublicItem(item, quote), nil
+}
+
+func (p *publicHandler) NewRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/public/items", p.GetAll)
+	get.HandleFunc("/public/items/{id:[\\d]+}", p.GetOne)
+
+	return r
+}
+
+// writes json body with cache headers. ETag is hash of body so clients
+// revalidating with If-None-Match get 304 while catalog does not change
+func writeCached(w http.ResponseWriter, r *http.Request, body interface{ ToJSON(io.Writer) error }) {
+	buf := &bytes.Buffer{}
+	if err := body.ToJSON(buf); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", publicCacheMaxAge))
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(buf.Bytes())
+}
+
+// checks If-None-Match header (list of etags or *) against etag
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
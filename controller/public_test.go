@@ -0,0 +1,105 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+)
+
+func publicTestRouter(store stores.ItemStore) *mux.Router {
+	r := mux.NewRouter()
+
+	publicHandler := controller.NewPublicHandler(store, services.NewPricingService(), &test_util.HcLogMock{})
+	r.PathPrefix("/public").Handler(publicHandler.NewRouter())
+	return r
+}
+
+func TestPublic_GetAll_HidesInternalFields(t *testing.T) {
+	title := "Tennis court"
+	showFrom := time.Now().AddDate(0, -1, 0)
+	items := models.Items{
+		{Id: 1, Title: &title, ShowFrom: &showFrom, Price: models.NewMoney(20, "EUR"), Capacity: 4, BookingMode: models.BookingModeDaily},
+	}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetVisible", mock.Anything, mock.Anything, mock.Anything).Return(items, nil)
+	router := publicTestRouter(itemStore)
+
+	req, _ := http.NewRequest("GET", "/public/items?tag=outdoor", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Public get all status code should be 200 but got %v", res.Result().StatusCode)
+	}
+
+	body := res.Body.String()
+	if !strings.Contains(body, `"price":{"amount":20,"currency":"EUR"}`) || strings.Contains(body, "showFrom") || strings.Contains(body, "capacity") {
+		t.Errorf("Unexpected public items body %v", body)
+	}
+
+	if res.Header().Get("ETag") == "" || !strings.Contains(res.Header().Get("Cache-Control"), "max-age") {
+		t.Errorf("Expected cache headers but got %v", res.Header())
+	}
+
+	filter := itemStore.Calls[0].Arguments.Get(1).(*models.ItemFilter)
+	if len(filter.Tags) != 1 || filter.Tags[0] != "outdoor" {
+		t.Errorf("Expected tag filter but got %#v", filter)
+	}
+}
+
+func TestPublic_GetOne_NotModified(t *testing.T) {
+	title := "Tennis court"
+	item := &models.Item{Id: 1, Title: &title, Price: models.NewMoney(20, "EUR"), BookingMode: models.BookingModeDaily}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetOne", mock.Anything, int64(1)).Return(item, nil)
+	router := publicTestRouter(itemStore)
+
+	req, _ := http.NewRequest("GET", "/public/items/1", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	etag := res.Header().Get("ETag")
+	if res.Result().StatusCode != 200 || etag == "" {
+		t.Fatalf("Expected 200 with ETag but got %v %v", res.Result().StatusCode, etag)
+	}
+
+	req, _ = http.NewRequest("GET", "/public/items/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 304 || res.Body.Len() != 0 {
+		t.Errorf("Expected 304 without body but got %v %v", res.Result().StatusCode, res.Body.String())
+	}
+}
+
+func TestPublic_GetOne_OutsideShowWindow(t *testing.T) {
+	title := "Tennis court"
+	showTo := time.Now().AddDate(0, 0, -1)
+	item := &models.Item{Id: 1, Title: &title, ShowTo: &showTo}
+
+	itemStore := &MyFakeItemStore{}
+	itemStore.On("GetOne", mock.Anything, int64(1)).Return(item, nil)
+	router := publicTestRouter(itemStore)
+
+	req, _ := http.NewRequest("GET", "/public/items/1", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 404 {
+		t.Errorf("Expected 404 for hidden item but got %v", res.Result().StatusCode)
+	}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewReservationHandler(store stores.ReservationStore, log hclog.Logger) ReservationHandler {
+	return &reservationHandler{
+		store: store,
+		log:   log,
+	}
+}
+
+// ReservationHandler finds inquiries and accepted reservations together
+type ReservationHandler interface {
+	GetByReference(w http.ResponseWriter, r *http.Request)
+	NewRouter() *mux.Router
+}
+
+type reservationHandler struct {
+	log   hclog.Logger
+	store stores.ReservationStore
+}
+
+// GetByReference returns inquiry and accepted reservation with reference code.
+// Code is matched regardless of case, spaces and dashes
+func (h *reservationHandler) GetByReference(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	reference, ok := services.NormalizeReference(params["code"])
+	if !ok {
+		http.Error(w, "Invalid reference code", http.StatusBadRequest)
+		return
+	}
+
+	found, err := h.store.GetByReference(r.Context(), reference)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		h.log.Error("Error retrieving reservation by reference", "reference", reference, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	found.ToJSON(w)
+}
+
+func (h *reservationHandler) NewRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/reservations/by-reference/{code}", h.GetByReference)
+
+	return r
+}
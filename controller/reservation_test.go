@@ -0,0 +1,87 @@
+package controller_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeReservationStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeReservationStore) GetByReference(ctx context.Context, reference string) (*models.ReservationReference, error) {
+	args := h.Called(ctx, reference)
+	return args.Get(0).(*models.ReservationReference), args.Error(1)
+}
+
+func reservationTestRouter(store stores.ReservationStore, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	handler := controller.NewReservationHandler(store, log)
+	r.PathPrefix("/reservations").Handler(handler.NewRouter())
+	return r
+}
+
+func TestReservation_GetByReference_Normalized(t *testing.T) {
+	store := &MyFakeReservationStore{}
+	store.On("GetByReference", mock.Anything, "K7F-92QX").Return(&models.ReservationReference{
+		Reference: "K7F-92QX",
+		Inquiry:   &models.Inquiry{Id: 4, Reference: "K7F-92QX", Status: models.InquiryAccepted},
+		Accepted:  &models.Accepted{Id: 9, Reference: "K7F-92QX", InquiryId: 4},
+	}, nil)
+	router := reservationTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/reservations/by-reference/k7f92qx", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Get by reference status code should be 200 but got %v", res.Result().StatusCode)
+	}
+	if body := res.Body.String(); !strings.Contains(body, `"inquiry":{"id":4`) || !strings.Contains(body, `"accepted":{"id":9`) {
+		t.Errorf("Expected inquiry and accepted reservation but got %v", body)
+	}
+}
+
+func TestReservation_GetByReference_Invalid(t *testing.T) {
+	store := &MyFakeReservationStore{}
+	router := reservationTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/reservations/by-reference/K0F-92QX", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Invalid reference should return 400 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "GetByReference", mock.Anything, mock.Anything)
+}
+
+func TestReservation_GetByReference_NotFound(t *testing.T) {
+	store := &MyFakeReservationStore{}
+	store.On("GetByReference", mock.Anything, "K7F-92QX").Return((*models.ReservationReference)(nil), sql.ErrNoRows)
+	router := reservationTestRouter(store, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("GET", "/reservations/by-reference/K7F-92QX", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 404 {
+		t.Errorf("Unknown reference should return 404 but got %v", res.Result().StatusCode)
+	}
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+)
+
+const dateLayout = "2006-01-02"
+
+// writes structured json error with given status code
+func writeErrorResponse(w http.ResponseWriter, status int, res *models.ErrorResponse) {
+	w.WriteHeader(status)
+	res.ToJSON(w)
+}
+
+// writes booking error (fully booked dates, ...) as structured response.
+// Only conflicts with existing reservations are reported as conflict, requests
+// outside of item schedule, blackouts or slots are bad requests
+func writeBookingError(w http.ResponseWriter, be *stores.BookingError) {
+	dates := make([]string, 0, len(be.Dates))
+	for _, d := range be.Dates {
+		dates = append(dates, d.Format(dateLayout))
+	}
+
+	details := &models.BookingErrorDetails{
+		ItemId: be.ItemId,
+		Dates:  dates,
+		Slots:  be.Slots,
+	}
+
+	status := http.StatusBadRequest
+	if be.Code == stores.BookingFullyBooked {
+		status = http.StatusConflict
+	}
+	writeErrorResponse(w, status, models.NewErrorResponse(be.Message, be.Code, details))
+}
+
+// writes promo code that can not be applied (or accepted any more) with given status
+func writePromoError(w http.ResponseWriter, status int, pe *stores.PromoCodeError) {
+	details := &models.PromoErrorDetails{PromoCode: pe.PromoCode}
+	writeErrorResponse(w, status, models.NewErrorResponse(pe.Message, pe.Code, details))
+}
+
+// writes illegal inquiry transition as conflict
+func writeTransitionError(w http.ResponseWriter, te *stores.InquiryTransitionError) {
+	details := &models.InquiryTransitionDetails{
+		InquiryId: te.InquiryId,
+		Status:    te.Status,
+		Target:    te.Target,
+	}
+	writeErrorResponse(w, http.StatusConflict, models.NewErrorResponse(te.Error(), stores.InquiryInvalidTransition, details))
+}
+
+// parses date query parameter (2006-01-02 or RFC3339). Returns fallback if param is missing
+func parseDateQuery(r *http.Request, name string, fallback time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parses integer query parameter. Returns nil if param is missing
+func parseIntQuery(r *http.Request, name string) (*int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %v", name)
+	}
+	return &number, nil
+}
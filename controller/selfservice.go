@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewSelfServiceHandler(store stores.AccessStore, issuer AccessIssuer, links services.AccessLinks, log hclog.Logger) SelfServiceHandler {
+	return &selfServiceHandler{
+		store:  store,
+		issuer: issuer,
+		links:  links,
+		log:    log,
+	}
+}
+
+// SelfServiceHandler lets staff issue and revoke self-service links and
+// customers use them without user account
+type SelfServiceHandler interface {
+	GetInquiryAccess(w http.ResponseWriter, r *http.Request)
+	IssueInquiryAccess(w http.ResponseWriter, r *http.Request)
+	RevokeInquiryAccess(w http.ResponseWriter, r *http.Request)
+	GetAcceptedAccess(w http.ResponseWriter, r *http.Request)
+	IssueAcceptedAccess(w http.ResponseWriter, r *http.Request)
+	RevokeAcceptedAccess(w http.ResponseWriter, r *http.Request)
+	GetReservation(w http.ResponseWriter, r *http.Request)
+	UpdateContact(w http.ResponseWriter, r *http.Request)
+	RequestCancellation(w http.ResponseWriter, r *http.Request)
+	NewInquiryRouter() *mux.Router
+	NewAcceptedRouter() *mux.Router
+	NewPublicRouter() *mux.Router
+}
+
+type selfServiceHandler struct {
+	log    hclog.Logger
+	store  stores.AccessStore
+	issuer AccessIssuer
+	links  services.AccessLinks
+}
+
+func (s *selfServiceHandler) GetInquiryAccess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	accesses, err := s.store.GetInquiryAccess(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		s.log.Error("Error retrieving inquiry access", "inquiry", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	accesses.ToJSON(w)
+}
+
+// IssueInquiryAccess issues new self-service link of inquiry, earlier links stay valid
+func (s *selfServiceHandler) IssueInquiryAccess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	link, err := s.issuer.IssueInquiry(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		s.log.Error("Error issuing inquiry access", "inquiry", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	link.ToJSON(w)
+}
+
+// RevokeInquiryAccess revokes all self-service links of inquiry
+func (s *selfServiceHandler) RevokeInquiryAccess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	if err := s.store.RevokeInquiryAccess(r.Context(), id); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		s.log.Error("Error revoking inquiry access", "inquiry", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *selfServiceHandler) GetAcceptedAccess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	accesses, err := s.store.GetAcceptedAccess(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		s.log.Error("Error retrieving accepted access", "accepted", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	accesses.ToJSON(w)
+}
+
+// IssueAcceptedAccess issues new self-service link of accepted reservation
+func (s *selfServiceHandler) IssueAcceptedAccess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	link, err := s.issuer.IssueAccepted(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		s.log.Error("Error issuing accepted access", "accepted", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	link.ToJSON(w)
+}
+
+// RevokeAcceptedAccess revokes all self-service links of accepted reservation
+func (s *selfServiceHandler) RevokeAcceptedAccess(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	if err := s.store.RevokeAcceptedAccess(r.Context(), id); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		s.log.Error("Error revoking accepted access", "accepted", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *selfServiceHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	accessId, now, ok := s.verifyToken(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := s.store.GetReservation(r.Context(), accessId, now)
+	if err != nil {
+		if errors.Cause(err) == stores.ErrAccessInvalid {
+			http.Error(w, stores.ErrAccessInvalid.Error(), http.StatusUnauthorized)
+			return
+		}
+		s.log.Error("Error retrieving reservation of access", "access", accessId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	reservation.ToJSON(w)
+}
+
+func (s *selfServiceHandler) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	accessId, now, ok := s.verifyToken(w, r)
+	if !ok {
+		return
+	}
+
+	contact := &models.CustomerContact{}
+	err := contact.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := baseValidate.Struct(contact); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.UpdateContact(r.Context(), accessId, now, contact); err != nil {
+		if errors.Cause(err) == stores.ErrAccessInvalid {
+			http.Error(w, stores.ErrAccessInvalid.Error(), http.StatusUnauthorized)
+			return
+		}
+		if errors.Cause(err) == stores.ErrReservationClosed {
+			http.Error(w, stores.ErrReservationClosed.Error(), http.StatusConflict)
+			return
+		}
+		s.log.Error("Error updating contact of access", "access", accessId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RequestCancellation cancels pending inquiry or asks staff to cancel
+// accepted reservation. Reason is optional
+func (s *selfServiceHandler) RequestCancellation(w http.ResponseWriter, r *http.Request) {
+	accessId, now, ok := s.verifyToken(w, r)
+	if !ok {
+		return
+	}
+
+	request := &models.CancellationRequest{}
+	err := request.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil && err != io.EOF {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := baseValidate.Struct(request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.RequestCancellation(r.Context(), accessId, now, request.Reason); err != nil {
+		if errors.Cause(err) == stores.ErrAccessInvalid {
+			http.Error(w, stores.ErrAccessInvalid.Error(), http.StatusUnauthorized)
+			return
+		}
+		if te, ok := errors.Cause(err).(*stores.InquiryTransitionError); ok {
+			writeTransitionError(w, te)
+			return
+		}
+		s.log.Error("Error requesting cancellation of access", "access", accessId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// returns access id of token in request path. Writes unauthorized when token
+// is forged or expired, revocation is checked by store
+func (s *selfServiceHandler) verifyToken(w http.ResponseWriter, r *http.Request) (int64, time.Time, bool) {
+	now := time.Now()
+	accessId, err := s.links.Verify(mux.Vars(r)["token"], now)
+	if err != nil {
+		http.Error(w, stores.ErrAccessInvalid.Error(), http.StatusUnauthorized)
+		return 0, now, false
+	}
+	return accessId, now, true
+}
+
+func (s *selfServiceHandler) NewInquiryRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/inquiry/{id:[\\d]+}/access", s.GetInquiryAccess)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/inquiry/{id:[\\d]+}/access", s.IssueInquiryAccess)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/inquiry/{id:[\\d]+}/access", s.RevokeInquiryAccess)
+
+	return r
+}
+
+func (s *selfServiceHandler) NewAcceptedRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/accepted/{id:[\\d]+}/access", s.GetAcceptedAccess)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/accepted/{id:[\\d]+}/access", s.IssueAcceptedAccess)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/accepted/{id:[\\d]+}/access", s.RevokeAcceptedAccess)
+
+	return r
+}
+
+// customer endpoints, authorized by token of self-service link
+func (s *selfServiceHandler) NewPublicRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/self-service/{token}", s.GetReservation)
+
+	put := r.Methods(http.MethodPut).Subrouter()
+	put.HandleFunc("/self-service/{token}/contact", s.UpdateContact)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/self-service/{token}/cancel", s.RequestCancellation)
+
+	return r
+}
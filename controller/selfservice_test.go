@@ -0,0 +1,297 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/middleware"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeAccessIssuer struct {
+	mock.Mock
+}
+
+func (h *MyFakeAccessIssuer) IssueInquiry(ctx context.Context, inquiryId int64) (*models.AccessLink, error) {
+	args := h.Called(ctx, inquiryId)
+	return args.Get(0).(*models.AccessLink), args.Error(1)
+}
+
+func (h *MyFakeAccessIssuer) IssueAccepted(ctx context.Context, acceptedId int64) (*models.AccessLink, error) {
+	args := h.Called(ctx, acceptedId)
+	return args.Get(0).(*models.AccessLink), args.Error(1)
+}
+
+type MyFakeAccessStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeAccessStore) IssueInquiryAccess(ctx context.Context, inquiryId int64, expiresAt time.Time) (*models.ReservationAccess, error) {
+	args := h.Called(ctx, inquiryId, expiresAt)
+	return args.Get(0).(*models.ReservationAccess), args.Error(1)
+}
+
+func (h *MyFakeAccessStore) IssueAcceptedAccess(ctx context.Context, acceptedId int64, expiresAt time.Time) (*models.ReservationAccess, error) {
+	args := h.Called(ctx, acceptedId, expiresAt)
+	return args.Get(0).(*models.ReservationAccess), args.Error(1)
+}
+
+func (h *MyFakeAccessStore) GetInquiryAccess(ctx context.Context, inquiryId int64) (models.ReservationAccesses, error) {
+	args := h.Called(ctx, inquiryId)
+	return args.Get(0).(models.ReservationAccesses), args.Error(1)
+}
+
+func (h *MyFakeAccessStore) GetAcceptedAccess(ctx context.Context, acceptedId int64) (models.ReservationAccesses, error) {
+	args := h.Called(ctx, acceptedId)
+	return args.Get(0).(models.ReservationAccesses), args.Error(1)
+}
+
+func (h *MyFakeAccessStore) RevokeInquiryAccess(ctx context.Context, inquiryId int64) error {
+	args := h.Called(ctx, inquiryId)
+	return args.Error(0)
+}
+
+func (h *MyFakeAccessStore) RevokeAcceptedAccess(ctx context.Context, acceptedId int64) error {
+	args := h.Called(ctx, acceptedId)
+	return args.Error(0)
+}
+
+func (h *MyFakeAccessStore) GetReservation(ctx context.Context, accessId int64, now time.Time) (*models.CustomerReservation, error) {
+	args := h.Called(ctx, accessId, now)
+	return args.Get(0).(*models.CustomerReservation), args.Error(1)
+}
+
+func (h *MyFakeAccessStore) UpdateContact(ctx context.Context, accessId int64, now time.Time, contact *models.CustomerContact) error {
+	args := h.Called(ctx, accessId, now, contact)
+	return args.Error(0)
+}
+
+func (h *MyFakeAccessStore) RequestCancellation(ctx context.Context, accessId int64, now time.Time, reason string) error {
+	args := h.Called(ctx, accessId, now, reason)
+	return args.Error(0)
+}
+
+type MyFakeNotifier struct {
+	mock.Mock
+}
+
+func (h *MyFakeNotifier) Notify(ctx context.Context, n *services.Notification) error {
+	args := h.Called(ctx, n)
+	return args.Error(0)
+}
+
+var selfServiceTestLinks = services.NewAccessLinks("test-secret", "https://example.com/reservation")
+
+func selfServiceTestRouter(store stores.AccessStore, notifier services.Notifier, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	authService := services.NewAuthService("test-secret", time.Minute, time.Hour)
+	jwt := middleware.NewJwt(authService, hclog.NewNullLogger())
+
+	issuer := controller.NewAccessIssuer(store, selfServiceTestLinks, notifier, 24*time.Hour, log)
+	handler := controller.NewSelfServiceHandler(store, issuer, selfServiceTestLinks, log)
+	inquiryRouter := handler.NewInquiryRouter()
+	inquiryRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/inquiry/{id:[\\d]+}/access").Handler(inquiryRouter)
+	acceptedRouter := handler.NewAcceptedRouter()
+	acceptedRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/accepted/{id:[\\d]+}/access").Handler(acceptedRouter)
+	r.PathPrefix("/self-service").Handler(handler.NewPublicRouter())
+	return r
+}
+
+func TestSelfService_IssueInquiryAccess_SendsLink(t *testing.T) {
+	inquiryId := int64(4)
+	store := &MyFakeAccessStore{}
+	store.On("IssueInquiryAccess", mock.Anything, inquiryId, mock.Anything).Return(&models.ReservationAccess{
+		Id: 11, InquiryId: &inquiryId, Email: "john.doe@doe.com", ExpiresAt: time.Now().Add(24 * time.Hour),
+	}, nil)
+	notifier := &MyFakeNotifier{}
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(nil)
+	router := selfServiceTestRouter(store, notifier, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("POST", "/inquiry/4/access", http.NoBody)
+	req.Header.Set("Authorization", inquiryTestAuthorization(t))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Fatalf("Issue access status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	n := notifier.Calls[0].Arguments.Get(1).(*services.Notification)
+	if n.To != "john.doe@doe.com" || !strings.Contains(n.Body, "https://example.com/reservation/") {
+		t.Errorf("Expected link sent to inquirer but got %#v", n)
+	}
+	if !strings.Contains(res.Body.String(), `"link":"https://example.com/reservation/`) {
+		t.Errorf("Expected link in response but got %v", res.Body.String())
+	}
+}
+
+func TestSelfService_IssueInquiryAccess_Unauthorized(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	// self-service token does not authorize staff endpoints
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("POST", "/inquiry/4/access", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Issue access with link token should return 401 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "IssueInquiryAccess", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSelfService_GetReservation(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	store.On("GetReservation", mock.Anything, int64(11), mock.Anything).Return(&models.CustomerReservation{
+		Inquirer: "John Doe", Status: models.InquiryPending, Messages: models.Messages{},
+	}, nil)
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("GET", "/self-service/"+token, nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Fatalf("Get reservation status code should be 200 but got %v", res.Result().StatusCode)
+	}
+	if !strings.Contains(res.Body.String(), `"status":"pending"`) {
+		t.Errorf("Expected reservation status in response but got %v", res.Body.String())
+	}
+}
+
+func TestSelfService_GetReservation_Revoked(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	store.On("GetReservation", mock.Anything, int64(11), mock.Anything).Return((*models.CustomerReservation)(nil), stores.ErrAccessInvalid)
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("GET", "/self-service/"+token, nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Revoked access should return 401 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestSelfService_GetReservation_Expired(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(-time.Minute))
+	req, _ := http.NewRequest("GET", "/self-service/"+token, nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 401 {
+		t.Errorf("Expired link should return 401 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "GetReservation", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSelfService_UpdateContact_InvalidEmail(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("PUT", "/self-service/"+token+"/contact", strings.NewReader(`{"email":"john"}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Invalid email should return 400 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "UpdateContact", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSelfService_UpdateContact_Empty(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("PUT", "/self-service/"+token+"/contact", strings.NewReader(`{}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Empty contact should return 400 but got %v", res.Result().StatusCode)
+	}
+	store.AssertNotCalled(t, "UpdateContact", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSelfService_UpdateContact_Closed(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	store.On("UpdateContact", mock.Anything, int64(11), mock.Anything, mock.Anything).Return(stores.ErrReservationClosed)
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("PUT", "/self-service/"+token+"/contact", strings.NewReader(`{"phone":"+38640123456"}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Contact of closed reservation should return 409 but got %v", res.Result().StatusCode)
+	}
+}
+
+func TestSelfService_RequestCancellation_WithoutReason(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	store.On("RequestCancellation", mock.Anything, int64(11), mock.Anything, "").Return(nil)
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("POST", "/self-service/"+token+"/cancel", http.NoBody)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 200 {
+		t.Errorf("Cancellation status code should be 200 but got %v", res.Result().StatusCode)
+	}
+	store.AssertExpectations(t)
+}
+
+func TestSelfService_RequestCancellation_AlreadyDecided(t *testing.T) {
+	store := &MyFakeAccessStore{}
+	store.On("RequestCancellation", mock.Anything, int64(11), mock.Anything, "Plans changed").Return(&stores.InquiryTransitionError{
+		InquiryId: 4,
+		Status:    models.InquiryRejected,
+		Target:    models.InquiryCancelled,
+	})
+	router := selfServiceTestRouter(store, &MyFakeNotifier{}, &test_util.HcLogMock{})
+
+	token := selfServiceTestLinks.Sign(11, time.Now().Add(time.Hour))
+	req, _ := http.NewRequest("POST", "/self-service/"+token+"/cancel", strings.NewReader(`{"reason":"Plans changed"}`))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 409 {
+		t.Errorf("Cancellation of rejected inquiry should return 409 but got %v", res.Result().StatusCode)
+	}
+}
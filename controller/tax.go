@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+func NewTaxHandler(store stores.TaxStore, log hclog.Logger) TaxHandler {
+	return &taxHandler{
+		store: store,
+		log:   log,
+	}
+}
+
+type TaxHandler interface {
+	GetClasses(w http.ResponseWriter, r *http.Request)
+	CreateClass(w http.ResponseWriter, r *http.Request)
+	UpdateClass(w http.ResponseWriter, r *http.Request)
+	DeleteClass(w http.ResponseWriter, r *http.Request)
+	GetRates(w http.ResponseWriter, r *http.Request)
+	CreateRate(w http.ResponseWriter, r *http.Request)
+	DeleteRate(w http.ResponseWriter, r *http.Request)
+	NewClassRouter() *mux.Router
+	NewRateRouter() *mux.Router
+}
+
+type taxHandler struct {
+	log   hclog.Logger
+	store stores.TaxStore
+}
+
+func (t *taxHandler) GetClasses(w http.ResponseWriter, r *http.Request) {
+	classes, err := t.store.GetClasses(r.Context())
+	if err != nil {
+		t.log.Error("Error retrieving tax classes", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	classes.ToJSON(w)
+}
+
+func (t *taxHandler) CreateClass(w http.ResponseWriter, r *http.Request) {
+	class := &models.TaxClass{}
+	err := class.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := createValidate.Struct(class); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := t.store.CreateClass(r.Context(), class)
+	if err != nil {
+		t.log.Error("Error creating tax class", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(id).ToJSON(w)
+}
+
+func (t *taxHandler) UpdateClass(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	class := &models.TaxClass{}
+	err := class.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	class.Id = id
+
+	if err := updateValidate.Struct(class); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = t.store.UpdateClass(r.Context(), class)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		t.log.Error("Error updating tax class", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (t *taxHandler) DeleteClass(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	err := t.store.DeleteClass(r.Context(), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrTaxClassInUse {
+			http.Error(w, stores.ErrTaxClassInUse.Error(), http.StatusBadRequest)
+			return
+		}
+		t.log.Error("Error deleting tax class", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (t *taxHandler) GetRates(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tenantId, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rates, err := t.store.GetRates(r.Context(), tenantId)
+	if err != nil {
+		t.log.Error("Error retrieving tax rates", "tenant", tenantId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	rates.ToJSON(w)
+}
+
+// CreateRate adds rate of tax class to tenant. Rates are not updated,
+// rate change is new rate valid from date of change
+func (t *taxHandler) CreateRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tenantId, _ := strconv.ParseInt(params["id"], 10, 64) // validated by regex already
+
+	rate := &models.TaxRate{}
+	err := rate.FromJSON(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	rate.TenantId = tenantId
+
+	if err := baseValidate.Struct(rate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := t.store.CreateRate(r.Context(), rate)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if errors.Cause(err) == stores.ErrTaxRateConflict {
+			http.Error(w, stores.ErrTaxRateConflict.Error(), http.StatusBadRequest)
+			return
+		}
+		t.log.Error("Error creating tax rate", "tenant", tenantId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	models.NewIdResponse(id).ToJSON(w)
+}
+
+func (t *taxHandler) DeleteRate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tenantId, _ := strconv.ParseInt(params["id"], 10, 64)   // validated by regex already
+	rateId, _ := strconv.ParseInt(params["rateId"], 10, 64) // validated by regex already
+
+	err := t.store.DeleteRate(r.Context(), tenantId, rateId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		t.log.Error("Error deleting tax rate", "tenant", tenantId, "rate", rateId, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (t *taxHandler) NewClassRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/tax-class", t.GetClasses)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/tax-class", t.CreateClass)
+
+	put := r.Methods(http.MethodPut).Subrouter()
+	put.HandleFunc("/tax-class/{id:[\\d]+}", t.UpdateClass)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/tax-class/{id:[\\d]+}", t.DeleteClass)
+
+	return r
+}
+
+// rates are configured per tenant
+func (t *taxHandler) NewRateRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	get := r.Methods(http.MethodGet).Subrouter()
+	get.HandleFunc("/tenant/{id:[\\d]+}/tax-rate", t.GetRates)
+
+	post := r.Methods(http.MethodPost).Subrouter()
+	post.HandleFunc("/tenant/{id:[\\d]+}/tax-rate", t.CreateRate)
+
+	delete := r.Methods(http.MethodDelete).Subrouter()
+	delete.HandleFunc("/tenant/{id:[\\d]+}/tax-rate/{rateId:[\\d]+}", t.DeleteRate)
+
+	return r
+}
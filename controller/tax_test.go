@@ -0,0 +1,138 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/controller"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/test_util"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeTaxStore struct {
+	mock.Mock
+}
+
+func (h *MyFakeTaxStore) GetClasses(ctx context.Context) (models.TaxClasses, error) {
+	args := h.Called(ctx)
+	return args.Get(0).(models.TaxClasses), args.Error(1)
+}
+
+func (h *MyFakeTaxStore) CreateClass(ctx context.Context, class *models.TaxClass) (int64, error) {
+	args := h.Called(ctx, class)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeTaxStore) UpdateClass(ctx context.Context, class *models.TaxClass) error {
+	args := h.Called(ctx, class)
+	return args.Error(0)
+}
+
+func (h *MyFakeTaxStore) DeleteClass(ctx context.Context, id int64) error {
+	args := h.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (h *MyFakeTaxStore) GetRates(ctx context.Context, tenantId int64) (models.TaxRates, error) {
+	args := h.Called(ctx, tenantId)
+	return args.Get(0).(models.TaxRates), args.Error(1)
+}
+
+func (h *MyFakeTaxStore) CreateRate(ctx context.Context, rate *models.TaxRate) (int64, error) {
+	args := h.Called(ctx, rate)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (h *MyFakeTaxStore) DeleteRate(ctx context.Context, tenantId int64, rateId int64) error {
+	args := h.Called(ctx, tenantId, rateId)
+	return args.Error(0)
+}
+
+func taxTestRouter(store stores.TaxStore, log hclog.Logger) *mux.Router {
+	r := mux.NewRouter()
+
+	taxHandler := controller.NewTaxHandler(store, log)
+	r.PathPrefix("/tax-class").Handler(taxHandler.NewClassRouter())
+	r.PathPrefix("/tenant/{id:[\\d]+}/tax-rate").Handler(taxHandler.NewRateRouter())
+	return r
+}
+
+func TestTax_CreateRate_Success(t *testing.T) {
+	taxStore := &MyFakeTaxStore{}
+	taxStore.On("CreateRate", mock.Anything, mock.Anything).Return(int64(4), nil)
+	router := taxTestRouter(taxStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"taxClassId":1,"rate":2200,"validFrom":"2022-01-01T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/tenant/3/tax-rate", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 201 {
+		t.Errorf("Create rate status code should be 201 but got %v", res.Result().StatusCode)
+	}
+
+	rate := taxStore.Calls[0].Arguments.Get(1).(*models.TaxRate)
+	if rate.TenantId != 3 || rate.TaxClassId != 1 || rate.Rate != 2200 {
+		t.Errorf("Expected 22%% rate of class 1 for tenant 3 but got %#v", rate)
+	}
+}
+
+func TestTax_CreateRate_BadRequest_InvertedValidity(t *testing.T) {
+	taxStore := &MyFakeTaxStore{}
+	router := taxTestRouter(taxStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"taxClassId":1,"rate":2200,"validFrom":"2022-01-01T00:00:00Z","validTo":"2021-12-31T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/tenant/3/tax-rate", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create rate status code should be 400 but got %v", res.Result().StatusCode)
+	}
+
+	taxStore.AssertNotCalled(t, "CreateRate", mock.Anything, mock.Anything)
+}
+
+func TestTax_CreateRate_BadRequest_Overlap(t *testing.T) {
+	taxStore := &MyFakeTaxStore{}
+	taxStore.On("CreateRate", mock.Anything, mock.Anything).Return(int64(0), errors.Wrap(stores.ErrTaxRateConflict, "exclusion"))
+	router := taxTestRouter(taxStore, &test_util.HcLogMock{})
+
+	jsonStr := []byte(`{"taxClassId":1,"rate":950,"validFrom":"2022-01-01T00:00:00Z"}`)
+	req, _ := http.NewRequest("POST", "/tenant/3/tax-rate", bytes.NewBuffer(jsonStr))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Create rate status code should be 400 but got %v", res.Result().StatusCode)
+	}
+	if res.Body.String() != stores.ErrTaxRateConflict.Error()+"\n" {
+		t.Errorf("Expected overlap error but got %v", res.Body.String())
+	}
+}
+
+func TestTax_DeleteClass_InUse(t *testing.T) {
+	taxStore := &MyFakeTaxStore{}
+	taxStore.On("DeleteClass", mock.Anything, int64(2)).Return(errors.Wrap(stores.ErrTaxClassInUse, "fk"))
+	router := taxTestRouter(taxStore, &test_util.HcLogMock{})
+
+	req, _ := http.NewRequest("DELETE", "/tax-class/2", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	if res.Result().StatusCode != 400 {
+		t.Errorf("Delete class status code should be 400 but got %v", res.Result().StatusCode)
+	}
+}
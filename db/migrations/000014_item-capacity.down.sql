@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS accepted_item_date_reservation_idx;
+
+ALTER TABLE item DROP COLUMN capacity;
@@ -0,0 +1,3 @@
+ALTER TABLE item ADD COLUMN capacity integer NOT NULL DEFAULT 1 CHECK (capacity > 0);
+
+CREATE INDEX IF NOT EXISTS accepted_item_date_reservation_idx ON accepted (item_id, date_reservation);
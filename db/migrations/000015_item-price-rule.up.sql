@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "item_price_rule" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	title varchar(255) NOT NULL,
+	priority integer NOT NULL DEFAULT 0,
+	weekdays smallint NOT NULL DEFAULT 0,
+	min_lead_days integer,
+	max_lead_days integer,
+	date_from timestamp,
+	date_to timestamp,
+	modifier_type varchar(20) NOT NULL CHECK (modifier_type IN ('percent', 'absolute')),
+	modifier bigint NOT NULL,
+	disabled boolean NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS item_price_rule_item_idx ON item_price_rule (item_id, priority, id);
@@ -0,0 +1,3 @@
+ALTER TABLE item_date_range_price
+	DROP CONSTRAINT IF EXISTS item_date_range_price_no_overlap,
+	DROP CONSTRAINT IF EXISTS item_date_range_price_valid_range;
@@ -0,0 +1,9 @@
+-- fails if item already has overlapping or inverted ranges, fix those before migrating
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE item_date_range_price
+	ADD CONSTRAINT item_date_range_price_valid_range CHECK (date_from <= date_to),
+	ADD CONSTRAINT item_date_range_price_no_overlap EXCLUDE USING gist (
+		item_id WITH =,
+		daterange(date_from::date, date_to::date, '[]') WITH &&
+	) DEFERRABLE INITIALLY DEFERRED;
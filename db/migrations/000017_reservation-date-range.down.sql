@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS accepted_item_date_end_idx;
+
+ALTER TABLE accepted DROP COLUMN date_end, DROP COLUMN total_price;
+
+ALTER TABLE inquiry DROP COLUMN date_end, DROP COLUMN total_price;
@@ -0,0 +1,18 @@
+-- date_reservation is check-in day, date_end check-out day (night before it is last one)
+ALTER TABLE inquiry
+	ADD COLUMN date_end timestamp,
+	ADD COLUMN total_price bigint;
+
+UPDATE inquiry SET date_end = date_reservation + interval '1 day', total_price = item_price;
+
+ALTER TABLE inquiry ALTER COLUMN date_end SET NOT NULL;
+
+ALTER TABLE accepted
+	ADD COLUMN date_end timestamp,
+	ADD COLUMN total_price bigint;
+
+UPDATE accepted SET date_end = date_reservation + interval '1 day', total_price = item_price;
+
+ALTER TABLE accepted ALTER COLUMN date_end SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS accepted_item_date_end_idx ON accepted (item_id, date_end);
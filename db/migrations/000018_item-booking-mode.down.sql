@@ -0,0 +1,3 @@
+ALTER TABLE item DROP CONSTRAINT item_slot_config_check;
+
+ALTER TABLE item DROP COLUMN booking_mode, DROP COLUMN slot_minutes, DROP COLUMN opens_at, DROP COLUMN closes_at;
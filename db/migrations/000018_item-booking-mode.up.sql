@@ -0,0 +1,11 @@
+-- slot items are reserved by slot_minutes long slots between opens_at and closes_at (UTC)
+ALTER TABLE item
+	ADD COLUMN booking_mode varchar(10) NOT NULL DEFAULT 'daily' CHECK (booking_mode IN ('daily', 'slot')),
+	ADD COLUMN slot_minutes integer CHECK (slot_minutes > 0),
+	ADD COLUMN opens_at time,
+	ADD COLUMN closes_at time;
+
+ALTER TABLE item ADD CONSTRAINT item_slot_config_check CHECK (
+	booking_mode = 'daily' OR
+	(slot_minutes IS NOT NULL AND opens_at IS NOT NULL AND closes_at IS NOT NULL AND opens_at < closes_at)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS item_blackout;
+
+DROP TABLE IF EXISTS item_schedule;
@@ -0,0 +1,24 @@
+-- weekly opening hours, entry without hours means whole day. Item without entries is always open
+CREATE TABLE IF NOT EXISTS "item_schedule" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	weekday smallint NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+	opens_at time,
+	closes_at time,
+	CHECK ((opens_at IS NULL) = (closes_at IS NULL)),
+	CHECK (opens_at < closes_at)
+);
+
+CREATE INDEX IF NOT EXISTS item_schedule_item_idx ON item_schedule (item_id, weekday);
+
+-- periods in which item can not be booked (date_to exclusive)
+CREATE TABLE IF NOT EXISTS "item_blackout" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	date_from timestamp NOT NULL,
+	date_to timestamp NOT NULL,
+	reason varchar(255) NOT NULL DEFAULT '',
+	CHECK (date_from < date_to)
+);
+
+CREATE INDEX IF NOT EXISTS item_blackout_item_idx ON item_blackout (item_id, date_from);
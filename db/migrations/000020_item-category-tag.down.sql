@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS item_tag;
+
+ALTER TABLE item DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS category;
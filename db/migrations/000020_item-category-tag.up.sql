@@ -0,0 +1,22 @@
+-- categories form tree, removing category moves its children to top level
+CREATE TABLE IF NOT EXISTS "category" (
+	id bigserial primary key,
+	parent_id bigint REFERENCES category(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	title varchar(255) NOT NULL,
+	CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS category_parent_idx ON category (parent_id);
+
+ALTER TABLE item ADD COLUMN category_id bigint REFERENCES category(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS item_category_idx ON item (category_id);
+
+-- tags are stored lowercased
+CREATE TABLE IF NOT EXISTS "item_tag" (
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tag varchar(50) NOT NULL,
+	PRIMARY KEY (item_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS item_tag_tag_idx ON item_tag (tag);
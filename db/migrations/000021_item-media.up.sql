@@ -0,0 +1,16 @@
+-- files are kept in blob store under key, url is public address of file
+CREATE TABLE IF NOT EXISTS "item_media" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	kind varchar(20) NOT NULL CHECK (kind IN ('image', 'document')),
+	file_name varchar(255) NOT NULL,
+	content_type varchar(100) NOT NULL,
+	size bigint NOT NULL,
+	key varchar(255) NOT NULL UNIQUE,
+	thumbnail_key varchar(255),
+	url text NOT NULL,
+	thumbnail_url text,
+	date_created timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS item_media_item_idx ON item_media (item_id, date_created);
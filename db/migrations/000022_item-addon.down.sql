@@ -0,0 +1,10 @@
+ALTER TABLE accepted
+	DROP COLUMN addons_total,
+	DROP COLUMN inquiry_id;
+
+ALTER TABLE inquiry
+	DROP COLUMN addons_total;
+
+DROP TABLE IF EXISTS accepted_addon;
+DROP TABLE IF EXISTS inquiry_addon;
+DROP TABLE IF EXISTS item_addon;
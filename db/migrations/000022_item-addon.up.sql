@@ -0,0 +1,45 @@
+-- unit add-ons are charged price * quantity, booking add-ons once per booking
+CREATE TABLE IF NOT EXISTS "item_addon" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	title varchar(255) NOT NULL,
+	price bigint NOT NULL CHECK (price >= 0),
+	charge varchar(20) NOT NULL CHECK (charge IN ('unit', 'booking')),
+	disabled boolean NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS item_addon_item_idx ON item_addon (item_id, id);
+
+-- line items are snapshots, title and price are kept when add-on is changed or deleted
+CREATE TABLE IF NOT EXISTS "inquiry_addon" (
+	id bigserial primary key,
+	inquiry_id bigint NOT NULL REFERENCES inquiry(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	addon_id bigint REFERENCES item_addon(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	title varchar(255) NOT NULL,
+	price bigint NOT NULL,
+	charge varchar(20) NOT NULL,
+	quantity integer NOT NULL CHECK (quantity > 0),
+	total bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS inquiry_addon_inquiry_idx ON inquiry_addon (inquiry_id);
+
+CREATE TABLE IF NOT EXISTS "accepted_addon" (
+	id bigserial primary key,
+	accepted_id bigint NOT NULL REFERENCES accepted(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	addon_id bigint REFERENCES item_addon(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	title varchar(255) NOT NULL,
+	price bigint NOT NULL,
+	charge varchar(20) NOT NULL,
+	quantity integer NOT NULL CHECK (quantity > 0),
+	total bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS accepted_addon_accepted_idx ON accepted_addon (accepted_id);
+
+ALTER TABLE inquiry
+	ADD COLUMN addons_total bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE accepted
+	ADD COLUMN inquiry_id bigint REFERENCES inquiry(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	ADD COLUMN addons_total bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS item_tenant_idx;
+
+ALTER TABLE accepted_addon DROP COLUMN currency;
+ALTER TABLE accepted DROP COLUMN currency;
+ALTER TABLE inquiry_addon DROP COLUMN currency;
+ALTER TABLE inquiry DROP COLUMN currency;
+ALTER TABLE item_addon DROP COLUMN currency;
+ALTER TABLE item_date_range_price DROP COLUMN currency;
+
+ALTER TABLE item
+	DROP COLUMN currency,
+	DROP COLUMN tenant_id;
+
+ALTER TABLE tenant DROP COLUMN currency;
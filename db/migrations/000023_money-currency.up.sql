@@ -0,0 +1,28 @@
+-- prices stay in bigint columns (minor units), currency is stored next to them.
+-- Existing rows get EUR, amounts are left untouched
+ALTER TABLE tenant
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE item
+	ADD COLUMN tenant_id bigint REFERENCES tenant(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE item_date_range_price
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE item_addon
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE inquiry
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE inquiry_addon
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE accepted
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+ALTER TABLE accepted_addon
+	ADD COLUMN currency char(3) NOT NULL DEFAULT 'EUR';
+
+CREATE INDEX IF NOT EXISTS item_tenant_idx ON item (tenant_id);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS accepted_tax;
+DROP TABLE IF EXISTS inquiry_tax;
+
+ALTER TABLE accepted_addon DROP COLUMN tax_class_id;
+ALTER TABLE inquiry_addon DROP COLUMN tax_class_id;
+ALTER TABLE item_addon DROP COLUMN tax_class_id;
+ALTER TABLE item DROP COLUMN tax_class_id;
+
+DROP TABLE IF EXISTS tax_rate;
+DROP TABLE IF EXISTS tax_class;
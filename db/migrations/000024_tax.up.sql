@@ -0,0 +1,60 @@
+-- rates are in basis points (2200 = 22%), prices include tax
+CREATE TABLE IF NOT EXISTS "tax_class" (
+	id bigserial primary key,
+	title varchar(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "tax_rate" (
+	id bigserial primary key,
+	tenant_id bigint NOT NULL REFERENCES tenant(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tax_class_id bigint NOT NULL REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	rate integer NOT NULL CHECK (rate >= 0 AND rate <= 10000),
+	valid_from date NOT NULL,
+	valid_to date,
+	CONSTRAINT tax_rate_valid_range CHECK (valid_to IS NULL OR valid_from <= valid_to),
+	CONSTRAINT tax_rate_no_overlap EXCLUDE USING gist (
+		tenant_id WITH =,
+		tax_class_id WITH =,
+		daterange(valid_from, valid_to, '[]') WITH &&
+	)
+);
+
+-- tax class in use by items or add-ons can not be removed
+ALTER TABLE item
+	ADD COLUMN tax_class_id bigint REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE item_addon
+	ADD COLUMN tax_class_id bigint REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE inquiry_addon
+	ADD COLUMN tax_class_id bigint REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+ALTER TABLE accepted_addon
+	ADD COLUMN tax_class_id bigint REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+-- tax breakdown lines, one per tax class. Rate is kept when class is removed
+CREATE TABLE IF NOT EXISTS "inquiry_tax" (
+	id bigserial primary key,
+	inquiry_id bigint NOT NULL REFERENCES inquiry(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tax_class_id bigint REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	rate integer NOT NULL,
+	net bigint NOT NULL,
+	tax bigint NOT NULL,
+	gross bigint NOT NULL,
+	currency char(3) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS inquiry_tax_inquiry_idx ON inquiry_tax (inquiry_id);
+
+CREATE TABLE IF NOT EXISTS "accepted_tax" (
+	id bigserial primary key,
+	accepted_id bigint NOT NULL REFERENCES accepted(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tax_class_id bigint REFERENCES tax_class(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	rate integer NOT NULL,
+	net bigint NOT NULL,
+	tax bigint NOT NULL,
+	gross bigint NOT NULL,
+	currency char(3) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS accepted_tax_accepted_idx ON accepted_tax (accepted_id);
@@ -0,0 +1,11 @@
+ALTER TABLE accepted
+	DROP COLUMN discount,
+	DROP COLUMN promo_code;
+
+ALTER TABLE inquiry
+	DROP COLUMN discount,
+	DROP COLUMN promo_code;
+
+DROP TABLE IF EXISTS promo_redemption;
+DROP TABLE IF EXISTS promo_code_item;
+DROP TABLE IF EXISTS promo_code;
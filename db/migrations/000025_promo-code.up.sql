@@ -0,0 +1,44 @@
+-- codes are stored uppercased. Percent discount is 1-100, fixed discount
+-- is amount in minor units of currency
+CREATE TABLE IF NOT EXISTS "promo_code" (
+	id bigserial primary key,
+	code varchar(50) NOT NULL UNIQUE,
+	discount_type varchar(20) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+	discount bigint NOT NULL CHECK (discount > 0),
+	currency char(3),
+	valid_from timestamp,
+	valid_to timestamp,
+	max_uses integer CHECK (max_uses > 0),
+	max_uses_per_customer integer CHECK (max_uses_per_customer > 0),
+	disabled boolean NOT NULL DEFAULT FALSE,
+	CHECK (discount_type <> 'percent' OR discount <= 100),
+	CHECK (discount_type <> 'fixed' OR currency IS NOT NULL),
+	CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to)
+);
+
+-- codes without items apply to every item
+CREATE TABLE IF NOT EXISTS "promo_code_item" (
+	promo_code_id bigint NOT NULL REFERENCES promo_code(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	PRIMARY KEY (promo_code_id, item_id)
+);
+
+-- redeemed codes can not be deleted, only disabled
+CREATE TABLE IF NOT EXISTS "promo_redemption" (
+	id bigserial primary key,
+	promo_code_id bigint NOT NULL REFERENCES promo_code(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	inquiry_id bigint NOT NULL UNIQUE REFERENCES inquiry(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	discount bigint NOT NULL,
+	currency char(3) NOT NULL,
+	date_created timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS promo_redemption_promo_code_idx ON promo_redemption (promo_code_id);
+
+ALTER TABLE inquiry
+	ADD COLUMN promo_code varchar(50),
+	ADD COLUMN discount bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE accepted
+	ADD COLUMN promo_code varchar(50),
+	ADD COLUMN discount bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+ALTER TABLE accepted
+	DROP CONSTRAINT accepted_item_id_fkey,
+	ADD CONSTRAINT accepted_item_id_fkey FOREIGN KEY (item_id) REFERENCES item (id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE inquiry
+	DROP CONSTRAINT inquiry_item_fk,
+	ADD CONSTRAINT inquiry_item_fk FOREIGN KEY (item_id) REFERENCES item (id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE item DROP COLUMN archived_at;
@@ -0,0 +1,12 @@
+-- deleted items are archived, reservations keep referencing them.
+-- Items with reservations can not be removed anymore
+ALTER TABLE item
+	ADD COLUMN archived_at timestamp;
+
+ALTER TABLE inquiry
+	DROP CONSTRAINT inquiry_item_fk,
+	ADD CONSTRAINT inquiry_item_fk FOREIGN KEY (item_id) REFERENCES item (id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE accepted
+	DROP CONSTRAINT accepted_item_id_fkey,
+	ADD CONSTRAINT accepted_item_id_fkey FOREIGN KEY (item_id) REFERENCES item (id) ON UPDATE CASCADE ON DELETE RESTRICT;
@@ -0,0 +1,26 @@
+-- snapshots of item title and prices, new version is saved on every change.
+-- Date prices are kept as json array of {id, dateFrom, dateTo, price}
+CREATE TABLE IF NOT EXISTS "item_history" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	version integer NOT NULL,
+	changed_at timestamp NOT NULL DEFAULT (now() at time zone 'utc'),
+	changed_by bigint REFERENCES reservation_user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	title varchar(255) NOT NULL,
+	price bigint NOT NULL,
+	currency char(3) NOT NULL,
+	date_prices jsonb NOT NULL DEFAULT '[]',
+	UNIQUE (item_id, version)
+);
+
+-- existing items start with their current state
+INSERT INTO item_history (item_id, version, title, price, currency, date_prices)
+SELECT i.id, 1, i.title, i.price, i.currency,
+	COALESCE((SELECT jsonb_agg(jsonb_build_object(
+				'id', p.id,
+				'dateFrom', to_char(p.date_from, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+				'dateTo', to_char(p.date_to, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+				'price', jsonb_build_object('amount', p.price, 'currency', p.currency))
+				ORDER BY p.date_from, p.id)
+			FROM item_date_range_price p WHERE p.item_id = i.id), '[]')
+FROM item i;
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS item_booking;
+DROP TABLE IF EXISTS "accepted_component";
+DROP TABLE IF EXISTS "item_bundle_component";
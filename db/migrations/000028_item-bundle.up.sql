@@ -0,0 +1,32 @@
+-- bundle is item made of other (daily) items, each taken in given quantity
+CREATE TABLE IF NOT EXISTS "item_bundle_component" (
+	bundle_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	quantity integer NOT NULL CHECK (quantity > 0),
+	PRIMARY KEY (bundle_id, item_id),
+	CHECK (bundle_id <> item_id)
+);
+
+CREATE INDEX IF NOT EXISTS item_bundle_component_item_idx ON item_bundle_component (item_id);
+
+-- components reserved by accepted bundle
+CREATE TABLE IF NOT EXISTS "accepted_component" (
+	accepted_id bigint NOT NULL REFERENCES accepted(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	item_title varchar(255) NOT NULL,
+	quantity integer NOT NULL CHECK (quantity > 0),
+	PRIMARY KEY (accepted_id, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS accepted_component_item_idx ON accepted_component (item_id);
+
+-- units of item capacity taken by accepted reservations. Accepted bundles
+-- take capacity of their components, not of bundle item
+CREATE OR REPLACE VIEW item_booking AS
+	SELECT a.id AS accepted_id, a.item_id, 1 AS quantity, a.date_reservation, a.date_end
+		FROM accepted a
+		WHERE NOT EXISTS (SELECT 1 FROM accepted_component c WHERE c.accepted_id = a.id)
+	UNION ALL
+	SELECT a.id, c.item_id, c.quantity, a.date_reservation, a.date_end
+		FROM accepted_component c
+			JOIN accepted a ON a.id = c.accepted_id;
@@ -0,0 +1,2 @@
+ALTER TABLE accepted DROP COLUMN unit_id;
+DROP TABLE IF EXISTS "item_unit";
@@ -0,0 +1,14 @@
+-- physical units of item. Items with units take capacity from their active units
+CREATE TABLE IF NOT EXISTS "item_unit" (
+	id bigserial primary key,
+	item_id bigint NOT NULL REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	label varchar(100) NOT NULL,
+	status varchar(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'out_of_service')),
+	UNIQUE (item_id, label)
+);
+
+-- units with reservations can only be taken out of service
+ALTER TABLE accepted
+	ADD COLUMN unit_id bigint REFERENCES item_unit(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS accepted_unit_idx ON accepted (unit_id);
@@ -0,0 +1,14 @@
+-- booking rules of item or tenant (defaults of its items). Missing rule does
+-- not limit reservations
+CREATE TABLE IF NOT EXISTS "booking_rule" (
+	id bigserial primary key,
+	item_id bigint UNIQUE REFERENCES item(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	tenant_id bigint UNIQUE REFERENCES tenant(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	min_notice_hours integer CHECK (min_notice_hours >= 0),
+	max_advance_days integer CHECK (max_advance_days > 0),
+	min_stay integer CHECK (min_stay > 0),
+	max_stay integer CHECK (max_stay > 0),
+	arrival_weekdays smallint[],
+	CHECK ((item_id IS NULL) <> (tenant_id IS NULL)),
+	CHECK (min_stay IS NULL OR max_stay IS NULL OR min_stay <= max_stay)
+);
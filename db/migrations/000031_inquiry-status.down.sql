@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS inquiry_status_idx;
+
+ALTER TABLE inquiry
+	DROP COLUMN status_note,
+	DROP COLUMN status_changed_at,
+	DROP COLUMN status;
@@ -0,0 +1,13 @@
+-- inquiries are kept after decision, status tells what happened to them.
+-- Inquiries already processed to accepted are marked as accepted
+ALTER TABLE inquiry
+	ADD COLUMN status varchar(20) NOT NULL DEFAULT 'pending'
+		CHECK (status IN ('pending', 'accepted', 'rejected', 'expired', 'cancelled')),
+	ADD COLUMN status_changed_at timestamp,
+	ADD COLUMN status_note text;
+
+UPDATE inquiry inq SET status = 'accepted', status_changed_at = a.date_accepted
+	FROM accepted a
+	WHERE a.inquiry_id = inq.id;
+
+CREATE INDEX IF NOT EXISTS inquiry_status_idx ON inquiry (status, date_created);
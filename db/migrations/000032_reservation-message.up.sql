@@ -0,0 +1,25 @@
+-- conversation of inquiry, carried over to accepted reservation. Customer
+-- messages come from inquirer, replies are written by staff and visible to
+-- customer, notes are internal. Author is missing for customer messages
+-- and removed users
+CREATE TABLE IF NOT EXISTS "reservation_message" (
+	id bigserial primary key,
+	inquiry_id bigint REFERENCES inquiry(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	accepted_id bigint REFERENCES accepted(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	kind varchar(20) NOT NULL CHECK (kind IN ('customer', 'reply', 'note')),
+	author_id bigint REFERENCES reservation_user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	author_name varchar(255),
+	body text NOT NULL,
+	date_created timestamp NOT NULL,
+	CHECK (inquiry_id IS NOT NULL OR accepted_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS reservation_message_inquiry_idx ON reservation_message (inquiry_id, date_created);
+CREATE INDEX IF NOT EXISTS reservation_message_accepted_idx ON reservation_message (accepted_id, date_created);
+
+-- comments of existing inquiries start their threads
+INSERT INTO reservation_message (inquiry_id, accepted_id, kind, author_name, body, date_created)
+	SELECT inq.id, (SELECT a.id FROM accepted a WHERE a.inquiry_id = inq.id ORDER BY a.id LIMIT 1),
+		'customer', inq.inquirer, inq.comment, inq.date_created
+	FROM inquiry inq
+	WHERE COALESCE(inq.comment, '') <> '';
@@ -0,0 +1,3 @@
+ALTER TABLE accepted DROP COLUMN cancellation_requested_at;
+
+DROP TABLE IF EXISTS reservation_access;
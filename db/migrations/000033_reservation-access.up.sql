@@ -0,0 +1,18 @@
+-- self-service links of customers. Token of link is signed by server and
+-- refers to access row, so staff can revoke it before it expires. Access
+-- of inquiry is carried over to accepted reservation
+CREATE TABLE IF NOT EXISTS "reservation_access" (
+	id bigserial primary key,
+	inquiry_id bigint REFERENCES inquiry(id) ON UPDATE CASCADE ON DELETE SET NULL,
+	accepted_id bigint REFERENCES accepted(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	expires_at timestamp NOT NULL,
+	revoked_at timestamp,
+	date_created timestamp NOT NULL,
+	CHECK (inquiry_id IS NOT NULL OR accepted_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS reservation_access_inquiry_idx ON reservation_access (inquiry_id);
+CREATE INDEX IF NOT EXISTS reservation_access_accepted_idx ON reservation_access (accepted_id);
+
+-- customers can ask for cancellation of accepted reservations, staff decides
+ALTER TABLE accepted ADD COLUMN cancellation_requested_at timestamp;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS accepted_reference_idx;
+DROP INDEX IF EXISTS inquiry_reference_idx;
+
+ALTER TABLE accepted DROP COLUMN reference;
+ALTER TABLE inquiry DROP COLUMN reference;
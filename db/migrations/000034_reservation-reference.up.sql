@@ -0,0 +1,53 @@
+-- human readable reference codes (e.g. K7F-92QX) of inquiries, carried over
+-- to reservation accepted from inquiry. Codes are random and use characters
+-- that are not easily mistaken for each other (no 0, O, 1, I or L)
+ALTER TABLE inquiry ADD COLUMN reference varchar(8);
+ALTER TABLE accepted ADD COLUMN reference varchar(8);
+
+-- generates code for existing rows, volatile so it is evaluated for every row
+CREATE FUNCTION pg_temp.random_reference() RETURNS varchar(8) AS $$
+	SELECT overlay(string_agg(substr('23456789ABCDEFGHJKMNPQRSTUVWXYZ', floor(random() * 31)::int + 1, 1), '')
+			PLACING '-' FROM 4 FOR 0)
+		FROM generate_series(1, 7)
+$$ LANGUAGE sql VOLATILE;
+
+UPDATE inquiry SET reference = pg_temp.random_reference();
+
+-- random codes can collide, all but first inquiry with code get new one until codes are unique
+DO $$
+BEGIN
+	WHILE EXISTS (SELECT 1 FROM inquiry GROUP BY reference HAVING COUNT(*) > 1) LOOP
+		UPDATE inquiry SET reference = pg_temp.random_reference()
+			WHERE id IN (SELECT d.id FROM (
+					SELECT id, row_number() OVER (PARTITION BY reference ORDER BY id) AS n FROM inquiry
+				) d WHERE d.n > 1);
+	END LOOP;
+END $$;
+
+-- first reservation of inquiry keeps its code, others get their own
+UPDATE accepted a SET reference = inq.reference
+	FROM inquiry inq
+	WHERE inq.id = a.inquiry_id
+		AND a.id = (SELECT MIN(f.id) FROM accepted f WHERE f.inquiry_id = a.inquiry_id);
+
+UPDATE accepted SET reference = pg_temp.random_reference() WHERE reference IS NULL;
+
+-- colliding codes are replaced, codes carried over from inquiry are kept
+DO $$
+BEGIN
+	WHILE EXISTS (SELECT 1 FROM accepted GROUP BY reference HAVING COUNT(*) > 1) LOOP
+		UPDATE accepted SET reference = pg_temp.random_reference()
+			WHERE id IN (SELECT d.id FROM (
+					SELECT a.id, row_number() OVER (PARTITION BY a.reference
+						ORDER BY (a.reference = inq.reference) IS NOT TRUE, a.id) AS n
+					FROM accepted a
+						LEFT JOIN inquiry inq ON inq.id = a.inquiry_id
+				) d WHERE d.n > 1);
+	END LOOP;
+END $$;
+
+ALTER TABLE inquiry ALTER COLUMN reference SET NOT NULL;
+ALTER TABLE accepted ALTER COLUMN reference SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS inquiry_reference_idx ON inquiry (reference);
+CREATE UNIQUE INDEX IF NOT EXISTS accepted_reference_idx ON accepted (reference);
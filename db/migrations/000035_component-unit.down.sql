@@ -0,0 +1,2 @@
+DROP VIEW IF EXISTS unit_booking;
+DROP TABLE IF EXISTS "accepted_component_unit";
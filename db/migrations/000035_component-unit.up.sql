@@ -0,0 +1,22 @@
+-- units of unit tracked components assigned to accepted bundle, one per
+-- reserved quantity of component
+CREATE TABLE IF NOT EXISTS "accepted_component_unit" (
+	accepted_id bigint NOT NULL,
+	item_id bigint NOT NULL,
+	unit_id bigint NOT NULL REFERENCES item_unit(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+	PRIMARY KEY (accepted_id, unit_id),
+	FOREIGN KEY (accepted_id, item_id) REFERENCES accepted_component(accepted_id, item_id)
+		ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS accepted_component_unit_unit_idx ON accepted_component_unit (unit_id);
+
+-- units taken by accepted reservations, directly or through bundle components
+CREATE OR REPLACE VIEW unit_booking AS
+	SELECT a.id AS accepted_id, a.unit_id, a.date_reservation, a.date_end
+		FROM accepted a
+		WHERE a.unit_id IS NOT NULL
+	UNION ALL
+	SELECT a.id, cu.unit_id, a.date_reservation, a.date_end
+		FROM accepted_component_unit cu
+			JOIN accepted a ON a.id = cu.accepted_id;
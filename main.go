@@ -14,6 +14,10 @@ import (
 	"github.com/alesbrelih/go-reservation-api/config"
 	"github.com/alesbrelih/go-reservation-api/db"
 	"github.com/alesbrelih/go-reservation-api/router"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/stores"
+	"github.com/alesbrelih/go-reservation-api/worker"
+	"github.com/hashicorp/go-hclog"
 )
 
 func main() {
@@ -23,12 +27,41 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	config.MediaDefaults()
+	if err := config.ExpiryDefaults(); err != nil {
+		panic(err)
+	}
+	config.SelfServiceDefaults()
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	dbFactory := db.NewDbFactory(config.Database.URL)
 
-	mux := router.InitializeRouter(dbFactory, config)
+	workerLogger := hclog.New(&hclog.LoggerOptions{
+		Name:  "reservation-worker",
+		Level: hclog.LevelFromString("DEBUG"),
+	})
+	notifier := services.NewLogNotifier(workerLogger.Named("notifier"))
+	if config.Smtp.Host != "" {
+		notifier = services.NewSmtpNotifier(config.Smtp.Host, config.Smtp.Port, config.Smtp.Username,
+			config.Smtp.Password, config.Smtp.From)
+	}
+
+	mux := router.InitializeRouter(dbFactory, config, notifier)
+
+	// expires stale inquiries in background until shutdown
+	expiry := worker.NewInquiryExpiry(
+		stores.NewInquiryStore(dbFactory, services.NewPricingService()),
+		notifier,
+		worker.ExpiryPolicy{
+			Interval: config.Expiry.Interval,
+			MaxAge:   config.Expiry.MaxAge,
+			Notify:   config.Expiry.Notify,
+		},
+		workerLogger.Named("inquiry-expiry"))
+	if !config.Expiry.Disabled {
+		expiry.Start(ctx)
+	}
 
 	l := log.New(os.Stdout, "reservations", log.LstdFlags)
 
@@ -45,7 +78,7 @@ func main() {
 	// open server in  nonblocking way
 	go func() {
 		err := server.ListenAndServe()
-		if err != nil {
+		if err != nil && err != http.ErrServerClosed {
 			l.Fatalf("HTTP server ListenAndServe: %v", err)
 		}
 	}()
@@ -65,6 +98,7 @@ func main() {
 	defer cancelShutdown()
 
 	err = server.Shutdown(gracefullCtx)
+	expiry.Stop()
 	if err != nil {
 		log.Printf("Shutdown error: %v\n", err)
 		os.Exit(1)
@@ -2,26 +2,66 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"time"
 )
 
-// todo: price checking needs to check if int only!
 // Implement custom marshaller for dates to avoid pointers
 //  had to use pointer else omitempty doesnt work
 type Accepted struct {
 	Id                 int64      `json:"id,omitempty" validate:"omitempty,required"`
-	Inquirer           string     `json:"inquirer,omitempty" validate:"required"`
+	Inquirer           string     `json:"inquirer,omitempty" validate:"required_without=InquiryId"`
 	InquirerEmail      string     `json:"inquirerEmail,omitempty" validate:"omitempty,required_without=Phone,email"`
 	InquirerPhone      string     `json:"inquirerPhone,omitempty" validate:"omitempty,required_without=Email,e164"`
 	InquirerComment    string     `json:"inquirerComment,omitempty"`
 	ItemId             int64      `json:"itemId,omitempty" validate:"omitempty,number,required_without=ItemTitle ItemPrice"`
 	ItemTitle          string     `json:"itemTitle,omitempty" validate:"omitempty,required_without=ItemId"`
-	ItemPrice          int64      `json:"itemPrice,omitempty" validate:"omitempty,number,required_without=ItemId"`
+	ItemPrice          Money      `json:"itemPrice"`
 	Notes              string     `json:"notes,omitempty"`
-	DateReservation    *time.Time `json:"dateReservation,omitempty" validate:"required"`
+	TotalPrice         Money      `json:"totalPrice"`
+	DateReservation    *time.Time `json:"dateReservation,omitempty" validate:"required_without=InquiryId"`
+	DateEnd            *time.Time `json:"dateEnd,omitempty" validate:"omitempty,gtfield=DateReservation"`
 	DateInquiryCreated *time.Time `json:"dateInquiryCreated,omitempty"`
 	DateAccepted       *time.Time `json:"dateAccepted,omitempty"`
+	// reservation of inquiry is made from inquiry as it was stored, add-on
+	// line items of inquiry are copied and their total is added to total
+	// price (price of reservation itself)
+	InquiryId   int64           `json:"inquiryId,omitempty" validate:"omitempty,min=1"`
+	AddonsTotal Money           `json:"addonsTotal"`
+	Addons      []AddonLineItem `json:"addons,omitempty"`
+	// tax breakdown of inquiry, recomputed only when total price was changed
+	Tax *TaxBreakdown `json:"tax,omitempty"`
+	// promo code discount of inquiry
+	PromoCode string `json:"promoCode,omitempty"`
+	Discount  Money  `json:"discount"`
+	// components reserved together with bundle item
+	Components BundleComponents `json:"components,omitempty"`
+	// unit of item assigned on accept, items without units have none
+	UnitId    *int64 `json:"unitId,omitempty"`
+	UnitLabel string `json:"unitLabel,omitempty"`
+	// reference code generated on save, reservation accepted from inquiry
+	// keeps code of inquiry
+	Reference string `json:"reference,omitempty"`
+	// set when customer asked for cancellation through self-service link
+	CancellationRequestedAt *time.Time `json:"cancellationRequestedAt,omitempty"`
+}
+
+// ErrInquiryOverride is returned when reservation of inquiry sets item, dates,
+// prices or discount, those are always taken from inquiry
+var ErrInquiryOverride = errors.New("Item, dates, prices and discount of inquiry reservation are taken from inquiry and must not be set")
+
+// InquiryOverrideError returns ErrInquiryOverride when reservation of inquiry
+// (InquiryId) sets any of the fields taken from inquiry
+func (a *Accepted) InquiryOverrideError() error {
+	if a.InquiryId == 0 {
+		return nil
+	}
+	if a.ItemId != 0 || a.ItemTitle != "" || a.ItemPrice != (Money{}) || a.TotalPrice != (Money{}) ||
+		a.DateReservation != nil || a.DateEnd != nil || a.PromoCode != "" || a.Discount != (Money{}) {
+		return ErrInquiryOverride
+	}
+	return nil
 }
 
 func (a *Accepted) ToJSON(w io.Writer) error {
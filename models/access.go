@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ReservationAccess is self-service access of customer to inquiry or
+// accepted reservation. Access is valid until it expires or is revoked
+type ReservationAccess struct {
+	Id         int64  `json:"id"`
+	InquiryId  *int64 `json:"inquiryId,omitempty"`
+	AcceptedId *int64 `json:"acceptedId,omitempty"`
+	// contact email of reservation, link is sent to it
+	Email       string     `json:"-"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	DateCreated time.Time  `json:"dateCreated"`
+}
+
+type ReservationAccesses []ReservationAccess
+
+func (a ReservationAccesses) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+// AccessLink is signed token of access, link is missing when self-service
+// url is not configured
+type AccessLink struct {
+	Id        int64     `json:"id"`
+	Token     string    `json:"token"`
+	Link      string    `json:"link,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (a *AccessLink) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+// InquiryCreated is returned to customer that submitted inquiry. Access is
+// missing when link could not be issued
+type InquiryCreated struct {
+	Id        int64       `json:"id"`
+	Reference string      `json:"reference"`
+	Access    *AccessLink `json:"access,omitempty"`
+}
+
+func (i *InquiryCreated) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(i)
+}
+
+// CustomerReservation is inquiry or accepted reservation as seen by customer.
+// Internal notes and staff fields are left out
+type CustomerReservation struct {
+	Reference               string     `json:"reference"`
+	Inquirer                string     `json:"inquirer"`
+	Email                   string     `json:"email,omitempty"`
+	Phone                   string     `json:"phone,omitempty"`
+	ItemTitle               string     `json:"itemTitle"`
+	DateReservation         time.Time  `json:"dateReservation"`
+	DateEnd                 time.Time  `json:"dateEnd"`
+	TotalPrice              Money      `json:"totalPrice"`
+	AddonsTotal             Money      `json:"addonsTotal"`
+	Status                  string     `json:"status"`
+	StatusNote              string     `json:"statusNote,omitempty"`
+	CancellationRequestedAt *time.Time `json:"cancellationRequestedAt,omitempty"`
+	Messages                Messages   `json:"messages"`
+}
+
+func (c *CustomerReservation) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+// CustomerContact replaces contact details of reservation
+type CustomerContact struct {
+	Email string `json:"email" validate:"required_without=Phone,omitempty,email"`
+	Phone string `json:"phone" validate:"required_without=Email,omitempty,e164"`
+}
+
+func (c *CustomerContact) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(c)
+}
+
+// CancellationRequest is sent by customer. Pending inquiries are cancelled
+// right away, cancellation of accepted reservation is decided by staff
+type CancellationRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+func (c *CancellationRequest) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(c)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// add-on charge types
+const (
+	AddonChargeUnit    = "unit"
+	AddonChargeBooking = "booking"
+)
+
+// ItemAddon is paid extra (equipment rental, breakfast, cleaning) offered with item.
+// Unit add-ons are charged price for every selected unit, booking add-ons
+// are charged price once per booking
+type ItemAddon struct {
+	Id         int64  `json:"id,omitempty"`
+	ItemId     int64  `json:"itemId,omitempty"`
+	Title      string `json:"title" validate:"required,gt=2,max=255"`
+	Price      Money  `json:"price"`
+	Charge     string `json:"charge" validate:"required,oneof=unit booking"`
+	Disabled   bool   `json:"disabled"`
+	TaxClassId *int64 `json:"taxClassId,omitempty" validate:"omitempty,min=1"`
+}
+
+func (a *ItemAddon) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(a)
+}
+
+func (a *ItemAddon) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+type ItemAddons []ItemAddon
+
+func (a ItemAddons) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+// AddonSelection is add-on requested on inquiry. Missing quantity means single unit
+type AddonSelection struct {
+	AddonId  int64 `json:"addonId" validate:"required"`
+	Quantity int   `json:"quantity" validate:"omitempty,min=1,max=1000"`
+}
+
+// AddonLineItem is snapshot of add-on priced for reservation. AddonId is
+// missing once add-on is deleted
+type AddonLineItem struct {
+	AddonId    *int64 `json:"addonId,omitempty"`
+	Title      string `json:"title"`
+	Charge     string `json:"charge"`
+	Price      Money  `json:"price"`
+	Quantity   int    `json:"quantity"`
+	Total      Money  `json:"total"`
+	TaxClassId *int64 `json:"taxClassId,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// AvailabilitySearch looks for items free on every night from check-in (From)
+// to check-out (To). Every guest takes one place of item capacity
+type AvailabilitySearch struct {
+	From       time.Time
+	To         time.Time
+	Guests     int64
+	CategoryId *int64
+}
+
+// AvailableItem is item found by availability search. Remaining is the
+// least free capacity over all nights of stay
+type AvailableItem struct {
+	Item      Item
+	Remaining int64
+}
+
+// AvailabilityMatch is item found by availability search as returned
+// to client, priced for the whole stay
+type AvailabilityMatch struct {
+	ItemId     int64     `json:"itemId"`
+	Title      string    `json:"title"`
+	CategoryId *int64    `json:"categoryId,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	Remaining  int64     `json:"remaining"`
+	DateFrom   time.Time `json:"dateFrom"`
+	DateTo     time.Time `json:"dateTo"`
+	Total      Money     `json:"total"`
+}
+
+type AvailabilityMatches []AvailabilityMatch
+
+func (a AvailabilityMatches) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+// NewAvailabilityMatch converts found item to match priced by stay quote
+func NewAvailabilityMatch(found *AvailableItem, quote *StayQuote) AvailabilityMatch {
+	match := AvailabilityMatch{
+		ItemId:     found.Item.Id,
+		CategoryId: found.Item.CategoryId,
+		Tags:       found.Item.Tags,
+		Remaining:  found.Remaining,
+		DateFrom:   quote.DateFrom,
+		DateTo:     quote.DateTo,
+		Total:      quote.Total,
+	}
+	if found.Item.Title != nil {
+		match.Title = *found.Item.Title
+	}
+	return match
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// BookingRules limit when and for how long item can be reserved. Rules are set
+// per item, rules missing on item are taken from its tenant and missing rules
+// do not limit reservations. Stay is counted in nights (slots for slot booked
+// items), arrival weekdays are 0 (sunday) to 6
+type BookingRules struct {
+	MinNoticeHours  *int64 `json:"minNoticeHours,omitempty" validate:"omitempty,min=0"`
+	MaxAdvanceDays  *int64 `json:"maxAdvanceDays,omitempty" validate:"omitempty,min=1"`
+	MinStay         *int64 `json:"minStay,omitempty" validate:"omitempty,min=1"`
+	MaxStay         *int64 `json:"maxStay,omitempty" validate:"omitempty,min=1"`
+	ArrivalWeekdays []int  `json:"arrivalWeekdays,omitempty" validate:"omitempty,dive,min=0,max=6"`
+}
+
+func (b *BookingRules) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(b)
+}
+
+func (b *BookingRules) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(b)
+}
+
+// StayError returns error when minimum stay is longer than maximum stay
+func (b *BookingRules) StayError() error {
+	if b.MinStay != nil && b.MaxStay != nil && *b.MinStay > *b.MaxStay {
+		return errors.New("Minimum stay must not be longer than maximum stay")
+	}
+	return nil
+}
+
+// Or returns rules with rules missing in b taken from defaults
+func (b *BookingRules) Or(defaults *BookingRules) *BookingRules {
+	rules := *b
+	if defaults == nil {
+		return &rules
+	}
+	if rules.MinNoticeHours == nil {
+		rules.MinNoticeHours = defaults.MinNoticeHours
+	}
+	if rules.MaxAdvanceDays == nil {
+		rules.MaxAdvanceDays = defaults.MaxAdvanceDays
+	}
+	if rules.MinStay == nil {
+		rules.MinStay = defaults.MinStay
+	}
+	if rules.MaxStay == nil {
+		rules.MaxStay = defaults.MaxStay
+	}
+	if len(rules.ArrivalWeekdays) == 0 {
+		rules.ArrivalWeekdays = defaults.ArrivalWeekdays
+	}
+	return &rules
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// BundleComponent is item reserved (in given quantity) together with
+// bundle item. Title is filled when reading
+type BundleComponent struct {
+	ItemId   int64   `json:"itemId" validate:"required,min=1"`
+	Quantity int64   `json:"quantity" validate:"required,min=1"`
+	Title    *string `json:"title,omitempty"`
+	UnitIds  []int64 `json:"unitIds,omitempty"`
+}
+
+type BundleComponents []BundleComponent
+
+func (b *BundleComponents) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(b)
+}
+
+func (b BundleComponents) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(b)
+}
+
+// IsBundle reports whether item is package of other items. Price of bundle
+// is package price, its availability is given by its components
+func (i *Item) IsBundle() bool {
+	return len(i.Components) != 0
+}
+
+// BundleAvailability combines availability of bundle components (same days,
+// parts[n] belongs to components[n]) into availability of bundle. Bundle can
+// be reserved as many times as its scarcest component allows
+func BundleAvailability(components BundleComponents, parts []ItemAvailabilityList) ItemAvailabilityList {
+	if len(parts) == 0 {
+		return ItemAvailabilityList{}
+	}
+
+	availability := make(ItemAvailabilityList, len(parts[0]))
+	for day := range availability {
+		availability[day].Date = parts[0][day].Date
+		for n, part := range parts {
+			capacity := part[day].Capacity / components[n].Quantity
+			remaining := part[day].Remaining / components[n].Quantity
+			if n == 0 || capacity < availability[day].Capacity {
+				availability[day].Capacity = capacity
+			}
+			if n == 0 || remaining < availability[day].Remaining {
+				availability[day].Remaining = remaining
+			}
+		}
+		availability[day].Booked = availability[day].Capacity - availability[day].Remaining
+		if availability[day].Booked < 0 {
+			availability[day].Booked = 0
+		}
+	}
+	return availability
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Category groups items. Categories form tree through ParentId,
+// top level categories have no parent
+type Category struct {
+	Id       int64  `json:"id,omitempty" create:"omitempty" update:"required,number"`
+	ParentId *int64 `json:"parentId,omitempty" create:"omitempty,min=1" update:"omitempty,min=1"`
+	Title    string `json:"title" create:"required,gt=2,max=255" update:"required,gt=2,max=255"`
+}
+
+func (c *Category) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(c)
+}
+
+func (c *Category) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+type Categories []Category
+
+func (c Categories) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
@@ -7,14 +7,83 @@ import (
 )
 
 type Inquiry struct {
-	Id              int64     `json:"id,omitempty"`
-	Inquirer        string    `json:"inquirer"`
-	Email           string    `json:"email"`
-	Phone           string    `json:"phone"`
-	Item            Item      `json:"item"`
-	DateReservation time.Time `json:"dateReservation"`
-	DateCreated     time.Time `json:"dateCreated"`
-	Comment         string    `json:"comment"`
+	Id              int64           `json:"id,omitempty"`
+	Reference       string          `json:"reference"`
+	Inquirer        string          `json:"inquirer"`
+	Email           string          `json:"email"`
+	Phone           string          `json:"phone"`
+	Item            Item            `json:"item"`
+	DateReservation time.Time       `json:"dateReservation"`
+	DateEnd         time.Time       `json:"dateEnd"`
+	DateCreated     time.Time       `json:"dateCreated"`
+	Comment         string          `json:"comment"`
+	TotalPrice      Money           `json:"totalPrice"`
+	AddonsTotal     Money           `json:"addonsTotal"`
+	Addons          []AddonLineItem `json:"addons,omitempty"`
+	Tax             *TaxBreakdown   `json:"tax,omitempty"`
+	PromoCode       string          `json:"promoCode,omitempty"`
+	Discount        Money           `json:"discount"`
+	Status          string          `json:"status"`
+	StatusChangedAt *time.Time      `json:"statusChangedAt,omitempty"`
+	// reason of rejection or cancellation
+	StatusNote string `json:"statusNote,omitempty"`
+}
+
+// states of inquiry. Inquiry is pending until it is accepted, rejected,
+// expired or cancelled, decided inquiries do not change anymore
+const (
+	InquiryPending   = "pending"
+	InquiryAccepted  = "accepted"
+	InquiryRejected  = "rejected"
+	InquiryExpired   = "expired"
+	InquiryCancelled = "cancelled"
+)
+
+// reasons recorded as status note of expired inquiries
+const (
+	InquiryExpiredPastDate   = "Reservation date has passed"
+	InquiryExpiredUnanswered = "Inquiry was not answered in time"
+)
+
+var inquiryTransitions = map[string][]string{
+	InquiryPending: {InquiryAccepted, InquiryRejected, InquiryExpired, InquiryCancelled},
+}
+
+// CanInquiryTransition reports whether inquiry in state from can move to state to
+func CanInquiryTransition(from string, to string) bool {
+	for _, allowed := range inquiryTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInquiryStatus reports whether status is one of inquiry states
+func IsInquiryStatus(status string) bool {
+	switch status {
+	case InquiryPending, InquiryAccepted, InquiryRejected, InquiryExpired, InquiryCancelled:
+		return true
+	}
+	return false
+}
+
+// InquiryDecision is optional note of staff deciding on inquiry. Note of
+// accepted inquiry becomes notes of reservation, note of rejected one is its reason
+type InquiryDecision struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+func (d *InquiryDecision) FromJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	return dec.Decode(d)
+}
+
+// InquiryTransitionDetails describes inquiry that could not change its status
+type InquiryTransitionDetails struct {
+	InquiryId int64  `json:"inquiryId"`
+	Status    string `json:"status"`
+	Target    string `json:"target"`
 }
 
 type Inquiries []Inquiry
@@ -24,15 +93,45 @@ func (i Inquiries) ToJSON(w io.Writer) error {
 	return e.Encode(i)
 }
 
-// TODO: on create check if date in future
-
+// Reservation is either single night (date), stay from dateFrom (check-in)
+// to dateTo (check-out) or number of slots starting at slot for slot booked items.
+// Reservation must start in the future and follow booking rules of item
 type InquiryCreate struct {
-	Inquirer string     `json:"inquirer" validate:"required,gt=2"`
-	Email    string     `json:"email" validate:"omitempty,required_without=Phone,email"`
-	Phone    string     `json:"phone" validate:"omitempty,required_without=Email,e164"`
-	ItemId   int64      `json:"itemId" validate:"required"`
-	Date     *time.Time `json:"date" validate:"required"`
-	Comment  string     `json:"comment"`
+	Inquirer string           `json:"inquirer" validate:"required,gt=2"`
+	Email    string           `json:"email" validate:"omitempty,required_without=Phone,email"`
+	Phone    string           `json:"phone" validate:"omitempty,required_without=Email,e164"`
+	ItemId   int64            `json:"itemId" validate:"required"`
+	Date     *time.Time       `json:"date" validate:"required_without_all=DateFrom Slot"`
+	DateFrom *time.Time       `json:"dateFrom" validate:"required_without_all=Date Slot"`
+	DateTo   *time.Time       `json:"dateTo" validate:"required_with=DateFrom"`
+	Slot     *time.Time       `json:"slot" validate:"required_without_all=Date DateFrom"`
+	Slots    int              `json:"slots" validate:"omitempty,min=1"`
+	Comment  string           `json:"comment"`
+	Addons   []AddonSelection `json:"addons,omitempty" validate:"omitempty,dive"`
+	// discount of promo code is applied to reservation price
+	PromoCode string `json:"promoCode,omitempty" validate:"omitempty,max=50"`
+}
+
+// IsSlotReservation reports whether inquiry is made for time slots
+func (ic *InquiryCreate) IsSlotReservation() bool {
+	return ic.Slot != nil
+}
+
+// SlotCount returns number of requested slots. Defaults to single slot
+func (ic *InquiryCreate) SlotCount() int {
+	if ic.Slots == 0 {
+		return 1
+	}
+	return ic.Slots
+}
+
+// Stay returns check-in and check-out of inquiry. Single date is treated
+// as one night stay
+func (ic *InquiryCreate) Stay() (time.Time, time.Time) {
+	if ic.DateFrom != nil && ic.DateTo != nil {
+		return *ic.DateFrom, *ic.DateTo
+	}
+	return *ic.Date, ic.Date.AddDate(0, 0, 1)
 }
 
 func (ic *InquiryCreate) FromJSON(r io.Reader) error {
@@ -7,12 +7,27 @@ import (
 )
 
 type Item struct {
-	Id         int64           `json:"id,omitempty" create:"number,omitempty" update:"required,number"`
-	Title      *string         `json:"title,omitempty" create:"required,gt=3" update:"required,gt=3"`
-	ShowFrom   *time.Time      `json:"showFrom,omitempty"`
-	ShowTo     *time.Time      `json:"showTo,omitempty"`
-	Price      int64           `json:"price,omitempty" create:"number,omitempty" update:"number,omitempty"`
-	DatePrices []ItemDatePrice `json:"datePrices,omitempty"`
+	Id          int64            `json:"id,omitempty" create:"number,omitempty" update:"required,number"`
+	Title       *string          `json:"title,omitempty" create:"required,gt=3" update:"required,gt=3"`
+	ShowFrom    *time.Time       `json:"showFrom,omitempty"`
+	ShowTo      *time.Time       `json:"showTo,omitempty"`
+	Price       Money            `json:"price"`
+	Capacity    int64            `json:"capacity,omitempty" create:"omitempty,min=1" update:"omitempty,min=1"`
+	BookingMode string           `json:"bookingMode,omitempty" create:"omitempty,oneof=daily slot" update:"omitempty,oneof=daily slot"`
+	SlotMinutes int              `json:"slotMinutes,omitempty" create:"omitempty,min=5,max=1440" update:"omitempty,min=5,max=1440"`
+	OpensAt     *string          `json:"opensAt,omitempty" create:"omitempty,datetime=15:04" update:"omitempty,datetime=15:04"`
+	ClosesAt    *string          `json:"closesAt,omitempty" create:"omitempty,datetime=15:04" update:"omitempty,datetime=15:04"`
+	CategoryId  *int64           `json:"categoryId,omitempty" create:"omitempty,min=1" update:"omitempty,min=0"`
+	TenantId    *int64           `json:"tenantId,omitempty" create:"omitempty,min=1" update:"omitempty,min=1"`
+	TaxClassId  *int64           `json:"taxClassId,omitempty" create:"omitempty,min=1" update:"omitempty,min=0"`
+	Tags        []string         `json:"tags,omitempty" create:"omitempty,dive,max=50" update:"omitempty,dive,max=50"`
+	DatePrices  []ItemDatePrice  `json:"datePrices,omitempty"`
+	PriceRules  []ItemPriceRule  `json:"priceRules,omitempty"`
+	Media       []ItemMedia      `json:"media,omitempty"`
+	Addons      []ItemAddon      `json:"addons,omitempty"`
+	Components  BundleComponents `json:"components,omitempty"`
+	// set when item was deleted, archived items are kept for their reservations
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
 }
 
 func (i *Item) FromJSON(r io.Reader) error {
@@ -37,5 +52,76 @@ type ItemDatePrice struct {
 	ItemId   int64     `json:"itemId,omitempty" create:"number,omitempty" update:"number,omitempty"`
 	DateFrom time.Time `json:"dateFrom" create:"required,datetime" update:"required,datetime"`
 	DateTo   time.Time `json:"dateTo" create:"required,datetime" update:"required,datetime"`
-	Price    int64     `json:"price" create:"required,number" update:"required,number"`
+	Price    Money     `json:"price"`
+}
+
+// ItemAvailability holds remaining capacity of item for single day
+type ItemAvailability struct {
+	Date      time.Time `json:"date"`
+	Capacity  int64     `json:"capacity"`
+	Booked    int64     `json:"booked"`
+	Remaining int64     `json:"remaining"`
+}
+
+type ItemAvailabilityList []ItemAvailability
+
+func (ia ItemAvailabilityList) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ia)
+}
+
+// reasons of date price conflicts
+const (
+	DatePriceInverted = "inverted"
+	DatePriceOverlap  = "overlap"
+)
+
+// DatePriceConflict describes date price range which is inverted (single index)
+// or pair of ranges that overlap. Indexes point to item DatePrices slice.
+type DatePriceConflict struct {
+	Reason  string          `json:"reason"`
+	Indexes []int           `json:"indexes"`
+	Ranges  []ItemDatePrice `json:"ranges"`
+}
+
+// DatePriceConflicts returns all inverted (DateFrom after DateTo) and overlapping
+// date price ranges of item. Ranges are compared by day and include both ends,
+// the same way price is resolved for reservation date.
+func (i *Item) DatePriceConflicts() []DatePriceConflict {
+	conflicts := []DatePriceConflict{}
+
+	for a := range i.DatePrices {
+		first := i.DatePrices[a]
+		if TruncateDay(first.DateFrom).After(TruncateDay(first.DateTo)) {
+			conflicts = append(conflicts, DatePriceConflict{
+				Reason:  DatePriceInverted,
+				Indexes: []int{a},
+				Ranges:  []ItemDatePrice{first},
+			})
+			continue
+		}
+
+		for b := a + 1; b < len(i.DatePrices); b++ {
+			second := i.DatePrices[b]
+			if TruncateDay(second.DateFrom).After(TruncateDay(second.DateTo)) {
+				continue
+			}
+
+			if !TruncateDay(first.DateFrom).After(TruncateDay(second.DateTo)) && !TruncateDay(second.DateFrom).After(TruncateDay(first.DateTo)) {
+				conflicts = append(conflicts, DatePriceConflict{
+					Reason:  DatePriceOverlap,
+					Indexes: []int{a, b},
+					Ranges:  []ItemDatePrice{first, second},
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// TruncateDay returns start of day (UTC) for given time
+func TruncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
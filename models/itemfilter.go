@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ItemFilter narrows down item catalog. Empty fields are not filtered on
+type ItemFilter struct {
+	// category including all of its subcategories
+	CategoryId *int64
+	// item must have all of the tags
+	Tags []string
+	// base item price range, both inclusive, in minor units of item currency
+	MinPrice *int64
+	MaxPrice *int64
+	// ISO 4217 currency of item price
+	Currency string
+	// part of item title, case insensitive
+	Search string
+	// item is bookable on given day
+	AvailableOn *time.Time
+	// list archived items instead of active ones
+	Archived bool
+}
+
+// IsEmpty reports whether filter has no conditions set
+func (f *ItemFilter) IsEmpty() bool {
+	return f.CategoryId == nil && len(f.Tags) == 0 && f.MinPrice == nil &&
+		f.MaxPrice == nil && f.Currency == "" && f.Search == "" && f.AvailableOn == nil && !f.Archived
+}
+
+// NormalizeTags trims and lowercases tags and drops empty and duplicate ones
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// fields of item tracked by item history
+const (
+	ItemHistoryTitle      = "title"
+	ItemHistoryPrice      = "price"
+	ItemHistoryDatePrices = "datePrices"
+)
+
+// ItemVersion is snapshot of item title and prices saved whenever one of
+// them changes. ChangedBy is id of user that made the change, it is missing
+// for changes made before history was kept or by removed users
+type ItemVersion struct {
+	ItemId        int64           `json:"itemId"`
+	Version       int64           `json:"version"`
+	ChangedAt     time.Time       `json:"changedAt"`
+	ChangedBy     *int64          `json:"changedBy,omitempty"`
+	ChangedByName *string         `json:"changedByName,omitempty"`
+	Title         *string         `json:"title,omitempty"`
+	Price         Money           `json:"price"`
+	DatePrices    []ItemDatePrice `json:"datePrices"`
+	// fields changed since previous version
+	Changes []string `json:"changes,omitempty"`
+}
+
+func (v *ItemVersion) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(v)
+}
+
+// ItemHistory holds versions of item ordered from oldest to newest
+type ItemHistory []ItemVersion
+
+func (h ItemHistory) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(h)
+}
+
+// At returns version of item that was valid at given time
+func (h ItemHistory) At(at time.Time) (*ItemVersion, bool) {
+	for idx := len(h) - 1; idx >= 0; idx-- {
+		if !h[idx].ChangedAt.After(at) {
+			return &h[idx], true
+		}
+	}
+	return nil, false
+}
+
+// SetChanges fills Changes of every version by comparing it with previous one.
+// First version has no changes
+func (h ItemHistory) SetChanges() {
+	for idx := 1; idx < len(h); idx++ {
+		prev, cur := &h[idx-1], &h[idx]
+		cur.Changes = nil
+		if stringValue(prev.Title) != stringValue(cur.Title) {
+			cur.Changes = append(cur.Changes, ItemHistoryTitle)
+		}
+		if prev.Price != cur.Price {
+			cur.Changes = append(cur.Changes, ItemHistoryPrice)
+		}
+		if !sameDatePrices(prev.DatePrices, cur.DatePrices) {
+			cur.Changes = append(cur.Changes, ItemHistoryDatePrices)
+		}
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// compares ranges and their prices, ids are ignored
+func sameDatePrices(a []ItemDatePrice, b []ItemDatePrice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if !a[idx].DateFrom.Equal(b[idx].DateFrom) || !a[idx].DateTo.Equal(b[idx].DateTo) ||
+			a[idx].Price != b[idx].Price {
+			return false
+		}
+	}
+	return true
+}
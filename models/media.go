@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// kinds of item media
+const (
+	MediaKindImage    = "image"
+	MediaKindDocument = "document"
+)
+
+// ItemMedia is image or document attached to item. Files are kept in blob
+// store under Key (and ThumbnailKey for images), only urls are exposed
+type ItemMedia struct {
+	Id           int64     `json:"id"`
+	ItemId       int64     `json:"itemId"`
+	Kind         string    `json:"kind"`
+	FileName     string    `json:"fileName"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl,omitempty"`
+	DateCreated  time.Time `json:"dateCreated"`
+}
+
+func (m *ItemMedia) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(m)
+}
+
+type ItemMediaList []ItemMedia
+
+func (ml ItemMediaList) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ml)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// kinds of reservation messages. Customer messages are written by inquirer,
+// replies by staff for customer to read and notes are internal to staff
+const (
+	MessageCustomer = "customer"
+	MessageReply    = "reply"
+	MessageNote     = "note"
+)
+
+// Message is single entry of conversation on inquiry, carried over to
+// reservation accepted from it. Author is user that wrote staff message,
+// author name is kept when user is removed
+type Message struct {
+	Id          int64     `json:"id,omitempty"`
+	InquiryId   *int64    `json:"inquiryId,omitempty"`
+	AcceptedId  *int64    `json:"acceptedId,omitempty"`
+	Kind        string    `json:"kind" validate:"required,oneof=reply note"`
+	AuthorId    *int64    `json:"authorId,omitempty"`
+	AuthorName  string    `json:"authorName,omitempty"`
+	Body        string    `json:"body" validate:"required,max=5000"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+func (m *Message) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(m)
+}
+
+// Messages are ordered from oldest to newest
+type Messages []Message
+
+func (m Messages) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(m)
+}
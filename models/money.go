@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DefaultCurrency is used when neither item nor its tenant sets currency
+const DefaultCurrency = "EUR"
+
+// returned when amounts in different currencies are combined (e.g. in single reservation)
+var ErrCurrencyMismatch = errors.New("Prices in different currencies can not be combined")
+
+// Money is amount in minor units (e.g. cents) of ISO 4217 currency.
+// Missing currency is resolved from context (item, tenant) when saved
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency,omitempty" validate:"omitempty,len=3,alpha,uppercase" create:"omitempty,len=3,alpha,uppercase" update:"omitempty,len=3,alpha,uppercase"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+// Add sums amounts of same currency. Zero value (no currency) can be
+// added to any amount so totals can start from it
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency == "" && m.Amount == 0 {
+		return other, nil
+	}
+	if other.Currency == "" && other.Amount == 0 {
+		return m, nil
+	}
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return NewMoney(m.Amount+other.Amount, m.Currency), nil
+}
+
+// Times multiplies amount by n
+func (m Money) Times(n int64) Money {
+	return NewMoney(m.Amount*n, m.Currency)
+}
+
+// OrCurrency returns money with currency set if it is missing
+func (m Money) OrCurrency(currency string) Money {
+	if m.Currency == "" {
+		m.Currency = currency
+	}
+	return m
+}
+
+// IsZero reports whether money is not set at all
+func (m Money) IsZero() bool {
+	return m == Money{}
+}
+
+// UnmarshalJSON accepts money object or bare amount (in minor units)
+// so clients sending prices without currency keep working
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var amount int64
+	if err := json.Unmarshal(data, &amount); err == nil {
+		*m = Money{Amount: amount}
+		return nil
+	}
+
+	// alias type without methods, otherwise Unmarshal would recurse
+	type money Money
+	var value money
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*m = Money(value)
+	return nil
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%d %v", m.Amount, m.Currency)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// pricing rule modifier types
+const (
+	PriceModifierPercent  = "percent"
+	PriceModifierAbsolute = "absolute"
+)
+
+// ItemPriceRule adjusts item price (after date range price is resolved).
+// Rule applies when all of its conditions match reservation:
+// weekday of reservation (empty = every day), lead time in days between
+// booking and reservation and optional date window.
+// Percent modifier is percentage change (-10 = 10% discount),
+// absolute modifier is added to price.
+type ItemPriceRule struct {
+	Id           int64      `json:"id,omitempty"`
+	ItemId       int64      `json:"itemId,omitempty"`
+	Title        string     `json:"title" validate:"required,gt=2"`
+	Priority     int        `json:"priority"`
+	Weekdays     []int      `json:"weekdays,omitempty" validate:"omitempty,dive,min=0,max=6"`
+	MinLeadDays  *int       `json:"minLeadDays,omitempty" validate:"omitempty,min=0"`
+	MaxLeadDays  *int       `json:"maxLeadDays,omitempty" validate:"omitempty,min=0"`
+	DateFrom     *time.Time `json:"dateFrom,omitempty"`
+	DateTo       *time.Time `json:"dateTo,omitempty"`
+	ModifierType string     `json:"modifierType" validate:"required,oneof=percent absolute"`
+	Modifier     int64      `json:"modifier" validate:"required"`
+	Disabled     bool       `json:"disabled"`
+}
+
+func (r *ItemPriceRule) FromJSON(reader io.Reader) error {
+	d := json.NewDecoder(reader)
+	return d.Decode(r)
+}
+
+func (r *ItemPriceRule) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(r)
+}
+
+type ItemPriceRules []ItemPriceRule
+
+func (r ItemPriceRules) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(r)
+}
+
+// AppliedPriceRule explains how rule changed quoted price
+type AppliedPriceRule struct {
+	RuleId       int64  `json:"ruleId"`
+	Title        string `json:"title"`
+	ModifierType string `json:"modifierType"`
+	Modifier     int64  `json:"modifier"`
+	Adjustment   Money  `json:"adjustment"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// promo code discount types
+const (
+	PromoDiscountPercent = "percent"
+	PromoDiscountFixed   = "fixed"
+)
+
+// reasons promo code is rejected on inquiry, returned to client as error code
+const (
+	PromoNotFound    = "promo_not_found"
+	PromoInactive    = "promo_inactive"
+	PromoExhausted   = "promo_exhausted"
+	PromoNotEligible = "promo_not_eligible"
+)
+
+// PromoCode discounts reservation price (add-ons are not discounted).
+// Percent discount is percentage of price (1-100), fixed discount is amount
+// in minor units of Currency. Code is valid between ValidFrom and ValidTo
+// (both optional) and can be restricted to items (empty ItemIds is every item).
+// MaxUses limits redemptions in total, MaxUsesPerCustomer by inquirer email (or phone).
+// Only redemptions of accepted inquiries are counted, so pending inquiries can
+// not exhaust code. Uses is number of such redemptions so far
+type PromoCode struct {
+	Id                 int64      `json:"id,omitempty" create:"omitempty" update:"required,number"`
+	Code               string     `json:"code" create:"required,alphanum,min=3,max=50" update:"required,alphanum,min=3,max=50"`
+	DiscountType       string     `json:"discountType" create:"required,oneof=percent fixed" update:"required,oneof=percent fixed"`
+	Discount           int64      `json:"discount" create:"required,min=1" update:"required,min=1"`
+	Currency           string     `json:"currency,omitempty" create:"omitempty,len=3,alpha,uppercase" update:"omitempty,len=3,alpha,uppercase"`
+	ValidFrom          *time.Time `json:"validFrom,omitempty"`
+	ValidTo            *time.Time `json:"validTo,omitempty"`
+	MaxUses            *int       `json:"maxUses,omitempty" create:"omitempty,min=1" update:"omitempty,min=1"`
+	MaxUsesPerCustomer *int       `json:"maxUsesPerCustomer,omitempty" create:"omitempty,min=1" update:"omitempty,min=1"`
+	ItemIds            []int64    `json:"itemIds,omitempty" create:"omitempty,dive,min=1" update:"omitempty,dive,min=1"`
+	Disabled           bool       `json:"disabled"`
+	Uses               int        `json:"uses"`
+}
+
+func (p *PromoCode) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(p)
+}
+
+func (p *PromoCode) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+// Rejection returns reason why code can not be used for reservation of item
+// priced in currency at given time or empty string. Usage limits are not checked
+func (p *PromoCode) Rejection(itemId int64, currency string, at time.Time) string {
+	if p.Disabled || (p.ValidFrom != nil && at.Before(*p.ValidFrom)) || (p.ValidTo != nil && at.After(*p.ValidTo)) {
+		return PromoInactive
+	}
+
+	if p.DiscountType == PromoDiscountFixed && p.Currency != currency {
+		return PromoNotEligible
+	}
+
+	if len(p.ItemIds) == 0 {
+		return ""
+	}
+	for _, id := range p.ItemIds {
+		if id == itemId {
+			return ""
+		}
+	}
+	return PromoNotEligible
+}
+
+type PromoCodes []PromoCode
+
+func (p PromoCodes) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+// PromoRedemption is use of promo code on inquiry
+type PromoRedemption struct {
+	Id          int64     `json:"id"`
+	PromoCodeId int64     `json:"promoCodeId"`
+	InquiryId   int64     `json:"inquiryId"`
+	Inquirer    string    `json:"inquirer"`
+	Discount    Money     `json:"discount"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+type PromoRedemptions []PromoRedemption
+
+func (p PromoRedemptions) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+// PromoErrorDetails names promo code which was rejected
+type PromoErrorDetails struct {
+	PromoCode string `json:"promoCode"`
+}
+
+// NormalizePromoCode trims and uppercases code, codes are matched case insensitive
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// PublicItem is item as shown in public catalog. Visibility window, capacity,
+// base price and pricing data are internal and left out, price is quoted instead
+type PublicItem struct {
+	Id          int64         `json:"id"`
+	Title       string        `json:"title"`
+	BookingMode string        `json:"bookingMode"`
+	SlotMinutes int           `json:"slotMinutes,omitempty"`
+	OpensAt     *string       `json:"opensAt,omitempty"`
+	ClosesAt    *string       `json:"closesAt,omitempty"`
+	CategoryId  *int64        `json:"categoryId,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Media       []PublicMedia `json:"media,omitempty"`
+	Addons      []ItemAddon   `json:"addons,omitempty"`
+	Quote       PublicQuote   `json:"quote"`
+}
+
+func (p *PublicItem) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+type PublicItems []PublicItem
+
+func (p PublicItems) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+type PublicMedia struct {
+	Kind         string `json:"kind"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+}
+
+// PublicQuote is price of item on date. ListPrice is price before price rules
+type PublicQuote struct {
+	Date      time.Time `json:"date"`
+	Price     Money     `json:"price"`
+	ListPrice Money     `json:"listPrice"`
+}
+
+// IsVisible reports whether at is inside item ShowFrom / ShowTo window.
+// Missing bound leaves window open on that side. Archived items are never visible
+func (i *Item) IsVisible(at time.Time) bool {
+	if i.ArchivedAt != nil {
+		return false
+	}
+	if i.ShowFrom != nil && i.ShowFrom.After(at) {
+		return false
+	}
+	if i.ShowTo != nil && i.ShowTo.Before(at) {
+		return false
+	}
+	return true
+}
+
+// NewPublicItem converts item to its public form with quote as current price
+func NewPublicItem(item *Item, quote *PriceQuote) PublicItem {
+	public := PublicItem{
+		Id:          item.Id,
+		BookingMode: item.BookingMode,
+		SlotMinutes: item.SlotMinutes,
+		OpensAt:     item.OpensAt,
+		ClosesAt:    item.ClosesAt,
+		CategoryId:  item.CategoryId,
+		Tags:        item.Tags,
+		Quote: PublicQuote{
+			Date:      quote.Date,
+			Price:     quote.Price,
+			ListPrice: quote.ListPrice,
+		},
+	}
+	if item.Title != nil {
+		public.Title = *item.Title
+	}
+
+	for _, a := range item.Addons {
+		if !a.Disabled {
+			public.Addons = append(public.Addons, a)
+		}
+	}
+
+	for _, m := range item.Media {
+		public.Media = append(public.Media, PublicMedia{
+			Kind:         m.Kind,
+			URL:          m.URL,
+			ThumbnailURL: m.ThumbnailURL,
+		})
+	}
+	return public
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// sources of resolved item price
+const (
+	PriceSourceBase      = "base"
+	PriceSourceDateRange = "dateRange"
+)
+
+// PriceQuote is effective price of item on given date together
+// with explanation where the price comes from
+type PriceQuote struct {
+	ItemId    int64          `json:"itemId"`
+	Date      time.Time      `json:"date"`
+	Price     Money          `json:"price"`
+	BasePrice Money          `json:"basePrice"`
+	ListPrice Money          `json:"listPrice"`
+	Source    string         `json:"source"`
+	DatePrice *ItemDatePrice `json:"datePrice,omitempty"`
+	// rules applied on top of base / date range price in order of evaluation
+	Rules []AppliedPriceRule `json:"rules,omitempty"`
+}
+
+func (p *PriceQuote) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+// StayQuote is price of stay computed night by night
+type StayQuote struct {
+	ItemId   int64        `json:"itemId"`
+	DateFrom time.Time    `json:"dateFrom"`
+	DateTo   time.Time    `json:"dateTo"`
+	Nights   []PriceQuote `json:"nights"`
+	Total    Money        `json:"total"`
+}
+
+func (s *StayQuote) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
+
+// SlotQuote is price of consecutive time slots computed slot by slot
+type SlotQuote struct {
+	ItemId int64        `json:"itemId"`
+	Start  time.Time    `json:"start"`
+	End    time.Time    `json:"end"`
+	Slots  []PriceQuote `json:"slots"`
+	Total  Money        `json:"total"`
+}
+
+func (s *SlotQuote) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ReservationReference holds inquiry and accepted reservation with reference
+// code. Reservations accepted without inquiry have no inquiry and inquiries
+// that were not accepted have no reservation
+type ReservationReference struct {
+	Reference string    `json:"reference"`
+	Inquiry   *Inquiry  `json:"inquiry,omitempty"`
+	Accepted  *Accepted `json:"accepted,omitempty"`
+}
+
+func (r *ReservationReference) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(r)
+}
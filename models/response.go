@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"io"
+	"time"
 )
 
 func NewIdResponse(id int64) *IdResponse {
@@ -17,3 +18,46 @@ func (i *IdResponse) ToJSON(w io.Writer) error {
 	e := json.NewEncoder(w)
 	return e.Encode(i)
 }
+
+func NewReferenceResponse(id int64, reference string) *ReferenceResponse {
+	return &ReferenceResponse{Id: id, Reference: reference}
+}
+
+// ReferenceResponse is returned when reservation with reference code is created
+type ReferenceResponse struct {
+	Id        int64  `json:"id"`
+	Reference string `json:"reference"`
+}
+
+func (r *ReferenceResponse) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(r)
+}
+
+// ErrorResponse is structured error body used when client needs
+// more than plain text to react on (e.g. conflicts on booked dates)
+type ErrorResponse struct {
+	Error   string      `json:"error"`
+	Code    string      `json:"code,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+func NewErrorResponse(msg string, code string, details interface{}) *ErrorResponse {
+	return &ErrorResponse{
+		Error:   msg,
+		Code:    code,
+		Details: details,
+	}
+}
+
+func (e *ErrorResponse) ToJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(e)
+}
+
+// BookingErrorDetails describes which item and dates (or slots) caused booking to fail
+type BookingErrorDetails struct {
+	ItemId int64       `json:"itemId,omitempty"`
+	Dates  []string    `json:"dates,omitempty"`
+	Slots  []time.Time `json:"slots,omitempty"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrScheduleHours = errors.New("closesAt must be after opensAt")
+
+// ItemSchedule holds opening hours of item on single weekday (0 is Sunday).
+// Entry without hours keeps item open the whole day. Weekday can have
+// more entries (e.g. morning and afternoon)
+type ItemSchedule struct {
+	Id       int64   `json:"id,omitempty"`
+	ItemId   int64   `json:"itemId,omitempty"`
+	Weekday  int     `json:"weekday" validate:"min=0,max=6"`
+	OpensAt  *string `json:"opensAt,omitempty" validate:"required_with=ClosesAt,omitempty,datetime=15:04"`
+	ClosesAt *string `json:"closesAt,omitempty" validate:"required_with=OpensAt,omitempty,datetime=15:04"`
+}
+
+// Hours returns opening and closing time as time elapsed since midnight
+func (s *ItemSchedule) Hours() (time.Duration, time.Duration, error) {
+	if s.OpensAt == nil || s.ClosesAt == nil {
+		return 0, 24 * time.Hour, nil
+	}
+
+	opens, err := parseClock(*s.OpensAt)
+	if err != nil {
+		return 0, 0, err
+	}
+	closes, err := parseClock(*s.ClosesAt)
+	if err != nil {
+		return 0, 0, err
+	}
+	if closes <= opens {
+		return 0, 0, ErrScheduleHours
+	}
+
+	return opens, closes, nil
+}
+
+// ItemScheduleList is weekly schedule of item. Empty schedule means
+// item can be booked any day
+type ItemScheduleList []ItemSchedule
+
+func (sl *ItemScheduleList) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(sl)
+}
+
+func (sl ItemScheduleList) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(sl)
+}
+
+// OpenOn reports whether item is open (for any part of day) on weekday of given day
+func (sl ItemScheduleList) OpenOn(day time.Time) bool {
+	if len(sl) == 0 {
+		return true
+	}
+
+	for _, s := range sl {
+		if time.Weekday(s.Weekday) == day.UTC().Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether whole period from start to end (same day) is
+// inside opening hours of single schedule entry
+func (sl ItemScheduleList) Covers(start time.Time, end time.Time) bool {
+	if len(sl) == 0 {
+		return true
+	}
+
+	day := TruncateDay(start)
+	for _, s := range sl {
+		if time.Weekday(s.Weekday) != day.Weekday() {
+			continue
+		}
+
+		opens, closes, err := s.Hours()
+		if err != nil {
+			continue
+		}
+		if !start.UTC().Before(day.Add(opens)) && !end.UTC().After(day.Add(closes)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemBlackout is period (dateFrom inclusive, dateTo exclusive) in which
+// item can not be booked, e.g. maintenance or holidays
+type ItemBlackout struct {
+	Id       int64     `json:"id,omitempty"`
+	ItemId   int64     `json:"itemId,omitempty"`
+	DateFrom time.Time `json:"dateFrom" validate:"required"`
+	DateTo   time.Time `json:"dateTo" validate:"required,gtfield=DateFrom"`
+	Reason   string    `json:"reason" validate:"max=255"`
+}
+
+func (b *ItemBlackout) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(b)
+}
+
+// Overlaps reports whether blackout overlaps period from start to end
+func (b *ItemBlackout) Overlaps(start time.Time, end time.Time) bool {
+	return b.DateFrom.Before(end) && b.DateTo.After(start)
+}
+
+type ItemBlackouts []ItemBlackout
+
+func (bl ItemBlackouts) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(bl)
+}
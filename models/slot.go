@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// booking modes of item. Daily items are reserved by nights,
+// slot items by time slots inside opening hours
+const (
+	BookingModeDaily = "daily"
+	BookingModeSlot  = "slot"
+)
+
+// layout of item opening and closing time
+const SlotTimeLayout = "15:04"
+
+var ErrSlotConfig = errors.New("slotMinutes, opensAt and closesAt are required for slot booking mode")
+var ErrSlotHours = errors.New("closesAt must be after opensAt and fit at least one slot")
+var ErrSlotNotAligned = errors.New("Slot must start on slot boundary inside opening hours")
+var ErrSlotCount = errors.New("At least one slot must be reserved")
+
+// ItemSlot holds remaining capacity and price of single item time slot
+type ItemSlot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  int64     `json:"capacity"`
+	Booked    int64     `json:"booked"`
+	Remaining int64     `json:"remaining"`
+	Price     Money     `json:"price"`
+}
+
+type ItemSlots []ItemSlot
+
+func (is ItemSlots) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(is)
+}
+
+// IsSlotBooked reports whether item is reserved by time slots instead of nights
+func (i *Item) IsSlotBooked() bool {
+	return i.BookingMode == BookingModeSlot
+}
+
+// SlotLength returns duration of single item slot
+func (i *Item) SlotLength() time.Duration {
+	return time.Duration(i.SlotMinutes) * time.Minute
+}
+
+// SlotConfigError checks that slot item has slot length and opening
+// hours that fit at least one slot. Daily items are always valid.
+func (i *Item) SlotConfigError() error {
+	if !i.IsSlotBooked() {
+		return nil
+	}
+
+	if i.SlotMinutes == 0 || i.OpensAt == nil || i.ClosesAt == nil {
+		return ErrSlotConfig
+	}
+
+	opens, closes, err := i.OpeningHours(time.Time{})
+	if err != nil {
+		return err
+	}
+	if opens.Add(i.SlotLength()).After(closes) {
+		return ErrSlotHours
+	}
+
+	return nil
+}
+
+// OpeningHours returns opening and closing time of item on given day (UTC)
+func (i *Item) OpeningHours(day time.Time) (time.Time, time.Time, error) {
+	if i.OpensAt == nil || i.ClosesAt == nil {
+		return time.Time{}, time.Time{}, ErrSlotConfig
+	}
+
+	opens, err := parseClock(*i.OpensAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrSlotConfig
+	}
+	closes, err := parseClock(*i.ClosesAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrSlotConfig
+	}
+
+	d := TruncateDay(day)
+	return d.Add(opens), d.Add(closes), nil
+}
+
+// parses time of day (15:04) to time elapsed since midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse(SlotTimeLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// SlotStarts returns start times of all whole slots between opening
+// and closing time of item on given day
+func (i *Item) SlotStarts(day time.Time) ([]time.Time, error) {
+	if !i.IsSlotBooked() {
+		return nil, ErrSlotConfig
+	}
+	if err := i.SlotConfigError(); err != nil {
+		return nil, err
+	}
+
+	opens, closes, err := i.OpeningHours(day)
+	if err != nil {
+		return nil, err
+	}
+
+	starts := []time.Time{}
+	for start := opens; !start.Add(i.SlotLength()).After(closes); start = start.Add(i.SlotLength()) {
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// SlotEnd validates that count consecutive slots starting at start are
+// inside opening hours and returns end of last slot
+func (i *Item) SlotEnd(start time.Time, count int) (time.Time, error) {
+	if count < 1 {
+		return time.Time{}, ErrSlotCount
+	}
+	if !i.IsSlotBooked() {
+		return time.Time{}, ErrSlotConfig
+	}
+	if err := i.SlotConfigError(); err != nil {
+		return time.Time{}, err
+	}
+
+	opens, closes, err := i.OpeningHours(start)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	start = start.UTC()
+	end := start.Add(time.Duration(count) * i.SlotLength())
+	if start.Before(opens) || end.After(closes) || start.Sub(opens)%i.SlotLength() != 0 {
+		return time.Time{}, ErrSlotNotAligned
+	}
+
+	return end, nil
+}
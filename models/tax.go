@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// TaxClass groups items and add-ons taxed by same rate (e.g. accommodation,
+// food). Rates of class are set by each tenant
+type TaxClass struct {
+	Id    int64  `json:"id,omitempty" create:"omitempty" update:"required,number"`
+	Title string `json:"title" create:"required,gt=2,max=255" update:"required,gt=2,max=255"`
+}
+
+func (t *TaxClass) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(t)
+}
+
+func (t *TaxClass) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(t)
+}
+
+type TaxClasses []TaxClass
+
+func (t TaxClasses) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(t)
+}
+
+// TaxRate is rate of tax class used by tenant between ValidFrom and ValidTo
+// (both inclusive, missing ValidTo means until changed). Rate is in basis
+// points, 2200 is 22%
+type TaxRate struct {
+	Id         int64      `json:"id,omitempty"`
+	TenantId   int64      `json:"tenantId,omitempty"`
+	TaxClassId int64      `json:"taxClassId" validate:"required,min=1"`
+	Rate       int64      `json:"rate" validate:"min=0,max=10000"`
+	ValidFrom  time.Time  `json:"validFrom" validate:"required"`
+	ValidTo    *time.Time `json:"validTo,omitempty" validate:"omitempty,gtefield=ValidFrom"`
+}
+
+func (t *TaxRate) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(t)
+}
+
+func (t *TaxRate) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(t)
+}
+
+type TaxRates []TaxRate
+
+func (t TaxRates) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(t)
+}
+
+// RateAt returns rate of tax class valid on day of date
+func (t TaxRates) RateAt(taxClassId int64, date time.Time) (*TaxRate, bool) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	for idx := range t {
+		rate := &t[idx]
+		if rate.TaxClassId != taxClassId || day.Before(rate.ValidFrom) {
+			continue
+		}
+		if rate.ValidTo == nil || !day.After(*rate.ValidTo) {
+			return rate, true
+		}
+	}
+	return nil, false
+}
+
+// TaxLine is part of reservation gross amount taxed by single tax class
+// and rate. Amounts without tax class have zero rate
+type TaxLine struct {
+	TaxClassId *int64 `json:"taxClassId,omitempty"`
+	Rate       int64  `json:"rate"`
+	Net        Money  `json:"net"`
+	Tax        Money  `json:"tax"`
+	Gross      Money  `json:"gross"`
+}
+
+// TaxBreakdown splits gross amount of reservation (prices include tax)
+// into net and tax amounts by tax class
+type TaxBreakdown struct {
+	Net   Money     `json:"net"`
+	Tax   Money     `json:"tax"`
+	Gross Money     `json:"gross"`
+	Lines []TaxLine `json:"lines"`
+}
+
+// NewTaxBreakdown sums lines into breakdown. Lines must be in same currency
+func NewTaxBreakdown(lines []TaxLine) (*TaxBreakdown, error) {
+	breakdown := &TaxBreakdown{Lines: lines}
+	for _, line := range lines {
+		var err error
+		if breakdown.Net, err = breakdown.Net.Add(line.Net); err != nil {
+			return nil, err
+		}
+		if breakdown.Tax, err = breakdown.Tax.Add(line.Tax); err != nil {
+			return nil, err
+		}
+		if breakdown.Gross, err = breakdown.Gross.Add(line.Gross); err != nil {
+			return nil, err
+		}
+	}
+	return breakdown, nil
+}
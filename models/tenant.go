@@ -9,7 +9,9 @@ type Tenant struct {
 	Id    int64  `json:"id" create:"omitempty" update:"required,number"`
 	Title string `json:"title" create:"required,gt=3" update:"required,gt=3"`
 	Email string `json:"email" create:"required,email" update:"required,email"`
-	Users Users  `json:"users,omitempty"`
+	// default currency of tenant items
+	Currency string `json:"currency,omitempty" create:"omitempty,len=3,alpha,uppercase" update:"omitempty,len=3,alpha,uppercase"`
+	Users    Users  `json:"users,omitempty"`
 }
 
 func (t *Tenant) FromJSON(r io.Reader) error {
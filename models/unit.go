@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// item unit statuses
+const (
+	UnitActive       = "active"
+	UnitOutOfService = "out_of_service"
+)
+
+// ItemUnit is single physical unit of item (e.g. one of kayaks). Items with
+// units can be reserved as many times as they have active units, every
+// accepted reservation gets one of them assigned
+type ItemUnit struct {
+	Id     int64  `json:"id,omitempty"`
+	ItemId int64  `json:"itemId,omitempty"`
+	Label  string `json:"label" validate:"required,max=100"`
+	Status string `json:"status,omitempty" validate:"omitempty,oneof=active out_of_service"`
+}
+
+func (u *ItemUnit) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(u)
+}
+
+func (u *ItemUnit) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(u)
+}
+
+type ItemUnits []ItemUnit
+
+func (u ItemUnits) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(u)
+}
+
+// UnitAssignment is manual change of unit assigned to accepted reservation
+type UnitAssignment struct {
+	UnitId int64 `json:"unitId" validate:"required,min=1"`
+}
+
+func (u *UnitAssignment) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(u)
+}
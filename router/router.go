@@ -3,6 +3,7 @@ package router
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/alesbrelih/go-reservation-api/config"
 	"github.com/alesbrelih/go-reservation-api/controller"
@@ -14,7 +15,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 )
 
-func InitializeRouter(db db.DbFactory, config config.Enviroment) *mux.Router {
+func InitializeRouter(db db.DbFactory, config config.Enviroment, notifier services.Notifier) *mux.Router {
 	r := mux.NewRouter()
 
 	controllerLogger := hclog.New(&hclog.LoggerOptions{
@@ -33,13 +34,58 @@ func InitializeRouter(db db.DbFactory, config config.Enviroment) *mux.Router {
 
 	jwt := middleware.NewJwt(authService, hclog.Default())
 
+	pricingService := services.NewPricingService()
+
+	// item media is mounted before item so it is not handled by item router
+	blobStore := services.NewLocalBlobStore(config.Media.Dir, config.Media.BaseURL)
+	mediaService := services.NewMediaService(blobStore, config.Media.MaxSize)
+	mediaStore := stores.NewMediaStore(db)
+	mediaHandler := controller.NewMediaHandler(mediaStore, mediaService, controllerLogger.Named("media"))
+	mediaRouter := mediaHandler.NewRouter()
+	mediaRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/item/{id:[\\d]+}/media").Handler(mediaRouter)
+	r.PathPrefix("/media/").Handler(controller.NewMediaFileHandler("/media/", config.Media.Dir))
+
+	// booking rules of items and tenant defaults, mounted before item and tenant
+	bookingRuleStore := stores.NewBookingRuleStore(db)
+	bookingRuleHandler := controller.NewBookingRuleHandler(bookingRuleStore, controllerLogger.Named("booking-rule"))
+	itemRuleRouter := bookingRuleHandler.NewItemRouter()
+	itemRuleRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/item/{id:[\\d]+}/booking-rules").Handler(itemRuleRouter)
+	tenantRuleRouter := bookingRuleHandler.NewTenantRouter()
+	tenantRuleRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/tenant/{id:[\\d]+}/booking-rules").Handler(tenantRuleRouter)
+
 	itemStore := stores.NewItemStoreSql(db)
 	itemLogger := log.New(os.Stdout, "item-controller ", log.LstdFlags)
-	itemHandler := controller.NewItemHandler(itemStore, itemLogger)
+	itemHandler := controller.NewItemHandler(itemStore, pricingService, itemLogger)
 	itemRouter := itemHandler.NewItemRouter()
 	itemRouter.Use(jwt.ValidateUser)
 	r.PathPrefix("/item").Handler(itemRouter)
 
+	// public catalog, no authentication
+	publicHandler := controller.NewPublicHandler(itemStore, pricingService, controllerLogger.Named("public"))
+	r.PathPrefix("/public").Handler(publicHandler.NewRouter())
+
+	// availability search across items, no authentication
+	availabilityHandler := controller.NewAvailabilityHandler(itemStore, pricingService, controllerLogger.Named("availability"))
+	r.PathPrefix("/availability").Handler(availabilityHandler.NewRouter())
+
+	// category
+	categoryStore := stores.NewCategoryStore(db)
+	categoryLogger := controllerLogger.Named("category")
+	categoryHandler := controller.NewCategoryHandler(categoryStore, categoryLogger)
+	categoryRouter := categoryHandler.NewRouter()
+	categoryRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/category").Handler(categoryRouter)
+
+	// promo codes
+	promoStore := stores.NewPromoStore(db)
+	promoHandler := controller.NewPromoHandler(promoStore, controllerLogger.Named("promo"))
+	promoRouter := promoHandler.NewRouter()
+	promoRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/promo-code").Handler(promoRouter)
+
 	// user handler
 	userStore := stores.NewUserStore(db)
 	userLogger := log.New(os.Stdout, "user-controller ", log.LstdFlags)
@@ -48,6 +94,17 @@ func InitializeRouter(db db.DbFactory, config config.Enviroment) *mux.Router {
 	userRouter.Use(jwt.ValidateUser)
 	r.PathPrefix("/user").Handler(userRouter)
 
+	// tax classes and tenant tax rates, rates are mounted before tenant
+	// so they are not handled by tenant router
+	taxStore := stores.NewTaxStore(db)
+	taxHandler := controller.NewTaxHandler(taxStore, controllerLogger.Named("tax"))
+	taxClassRouter := taxHandler.NewClassRouter()
+	taxClassRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/tax-class").Handler(taxClassRouter)
+	taxRateRouter := taxHandler.NewRateRouter()
+	taxRateRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/tenant/{id:[\\d]+}/tax-rate").Handler(taxRateRouter)
+
 	// tenant handler
 	tenantStore := stores.NewTenantStore(db)
 	tenantLogger := log.New(os.Stdout, "tenant-controller ", log.LstdFlags)
@@ -62,18 +119,53 @@ func InitializeRouter(db db.DbFactory, config config.Enviroment) *mux.Router {
 	authHandler := controller.NewAuthHandler(authStore, authService, authLogger)
 	r.PathPrefix("/auth").Handler(authHandler.NewRouter())
 
+	// inquiry and accepted message threads, mounted before inquiry and accepted
+	messageStore := stores.NewMessageStore(db)
+	messageHandler := controller.NewMessageHandler(messageStore, controllerLogger.Named("message"))
+	inquiryMessageRouter := messageHandler.NewInquiryRouter()
+	inquiryMessageRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/inquiry/{id:[\\d]+}/messages").Handler(inquiryMessageRouter)
+	acceptedMessageRouter := messageHandler.NewAcceptedRouter()
+	acceptedMessageRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/accepted/{id:[\\d]+}/messages").Handler(acceptedMessageRouter)
+
+	// customer self-service links, staff manages links of inquiry and accepted
+	// (mounted before them), customers use them without authentication
+	accessStore := stores.NewAccessStore(db)
+	accessLinks := services.NewAccessLinks(config.SelfService.Secret, config.SelfService.URL)
+	accessLogger := controllerLogger.Named("access")
+	accessNotifier := services.NewBackgroundNotifier(notifier, time.Minute, accessLogger)
+	accessIssuer := controller.NewAccessIssuer(accessStore, accessLinks, accessNotifier, config.SelfService.Expiration,
+		accessLogger)
+	selfServiceHandler := controller.NewSelfServiceHandler(accessStore, accessIssuer, accessLinks,
+		controllerLogger.Named("self-service"))
+	inquiryAccessRouter := selfServiceHandler.NewInquiryRouter()
+	inquiryAccessRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/inquiry/{id:[\\d]+}/access").Handler(inquiryAccessRouter)
+	acceptedAccessRouter := selfServiceHandler.NewAcceptedRouter()
+	acceptedAccessRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/accepted/{id:[\\d]+}/access").Handler(acceptedAccessRouter)
+	r.PathPrefix("/self-service").Handler(selfServiceHandler.NewPublicRouter())
+
 	// inquiry
-	inquiryStore := stores.NewInquiryStore(db)
+	inquiryStore := stores.NewInquiryStore(db, pricingService)
 	inquiryLogger := controllerLogger.Named("inquiry")
-	inquiryHandler := controller.NewInquiryHandler(inquiryStore, jwt, inquiryLogger)
+	inquiryHandler := controller.NewInquiryHandler(inquiryStore, accessIssuer, jwt, inquiryLogger)
 	r.PathPrefix("/inquiry").Handler(inquiryHandler.NewRouter())
 
 	// accepted
-	acceptStore := stores.NewAcceptedStoreSql(db)
+	acceptStore := stores.NewAcceptedStoreSql(db, pricingService)
 	acceptedLogger := controllerLogger.Named("accepted")
 	acceptedHandler := controller.NewAcceptedHandler(acceptStore, acceptedLogger)
 	acceptedRouter := acceptedHandler.NewRouter()
 	acceptedRouter.Use(jwt.ValidateUser)
 	r.PathPrefix("/accepted").Handler(acceptedRouter)
+
+	// inquiries and accepted reservations by reference code
+	reservationStore := stores.NewReservationStore(db)
+	reservationHandler := controller.NewReservationHandler(reservationStore, controllerLogger.Named("reservation"))
+	reservationRouter := reservationHandler.NewRouter()
+	reservationRouter.Use(jwt.ValidateUser)
+	r.PathPrefix("/reservations").Handler(reservationRouter)
 	return r
 }
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var InvalidAccessTokenError = errors.New("Invalid access token")
+
+// NewAccessLinks signs self-service tokens with key derived from secret, so
+// the same secret as for jwt can be used without tokens being interchangeable.
+// Links are made by appending token to baseURL, without it only tokens are issued
+func NewAccessLinks(secret string, baseURL string) AccessLinks {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("reservation-access"))
+	return &accessLinks{
+		key:     mac.Sum(nil),
+		baseURL: baseURL,
+	}
+}
+
+// AccessLinks signs and verifies tokens of customer self-service access.
+// Token carries access id and its expiry, revocation is checked by caller
+type AccessLinks interface {
+	Sign(accessId int64, expiresAt time.Time) string
+	Verify(token string, now time.Time) (int64, error)
+	Link(token string) string
+}
+
+type accessLinks struct {
+	key     []byte
+	baseURL string
+}
+
+// Sign returns token in form payload.signature, both base64 url encoded
+func (a *accessLinks) Sign(accessId int64, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", accessId, expiresAt.Unix())))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(a.signature(payload))
+}
+
+// Verify returns access id of token signed by Sign which did not expire before now
+func (a *accessLinks) Verify(token string, now time.Time) (int64, error) {
+	split := strings.Split(token, ".")
+	if len(split) != 2 {
+		return 0, errors.Wrap(InvalidAccessTokenError, "Malformed token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(split[1])
+	if err != nil || !hmac.Equal(signature, a.signature(split[0])) {
+		return 0, errors.Wrap(InvalidAccessTokenError, "Signature mismatch")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(split[0])
+	if err != nil {
+		return 0, errors.Wrap(InvalidAccessTokenError, "Malformed payload")
+	}
+
+	var accessId, expiresAt int64
+	if _, err := fmt.Sscanf(string(payload), "%d.%d", &accessId, &expiresAt); err != nil {
+		return 0, errors.Wrap(InvalidAccessTokenError, "Malformed payload")
+	}
+	if now.Unix() >= expiresAt {
+		return 0, errors.Wrap(InvalidAccessTokenError, "Token expired")
+	}
+
+	return accessId, nil
+}
+
+func (a *accessLinks) Link(token string) string {
+	if a.baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(a.baseURL, "/") + "/" + token
+}
+
+func (a *accessLinks) signature(payload string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/pkg/errors"
+)
+
+func TestAccessLinks_SignVerify(t *testing.T) {
+	links := services.NewAccessLinks("secret", "https://example.com/reservation/")
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	token := links.Sign(42, now.Add(time.Hour))
+	id, err := links.Verify(token, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 {
+		t.Errorf("Expected access 42 but got %v", id)
+	}
+
+	if link := links.Link(token); link != "https://example.com/reservation/"+token {
+		t.Errorf("Expected token appended to base url but got %v", link)
+	}
+}
+
+func TestAccessLinks_Verify_Expired(t *testing.T) {
+	links := services.NewAccessLinks("secret", "")
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	token := links.Sign(42, now)
+	if _, err := links.Verify(token, now); errors.Cause(err) != services.InvalidAccessTokenError {
+		t.Errorf("Expected expired token to be invalid but got %v", err)
+	}
+	if link := links.Link(token); link != "" {
+		t.Errorf("Expected no link without base url but got %v", link)
+	}
+}
+
+func TestAccessLinks_Verify_Forged(t *testing.T) {
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	token := services.NewAccessLinks("other secret", "").Sign(42, now.Add(time.Hour))
+
+	links := services.NewAccessLinks("secret", "")
+	for _, forged := range []string{token, "42", "", token + "x"} {
+		if _, err := links.Verify(forged, now); errors.Cause(err) != services.InvalidAccessTokenError {
+			t.Errorf("Expected token %q to be invalid but got %v", forged, err)
+		}
+	}
+}
+
+func TestAccessLinks_NotJwt(t *testing.T) {
+	// link tokens signed with jwt secret must not authorize staff
+	token := services.NewAccessLinks("secret", "").Sign(1, time.Now().Add(time.Hour))
+	auth := services.NewAuthService("secret", time.Minute, time.Hour)
+
+	if _, err := auth.GetClaims(token); err == nil {
+		t.Error("Expected access token to be rejected as jwt")
+	}
+}
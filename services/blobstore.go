@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var InvalidBlobKeyError = errors.New("Invalid blob key")
+
+// BlobStore stores binary files (media uploads) under keys
+// and knows public url of every stored file
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewLocalBlobStore stores files inside dir. Files are expected to be
+// served on baseURL (e.g. /media)
+func NewLocalBlobStore(dir string, baseURL string) BlobStore {
+	return &localBlobStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type localBlobStore struct {
+	dir     string
+	baseURL string
+}
+
+func (l *localBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return errors.Wrap(err, "Error creating blob directory")
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrap(err, "Error creating blob file")
+	}
+
+	if _, err := io.Copy(f, bytes.NewReader(data)); err != nil {
+		f.Close()
+		return errors.Wrap(err, "Error writing blob file")
+	}
+	return f.Close()
+}
+
+func (l *localBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (l *localBlobStore) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "Error deleting blob file")
+	}
+	return nil
+}
+
+func (l *localBlobStore) URL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+// resolves key to path inside store directory. Keys escaping
+// the directory are rejected
+func (l *localBlobStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", InvalidBlobKeyError
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+)
+
+// booking rules reservation can break
+const (
+	BookingRulePast       = "past_date"
+	BookingRuleMinNotice  = "min_notice"
+	BookingRuleMaxAdvance = "max_advance"
+	BookingRuleMinStay    = "min_stay"
+	BookingRuleMaxStay    = "max_stay"
+	BookingRuleArrivalDay = "arrival_weekday"
+)
+
+// BookingRuleError describes booking rule broken by reservation
+type BookingRuleError struct {
+	Rule    string
+	Message string
+}
+
+func (b *BookingRuleError) Error() string {
+	return b.Message
+}
+
+// CheckBookingRules validates that reservation of item starting at start and
+// lasting units nights (slots for slot booked items) made at now is in the
+// future and follows rules. Daily reservations start at midnight (UTC) of
+// check-in, so they can be made for today unless notice is required
+func CheckBookingRules(item *models.Item, rules *models.BookingRules, start time.Time, units int, now time.Time) error {
+	start = start.UTC()
+	now = now.UTC()
+	unit := "slot"
+	if !item.IsSlotBooked() {
+		start = start.Truncate(24 * time.Hour)
+		unit = "night"
+	}
+
+	if start.Before(now) && (item.IsSlotBooked() || start.Before(now.Truncate(24*time.Hour))) {
+		return &BookingRuleError{Rule: BookingRulePast, Message: "Reservation must start in the future"}
+	}
+
+	if rules == nil {
+		return nil
+	}
+
+	if rules.MinNoticeHours != nil && start.Before(now.Add(time.Duration(*rules.MinNoticeHours)*time.Hour)) {
+		return &BookingRuleError{
+			Rule:    BookingRuleMinNotice,
+			Message: fmt.Sprintf("Reservation must be made at least %d hours in advance", *rules.MinNoticeHours),
+		}
+	}
+
+	if rules.MaxAdvanceDays != nil && start.After(now.AddDate(0, 0, int(*rules.MaxAdvanceDays))) {
+		return &BookingRuleError{
+			Rule:    BookingRuleMaxAdvance,
+			Message: fmt.Sprintf("Reservation can be made at most %d days in advance", *rules.MaxAdvanceDays),
+		}
+	}
+
+	if rules.MinStay != nil && int64(units) < *rules.MinStay {
+		return &BookingRuleError{
+			Rule:    BookingRuleMinStay,
+			Message: fmt.Sprintf("Reservation must be at least %d %s long", *rules.MinStay, plural(unit, *rules.MinStay)),
+		}
+	}
+
+	if rules.MaxStay != nil && int64(units) > *rules.MaxStay {
+		return &BookingRuleError{
+			Rule:    BookingRuleMaxStay,
+			Message: fmt.Sprintf("Reservation can be at most %d %s long", *rules.MaxStay, plural(unit, *rules.MaxStay)),
+		}
+	}
+
+	if len(rules.ArrivalWeekdays) != 0 {
+		days := make([]string, 0, len(rules.ArrivalWeekdays))
+		allowed := false
+		for _, day := range rules.ArrivalWeekdays {
+			days = append(days, time.Weekday(day).String())
+			allowed = allowed || time.Weekday(day) == start.Weekday()
+		}
+		if !allowed {
+			return &BookingRuleError{
+				Rule:    BookingRuleArrivalDay,
+				Message: "Reservation can only start on " + strings.Join(days, ", "),
+			}
+		}
+	}
+
+	return nil
+}
+
+func plural(unit string, count int64) string {
+	if count == 1 {
+		return unit
+	}
+	return unit + "s"
+}
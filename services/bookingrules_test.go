@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+)
+
+// wednesday
+var rulesNow = time.Date(2021, 8, 4, 10, 0, 0, 0, time.UTC)
+
+func ruleOf(err error) string {
+	if re, ok := err.(*services.BookingRuleError); ok {
+		return re.Rule
+	}
+	return ""
+}
+
+func TestCheckBookingRules_PastDate(t *testing.T) {
+	item := &models.Item{Id: 1, BookingMode: models.BookingModeDaily}
+
+	err := services.CheckBookingRules(item, nil, rulesNow.AddDate(0, 0, -1), 1, rulesNow)
+	if ruleOf(err) != services.BookingRulePast {
+		t.Errorf("Expected %v but got %v", services.BookingRulePast, err)
+	}
+
+	// daily reservation for today is in the future
+	today := time.Date(2021, 8, 4, 0, 0, 0, 0, time.UTC)
+	if err := services.CheckBookingRules(item, nil, today, 1, rulesNow); err != nil {
+		t.Errorf("Expected reservation for today to pass but got %v", err)
+	}
+
+	// slot that already started is not
+	slotItem := &models.Item{Id: 2, BookingMode: models.BookingModeSlot, SlotMinutes: 60}
+	err = services.CheckBookingRules(slotItem, nil, rulesNow.Add(-time.Hour), 1, rulesNow)
+	if ruleOf(err) != services.BookingRulePast {
+		t.Errorf("Expected %v but got %v", services.BookingRulePast, err)
+	}
+}
+
+func TestCheckBookingRules_NoticeAndAdvance(t *testing.T) {
+	item := &models.Item{Id: 1, BookingMode: models.BookingModeDaily}
+	rules := &models.BookingRules{MinNoticeHours: int64Ptr(48), MaxAdvanceDays: int64Ptr(30)}
+
+	err := services.CheckBookingRules(item, rules, rulesNow.AddDate(0, 0, 1), 1, rulesNow)
+	if ruleOf(err) != services.BookingRuleMinNotice {
+		t.Errorf("Expected %v but got %v", services.BookingRuleMinNotice, err)
+	}
+	if err != nil && err.Error() != "Reservation must be made at least 48 hours in advance" {
+		t.Errorf("Unexpected message %v", err)
+	}
+
+	err = services.CheckBookingRules(item, rules, rulesNow.AddDate(0, 0, 31), 1, rulesNow)
+	if ruleOf(err) != services.BookingRuleMaxAdvance {
+		t.Errorf("Expected %v but got %v", services.BookingRuleMaxAdvance, err)
+	}
+
+	if err := services.CheckBookingRules(item, rules, rulesNow.AddDate(0, 0, 10), 1, rulesNow); err != nil {
+		t.Errorf("Expected reservation to pass but got %v", err)
+	}
+}
+
+func TestCheckBookingRules_StayAndArrivalDay(t *testing.T) {
+	item := &models.Item{Id: 1, BookingMode: models.BookingModeDaily}
+	rules := &models.BookingRules{MinStay: int64Ptr(2), MaxStay: int64Ptr(7), ArrivalWeekdays: []int{6}}
+	saturday := time.Date(2021, 8, 7, 0, 0, 0, 0, time.UTC)
+
+	err := services.CheckBookingRules(item, rules, saturday, 1, rulesNow)
+	if ruleOf(err) != services.BookingRuleMinStay || err.Error() != "Reservation must be at least 2 nights long" {
+		t.Errorf("Expected %v but got %v", services.BookingRuleMinStay, err)
+	}
+
+	err = services.CheckBookingRules(item, rules, saturday, 8, rulesNow)
+	if ruleOf(err) != services.BookingRuleMaxStay {
+		t.Errorf("Expected %v but got %v", services.BookingRuleMaxStay, err)
+	}
+
+	err = services.CheckBookingRules(item, rules, saturday.AddDate(0, 0, 1), 7, rulesNow)
+	if ruleOf(err) != services.BookingRuleArrivalDay || err.Error() != "Reservation can only start on Saturday" {
+		t.Errorf("Expected %v but got %v", services.BookingRuleArrivalDay, err)
+	}
+
+	if err := services.CheckBookingRules(item, rules, saturday, 7, rulesNow); err != nil {
+		t.Errorf("Expected reservation to pass but got %v", err)
+	}
+}
+
+func TestBookingRules_OrTakesMissingFromDefaults(t *testing.T) {
+	rules := &models.BookingRules{MinStay: int64Ptr(3)}
+	defaults := &models.BookingRules{MinStay: int64Ptr(1), MaxStay: int64Ptr(14), ArrivalWeekdays: []int{5, 6}}
+
+	merged := rules.Or(defaults)
+	if *merged.MinStay != 3 || *merged.MaxStay != 14 || len(merged.ArrivalWeekdays) != 2 {
+		t.Errorf("Expected item min stay with tenant max stay and weekdays but got %#v", merged)
+	}
+	if rules.MaxStay != nil {
+		t.Errorf("Item rules should not be changed")
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers gif decoder
+	"image/jpeg"
+	_ "image/png" // registers png decoder
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+var UnsupportedMediaError = errors.New("Unsupported media type. Allowed are JPEG, PNG, GIF images and PDF documents")
+var MediaTooLargeError = errors.New("Media file is too large")
+var ImageTooLargeError = errors.New("Image dimensions are too large")
+
+// longer side of generated image thumbnails in pixels
+const thumbnailSize = 320
+
+// max number of pixels of uploaded image. Image is checked before it is
+// decoded, so small files declaring huge images are not decompressed
+const maxImagePixels = 50 * 1000 * 1000
+
+// allowed content types (detected from file content) and their media kind
+var mediaKinds = map[string]string{
+	"image/jpeg":      models.MediaKindImage,
+	"image/png":       models.MediaKindImage,
+	"image/gif":       models.MediaKindImage,
+	"application/pdf": models.MediaKindDocument,
+}
+
+var mediaExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+func NewMediaService(blobs BlobStore, maxSize int64) MediaService {
+	return &mediaService{
+		blobs:   blobs,
+		maxSize: maxSize,
+	}
+}
+
+type MediaService interface {
+	Upload(ctx context.Context, itemId int64, fileName string, data []byte) (*models.ItemMedia, error)
+	Remove(ctx context.Context, media *models.ItemMedia) error
+	MaxSize() int64
+}
+
+type mediaService struct {
+	blobs   BlobStore
+	maxSize int64
+}
+
+func (m *mediaService) MaxSize() int64 {
+	return m.maxSize
+}
+
+// Upload validates file size and type (by content, not by name) and stores it
+// in blob store. Images also get jpeg thumbnail. Returned media is not saved yet.
+func (m *mediaService) Upload(ctx context.Context, itemId int64, fileName string, data []byte) (*models.ItemMedia, error) {
+	if int64(len(data)) > m.maxSize {
+		return nil, MediaTooLargeError
+	}
+
+	contentType := http.DetectContentType(data)
+	kind, ok := mediaKinds[contentType]
+	if !ok {
+		return nil, UnsupportedMediaError
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+
+	media := &models.ItemMedia{
+		ItemId:      itemId,
+		Kind:        kind,
+		FileName:    cleanFileName(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Key:         fmt.Sprintf("items/%d/%s%s", itemId, name, mediaExtensions[contentType]),
+	}
+
+	var thumb []byte
+	if kind == models.MediaKindImage {
+		if thumb, err = thumbnail(data); err != nil {
+			return nil, err
+		}
+		media.ThumbnailKey = fmt.Sprintf("items/%d/%s_thumb.jpg", itemId, name)
+	}
+
+	if err := m.blobs.Put(ctx, media.Key, data); err != nil {
+		return nil, errors.Wrap(err, "Error storing media file")
+	}
+	media.URL = m.blobs.URL(media.Key)
+
+	if thumb != nil {
+		if err := m.blobs.Put(ctx, media.ThumbnailKey, thumb); err != nil {
+			m.blobs.Delete(ctx, media.Key)
+			return nil, errors.Wrap(err, "Error storing media thumbnail")
+		}
+		media.ThumbnailURL = m.blobs.URL(media.ThumbnailKey)
+	}
+
+	return media, nil
+}
+
+// Remove deletes media file and its thumbnail from blob store
+func (m *mediaService) Remove(ctx context.Context, media *models.ItemMedia) error {
+	if media.ThumbnailKey != "" {
+		if err := m.blobs.Delete(ctx, media.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return m.blobs.Delete(ctx, media.Key)
+}
+
+// returns jpeg thumbnail of image scaled (nearest neighbour) so that its
+// longer side is at most thumbnailSize. Transparent areas become white
+func thumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(UnsupportedMediaError, err.Error())
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, ImageTooLargeError
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(UnsupportedMediaError, err.Error())
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			tw, th = thumbnailSize, h*thumbnailSize/w
+		} else {
+			tw, th = w*thumbnailSize/h, thumbnailSize
+		}
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy := b.Min.Y + y*h/th
+		for x := 0; x < tw; x++ {
+			scaled.Set(x, y, src.At(b.Min.X+x*w/tw, sy))
+		}
+	}
+
+	dst := image.NewRGBA(scaled.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), scaled, image.Point{}, draw.Over)
+
+	out := &bytes.Buffer{}
+	if err := jpeg.Encode(out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, errors.Wrap(err, "Error encoding thumbnail")
+	}
+	return out.Bytes(), nil
+}
+
+// strips client path from uploaded file name and limits its length
+func cleanFileName(fileName string) string {
+	name := path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "Error generating media name")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/pkg/errors"
+)
+
+func pngImage(t *testing.T, w int, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{255, 0, 0, 255})
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMedia_Upload_ImageWithThumbnail(t *testing.T) {
+	dir := t.TempDir()
+	media := services.NewMediaService(services.NewLocalBlobStore(dir, "/media/"), 1<<20)
+
+	uploaded, err := media.Upload(context.Background(), 7, "C:\\photos\\room.png", pngImage(t, 1000, 500))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uploaded.Kind != models.MediaKindImage || uploaded.ContentType != "image/png" || uploaded.FileName != "room.png" {
+		t.Errorf("Unexpected media metadata: %#v", uploaded)
+	}
+
+	if !strings.HasPrefix(uploaded.URL, "/media/items/7/") || !strings.HasSuffix(uploaded.ThumbnailURL, "_thumb.jpg") {
+		t.Errorf("Unexpected media urls: %v, %v", uploaded.URL, uploaded.ThumbnailURL)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(uploaded.ThumbnailKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.Bounds().Dx() != 320 || thumb.Bounds().Dy() != 160 {
+		t.Errorf("Expected 320x160 thumbnail but got %v", thumb.Bounds())
+	}
+}
+
+func TestMedia_Upload_Document(t *testing.T) {
+	media := services.NewMediaService(services.NewLocalBlobStore(t.TempDir(), "/media"), 1<<20)
+
+	uploaded, err := media.Upload(context.Background(), 7, "terms.pdf", []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uploaded.Kind != models.MediaKindDocument || uploaded.ThumbnailKey != "" || !strings.HasSuffix(uploaded.Key, ".pdf") {
+		t.Errorf("Unexpected document media: %#v", uploaded)
+	}
+}
+
+func TestMedia_Upload_Rejected(t *testing.T) {
+	media := services.NewMediaService(services.NewLocalBlobStore(t.TempDir(), "/media"), 100)
+
+	if _, err := media.Upload(context.Background(), 7, "evil.png", []byte("<html><script></script></html>")); errors.Cause(err) != services.UnsupportedMediaError {
+		t.Errorf("Expected UnsupportedMediaError but got %v", err)
+	}
+
+	if _, err := media.Upload(context.Background(), 7, "big.png", pngImage(t, 200, 200)); errors.Cause(err) != services.MediaTooLargeError {
+		t.Errorf("Expected MediaTooLargeError but got %v", err)
+	}
+}
+
+// returns png header declaring image of given size without its pixel data
+func pngHeader(w uint32, h uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // rgba
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestMedia_Upload_DecompressionBomb(t *testing.T) {
+	media := services.NewMediaService(services.NewLocalBlobStore(t.TempDir(), "/media"), 1<<20)
+
+	if _, err := media.Upload(context.Background(), 7, "bomb.png", pngHeader(50000, 50000)); errors.Cause(err) != services.ImageTooLargeError {
+		t.Errorf("Expected ImageTooLargeError but got %v", err)
+	}
+}
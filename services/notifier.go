@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+)
+
+var MissingRecipientError = errors.New("Notification has no recipient")
+
+// limits single email when caller sets no earlier deadline
+const smtpTimeout = 10 * time.Second
+
+// Notification is plain text message for single recipient (email address)
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers notifications to customers
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// NewLogNotifier only logs notifications. It is used when no mail server is configured
+func NewLogNotifier(log hclog.Logger) Notifier {
+	return &logNotifier{
+		log: log,
+	}
+}
+
+type logNotifier struct {
+	log hclog.Logger
+}
+
+func (l *logNotifier) Notify(ctx context.Context, n *Notification) error {
+	if n.To == "" {
+		return MissingRecipientError
+	}
+	l.log.Info("Notification", "to", n.To, "subject", n.Subject, "body", n.Body)
+	return nil
+}
+
+// NewSmtpNotifier sends notifications as emails through mail server on host:port.
+// Plain auth is used when username is set
+func NewSmtpNotifier(host string, port string, username string, password string, from string) Notifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpNotifier{
+		host: host,
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+type smtpNotifier struct {
+	host string
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func (s *smtpNotifier) Notify(ctx context.Context, n *Notification) error {
+	if n.To == "" {
+		return MissingRecipientError
+	}
+
+	if err := s.send(ctx, n); err != nil {
+		return errors.Wrap(err, "Error sending notification email")
+	}
+	return nil
+}
+
+// sends email same as smtp.SendMail but connection is closed when ctx is done
+// and whole conversation must finish before ctx deadline or smtpTimeout
+func (s *smtpNotifier) send(ctx context.Context, n *Notification) error {
+	dialer := net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("Mail server doesn't support AUTH")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(n.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.message(n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// builds email with headers. Line breaks are removed from header values
+// so they can not inject other headers
+func (s *smtpNotifier) message(n *Notification) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		header.Replace(s.from), header.Replace(n.To), header.Replace(n.Subject), n.Body)
+	return []byte(msg)
+}
+
+// NewBackgroundNotifier sends notifications through next without making caller
+// wait for it, so requests are not held up by mail server. Each notification
+// is limited to timeout, errors are only logged
+func NewBackgroundNotifier(next Notifier, timeout time.Duration, log hclog.Logger) Notifier {
+	return &backgroundNotifier{
+		next:    next,
+		timeout: timeout,
+		log:     log,
+	}
+}
+
+type backgroundNotifier struct {
+	next    Notifier
+	timeout time.Duration
+	log     hclog.Logger
+}
+
+func (b *backgroundNotifier) Notify(ctx context.Context, n *Notification) error {
+	if n.To == "" {
+		return MissingRecipientError
+	}
+
+	// request context is cancelled once response is written
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+		defer cancel()
+		if err := b.next.Notify(ctx, n); err != nil {
+			b.log.Error("Error sending notification", "to", n.To, "subject", n.Subject, "error", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestSmtpNotifier_Notify_ServerNotResponding(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// accepts connections but never greets client
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	notifier := services.NewSmtpNotifier(host, port, "", "", "shop@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = notifier.Notify(ctx, &services.Notification{To: "john.doe@doe.com", Subject: "Test", Body: "Test"})
+	if err == nil {
+		t.Fatal("Expected error when mail server does not respond")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected send to stop at context deadline but it took %v", elapsed)
+	}
+}
+
+type slowNotifier struct {
+	sent chan *services.Notification
+}
+
+func (s *slowNotifier) Notify(ctx context.Context, n *services.Notification) error {
+	<-ctx.Done()
+	s.sent <- n
+	return ctx.Err()
+}
+
+func TestBackgroundNotifier_Notify_DoesNotWait(t *testing.T) {
+	next := &slowNotifier{sent: make(chan *services.Notification, 1)}
+	notifier := services.NewBackgroundNotifier(next, 50*time.Millisecond, hclog.NewNullLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := notifier.Notify(ctx, &services.Notification{To: "john.doe@doe.com"}); err != nil {
+		t.Fatal(err)
+	}
+	// cancelled request must not stop notification, it runs until its own timeout
+	cancel()
+
+	select {
+	case n := <-next.sent:
+		if n.To != "john.doe@doe.com" {
+			t.Errorf("Expected notification to john.doe@doe.com but got %v", n.To)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected notification to be sent in background")
+	}
+}
+
+func TestBackgroundNotifier_Notify_MissingRecipient(t *testing.T) {
+	notifier := services.NewBackgroundNotifier(&slowNotifier{}, time.Second, hclog.NewNullLogger())
+
+	if err := notifier.Notify(context.Background(), &services.Notification{}); err != services.MissingRecipientError {
+		t.Errorf("Expected missing recipient error but got %v", err)
+	}
+}
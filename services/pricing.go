@@ -0,0 +1,317 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+var MissingItemError = errors.New("Item is required for price quote")
+var InvalidStayError = errors.New("Stay must be at least one night long")
+var InvalidSlotError = errors.New("Slots must be inside opening hours of slot booked item")
+var InvalidAddonError = errors.New("Invalid add-on selection")
+var MissingTaxRateError = errors.New("Tax rate is not set for tax class on reservation date")
+
+func NewPricingService() PricingService {
+	return &pricingService{
+		now: time.Now,
+	}
+}
+
+type PricingService interface {
+	Quote(item *models.Item, date time.Time) (*models.PriceQuote, error)
+	QuoteStay(item *models.Item, from time.Time, to time.Time) (*models.StayQuote, error)
+	QuoteSlots(item *models.Item, start time.Time, count int) (*models.SlotQuote, error)
+	QuoteAddons(item *models.Item, selections []models.AddonSelection) ([]models.AddonLineItem, models.Money, error)
+	QuoteDiscount(promo *models.PromoCode, amount models.Money) models.Money
+	QuoteTax(item *models.Item, reservation models.Money, addons []models.AddonLineItem, rates models.TaxRates, date time.Time) (*models.TaxBreakdown, error)
+}
+
+type pricingService struct {
+	now func() time.Time
+}
+
+// Resolves effective price of item for reservation date.
+// Date price range covering the date wins over item base price. Ranges are
+// compared by day and are inclusive on both ends. If more ranges cover the date
+// the one starting latest (most specific) is used.
+// Matching price rules are then applied on top of that price ordered by
+// priority and id (lower first), each rule working on result of previous one.
+// Date range price in other currency than item is rejected.
+func (p *pricingService) Quote(item *models.Item, date time.Time) (*models.PriceQuote, error) {
+	if item == nil {
+		return nil, MissingItemError
+	}
+
+	day := models.TruncateDay(date)
+	quote := &models.PriceQuote{
+		ItemId:    item.Id,
+		Date:      day,
+		Price:     item.Price,
+		BasePrice: item.Price,
+		ListPrice: item.Price,
+		Source:    models.PriceSourceBase,
+	}
+
+	var match *models.ItemDatePrice
+	for i := range item.DatePrices {
+		dp := &item.DatePrices[i]
+		if day.Before(models.TruncateDay(dp.DateFrom)) || day.After(models.TruncateDay(dp.DateTo)) {
+			continue
+		}
+		if match == nil || dp.DateFrom.After(match.DateFrom) {
+			match = dp
+		}
+	}
+
+	if match != nil {
+		price := match.Price.OrCurrency(item.Price.Currency)
+		if price.Currency != item.Price.Currency {
+			return nil, errors.Wrapf(models.ErrCurrencyMismatch, "Date price %d of item %d is in %v, item in %v",
+				match.Id, item.Id, price.Currency, item.Price.Currency)
+		}
+		quote.Price = price
+		quote.ListPrice = price
+		quote.Source = models.PriceSourceDateRange
+		quote.DatePrice = match
+	}
+
+	leadDays := int(day.Sub(models.TruncateDay(p.now())).Hours() / 24)
+	for _, rule := range sortedRules(item.PriceRules) {
+		if !ruleMatches(&rule, day, leadDays) {
+			continue
+		}
+
+		price := models.NewMoney(applyRule(&rule, quote.Price.Amount), quote.Price.Currency)
+		quote.Rules = append(quote.Rules, models.AppliedPriceRule{
+			RuleId:       rule.Id,
+			Title:        rule.Title,
+			ModifierType: rule.ModifierType,
+			Modifier:     rule.Modifier,
+			Adjustment:   models.NewMoney(price.Amount-quote.Price.Amount, price.Currency),
+		})
+		quote.Price = price
+	}
+
+	return quote, nil
+}
+
+// Prices stay from check-in (from) to check-out (to) night by night,
+// each night is quoted on its own so ranges and rules can differ between nights
+func (p *pricingService) QuoteStay(item *models.Item, from time.Time, to time.Time) (*models.StayQuote, error) {
+	if item == nil {
+		return nil, MissingItemError
+	}
+
+	checkIn := models.TruncateDay(from)
+	checkOut := models.TruncateDay(to)
+	if !checkOut.After(checkIn) {
+		return nil, InvalidStayError
+	}
+
+	stay := &models.StayQuote{
+		ItemId:   item.Id,
+		DateFrom: checkIn,
+		DateTo:   checkOut,
+		Nights:   []models.PriceQuote{},
+		Total:    models.NewMoney(0, item.Price.Currency),
+	}
+
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		quote, err := p.Quote(item, night)
+		if err != nil {
+			return nil, err
+		}
+		stay.Nights = append(stay.Nights, *quote)
+		if stay.Total, err = stay.Total.Add(quote.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	return stay, nil
+}
+
+// Prices count consecutive slots of slot booked item starting at start.
+// Item price (date ranges and rules included) is price of single slot,
+// ranges and rules are resolved by day of each slot
+func (p *pricingService) QuoteSlots(item *models.Item, start time.Time, count int) (*models.SlotQuote, error) {
+	if item == nil {
+		return nil, MissingItemError
+	}
+
+	end, err := item.SlotEnd(start, count)
+	if err != nil {
+		return nil, errors.Wrap(InvalidSlotError, err.Error())
+	}
+
+	quote := &models.SlotQuote{
+		ItemId: item.Id,
+		Start:  start.UTC(),
+		End:    end,
+		Slots:  []models.PriceQuote{},
+		Total:  models.NewMoney(0, item.Price.Currency),
+	}
+
+	for slot := quote.Start; slot.Before(end); slot = slot.Add(item.SlotLength()) {
+		q, err := p.Quote(item, slot)
+		if err != nil {
+			return nil, err
+		}
+		q.Date = slot
+		quote.Slots = append(quote.Slots, *q)
+		if quote.Total, err = quote.Total.Add(q.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	return quote, nil
+}
+
+// Prices add-ons selected on reservation of item. Only add-ons offered with item
+// (item.Addons) can be selected and each of them only once. Unit add-ons are
+// charged price * quantity, booking add-ons are charged once and allow single unit.
+// Add-ons must be priced in item currency. Returns snapshot line items and their total
+func (p *pricingService) QuoteAddons(item *models.Item, selections []models.AddonSelection) ([]models.AddonLineItem, models.Money, error) {
+	if item == nil {
+		return nil, models.Money{}, MissingItemError
+	}
+
+	lines := []models.AddonLineItem{}
+	total := models.NewMoney(0, item.Price.Currency)
+	selected := map[int64]bool{}
+	for _, selection := range selections {
+		addon := findAddon(item.Addons, selection.AddonId)
+		if addon == nil {
+			return nil, models.Money{}, errors.Wrapf(InvalidAddonError, "Add-on %d is not offered with item %d", selection.AddonId, item.Id)
+		}
+		if selected[addon.Id] {
+			return nil, models.Money{}, errors.Wrapf(InvalidAddonError, "Add-on %d is selected more than once", addon.Id)
+		}
+		selected[addon.Id] = true
+
+		quantity := selection.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		price := addon.Price.OrCurrency(item.Price.Currency)
+		line := models.AddonLineItem{
+			AddonId:    &addon.Id,
+			Title:      addon.Title,
+			Charge:     addon.Charge,
+			Price:      price,
+			Quantity:   quantity,
+			Total:      price.Times(int64(quantity)),
+			TaxClassId: addon.TaxClassId,
+		}
+		if addon.Charge == models.AddonChargeBooking {
+			if quantity != 1 {
+				return nil, models.Money{}, errors.Wrapf(InvalidAddonError, "Add-on %d is charged per booking, quantity must be 1", addon.Id)
+			}
+			line.Total = price
+		}
+
+		var err error
+		if total, err = total.Add(line.Total); err != nil {
+			return nil, models.Money{}, errors.Wrapf(models.ErrCurrencyMismatch, "Add-on %d is in %v, item %d in %v",
+				addon.Id, price.Currency, item.Id, item.Price.Currency)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, total, nil
+}
+
+// Returns discount of promo code on amount. Percent discount is rounded half up.
+// Discount never exceeds amount. Eligibility of code is checked by caller
+func (p *pricingService) QuoteDiscount(promo *models.PromoCode, amount models.Money) models.Money {
+	discount := promo.Discount
+	if promo.DiscountType == models.PromoDiscountPercent {
+		discount = (amount.Amount*promo.Discount*2 + 100) / 200
+	}
+	if discount > amount.Amount {
+		discount = amount.Amount
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	return models.NewMoney(discount, amount.Currency)
+}
+
+// returns enabled add-on with id or nil
+func findAddon(addons []models.ItemAddon, id int64) *models.ItemAddon {
+	for i := range addons {
+		if addons[i].Id == id && !addons[i].Disabled {
+			return &addons[i]
+		}
+	}
+	return nil
+}
+
+// returns copy of rules in evaluation order (priority, id)
+func sortedRules(rules []models.ItemPriceRule) []models.ItemPriceRule {
+	sorted := make([]models.ItemPriceRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority < sorted[j].Priority
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+	return sorted
+}
+
+func ruleMatches(rule *models.ItemPriceRule, day time.Time, leadDays int) bool {
+	if rule.Disabled {
+		return false
+	}
+
+	if len(rule.Weekdays) != 0 {
+		found := false
+		for _, wd := range rule.Weekdays {
+			if time.Weekday(wd) == day.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if rule.MinLeadDays != nil && leadDays < *rule.MinLeadDays {
+		return false
+	}
+	if rule.MaxLeadDays != nil && leadDays > *rule.MaxLeadDays {
+		return false
+	}
+
+	if rule.DateFrom != nil && day.Before(models.TruncateDay(*rule.DateFrom)) {
+		return false
+	}
+	if rule.DateTo != nil && day.After(models.TruncateDay(*rule.DateTo)) {
+		return false
+	}
+
+	return true
+}
+
+// applies rule modifier to price. Percent changes are rounded to nearest
+// minor unit, price never goes below zero
+func applyRule(rule *models.ItemPriceRule, price int64) int64 {
+	switch rule.ModifierType {
+	case models.PriceModifierPercent:
+		change := float64(price) * float64(rule.Modifier) / 100
+		price += int64(math.Round(change))
+	case models.PriceModifierAbsolute:
+		price += rule.Modifier
+	}
+
+	if price < 0 {
+		return 0
+	}
+	return price
+}
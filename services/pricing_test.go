@@ -0,0 +1,310 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/pkg/errors"
+)
+
+func pricedItem() *models.Item {
+	return &models.Item{
+		Id:    1,
+		Price: models.NewMoney(100, "EUR"),
+		DatePrices: []models.ItemDatePrice{
+			{
+				Id:       1,
+				DateFrom: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+				DateTo:   time.Date(2022, 8, 31, 0, 0, 0, 0, time.UTC),
+				Price:    models.NewMoney(200, "EUR"),
+			},
+		},
+	}
+}
+
+func TestPricing_Quote_FallbackToBasePrice(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	quote, err := pricing.Quote(pricedItem(), time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quote.Price.Amount != 100 || quote.Source != models.PriceSourceBase || quote.DatePrice != nil {
+		t.Errorf("Expected base price 100 but got %#v", quote)
+	}
+}
+
+func TestPricing_Quote_RangeIsInclusive(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	quote, err := pricing.Quote(pricedItem(), time.Date(2022, 8, 31, 18, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quote.Price.Amount != 200 || quote.Source != models.PriceSourceDateRange {
+		t.Errorf("Expected date range price 200 on last day of range but got %#v", quote)
+	}
+}
+
+func TestPricing_Quote_DatePriceInOtherCurrency(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := pricedItem()
+	item.DatePrices[0].Price = models.NewMoney(200, "USD")
+
+	_, err := pricing.Quote(item, time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	if errors.Cause(err) != models.ErrCurrencyMismatch {
+		t.Errorf("Expected ErrCurrencyMismatch but got %v", err)
+	}
+}
+
+func TestPricing_QuoteStay_TotalKeepsCurrency(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := pricedItem()
+	item.Price = models.NewMoney(100, "USD")
+	item.DatePrices = nil
+
+	stay, err := pricing.QuoteStay(item, time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 12, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stay.Total != models.NewMoney(200, "USD") {
+		t.Errorf("Expected total 200 USD but got %v", stay.Total)
+	}
+}
+
+func TestPricing_Quote_MissingItem(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	if _, err := pricing.Quote(nil, time.Now()); err != services.MissingItemError {
+		t.Errorf("Expected MissingItemError but got %v", err)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestPricing_Quote_RulesAppliedByPriority(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	// next saturday, at least a week ahead
+	date := time.Now().UTC().AddDate(0, 0, 7)
+	for date.Weekday() != time.Saturday {
+		date = date.AddDate(0, 0, 1)
+	}
+
+	item := &models.Item{
+		Id:    1,
+		Price: models.NewMoney(1000, "EUR"),
+		PriceRules: []models.ItemPriceRule{
+			{Id: 1, Title: "Flat fee", Priority: 2, ModifierType: models.PriceModifierAbsolute, Modifier: 50},
+			{Id: 2, Title: "Weekend", Priority: 1, Weekdays: []int{0, 6}, ModifierType: models.PriceModifierPercent, Modifier: 20},
+			{Id: 3, Title: "Weekdays only", Priority: 0, Weekdays: []int{1, 2, 3, 4, 5}, ModifierType: models.PriceModifierPercent, Modifier: -50},
+			{Id: 4, Title: "Disabled", Priority: 0, Disabled: true, ModifierType: models.PriceModifierAbsolute, Modifier: -1000},
+		},
+	}
+
+	quote, err := pricing.Quote(item, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1000 + 20% = 1200, + 50 = 1250
+	if quote.Price.Amount != 1250 || quote.ListPrice.Amount != 1000 {
+		t.Errorf("Expected price 1250 (list 1000) but got %v (list %v)", quote.Price, quote.ListPrice)
+	}
+
+	if len(quote.Rules) != 2 || quote.Rules[0].RuleId != 2 || quote.Rules[1].RuleId != 1 {
+		t.Fatalf("Expected rules 2 and 1 to be applied in order but got %#v", quote.Rules)
+	}
+
+	if quote.Rules[0].Adjustment.Amount != 200 || quote.Rules[1].Adjustment.Amount != 50 {
+		t.Errorf("Unexpected adjustments %#v", quote.Rules)
+	}
+}
+
+func TestPricing_Quote_LeadTimeRules(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := &models.Item{
+		Id:    1,
+		Price: models.NewMoney(1000, "EUR"),
+		PriceRules: []models.ItemPriceRule{
+			{Id: 1, Title: "Early bird", MinLeadDays: intPtr(60), ModifierType: models.PriceModifierPercent, Modifier: -10},
+			{Id: 2, Title: "Last minute", MaxLeadDays: intPtr(3), ModifierType: models.PriceModifierPercent, Modifier: -25},
+		},
+	}
+
+	early, _ := pricing.Quote(item, time.Now().AddDate(0, 0, 90))
+	if early.Price.Amount != 900 {
+		t.Errorf("Expected early bird price 900 but got %v", early.Price)
+	}
+
+	lastMinute, _ := pricing.Quote(item, time.Now().AddDate(0, 0, 1))
+	if lastMinute.Price.Amount != 750 {
+		t.Errorf("Expected last minute price 750 but got %v", lastMinute.Price)
+	}
+
+	regular, _ := pricing.Quote(item, time.Now().AddDate(0, 0, 30))
+	if regular.Price.Amount != 1000 || len(regular.Rules) != 0 {
+		t.Errorf("Expected regular price 1000 without rules but got %#v", regular)
+	}
+}
+
+func TestPricing_Quote_PriceNeverNegative(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := &models.Item{
+		Id:    1,
+		Price: models.NewMoney(100, "EUR"),
+		PriceRules: []models.ItemPriceRule{
+			{Id: 1, Title: "Voucher", ModifierType: models.PriceModifierAbsolute, Modifier: -500},
+		},
+	}
+
+	quote, _ := pricing.Quote(item, time.Now())
+	if quote.Price.Amount != 0 {
+		t.Errorf("Expected price 0 but got %v", quote.Price)
+	}
+}
+
+func TestPricing_QuoteStay_NightByNight(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	// two nights before range (100 each) and two inside it (200 each)
+	from := time.Date(2022, 5, 30, 14, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 6, 3, 10, 0, 0, 0, time.UTC)
+
+	stay, err := pricing.QuoteStay(pricedItem(), from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stay.Nights) != 4 || stay.Total.Amount != 600 {
+		t.Errorf("Expected 4 nights with total 600 but got %v nights with total %v", len(stay.Nights), stay.Total)
+	}
+}
+
+func TestPricing_QuoteStay_InvalidStay(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	day := time.Date(2022, 6, 3, 0, 0, 0, 0, time.UTC)
+	if _, err := pricing.QuoteStay(pricedItem(), day, day); err != services.InvalidStayError {
+		t.Errorf("Expected InvalidStayError but got %v", err)
+	}
+}
+
+func slotItem() *models.Item {
+	item := pricedItem()
+	opensAt := "08:00"
+	closesAt := "20:00"
+	item.BookingMode = models.BookingModeSlot
+	item.SlotMinutes = 90
+	item.OpensAt = &opensAt
+	item.ClosesAt = &closesAt
+	return item
+}
+
+func TestPricing_QuoteSlots_SlotBySlot(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	start := time.Date(2022, 6, 3, 9, 30, 0, 0, time.UTC)
+	quote, err := pricing.QuoteSlots(slotItem(), start, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(quote.Slots) != 3 || quote.Total.Amount != 600 {
+		t.Errorf("Expected 3 slots with total 600 but got %v slots with total %v", len(quote.Slots), quote.Total)
+	}
+
+	if !quote.End.Equal(time.Date(2022, 6, 3, 14, 0, 0, 0, time.UTC)) || !quote.Slots[2].Date.Equal(time.Date(2022, 6, 3, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected slots ending at 14:00 with last one at 12:30 but got %#v", quote)
+	}
+}
+
+func TestPricing_QuoteSlots_InvalidSlot(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	misaligned := time.Date(2022, 6, 3, 9, 0, 0, 0, time.UTC)
+	if _, err := pricing.QuoteSlots(slotItem(), misaligned, 1); errors.Cause(err) != services.InvalidSlotError {
+		t.Errorf("Expected InvalidSlotError for misaligned slot but got %v", err)
+	}
+
+	// last slot would end at 21:30, after closing
+	late := time.Date(2022, 6, 3, 17, 0, 0, 0, time.UTC)
+	if _, err := pricing.QuoteSlots(slotItem(), late, 3); errors.Cause(err) != services.InvalidSlotError {
+		t.Errorf("Expected InvalidSlotError after closing but got %v", err)
+	}
+
+	if _, err := pricing.QuoteSlots(pricedItem(), misaligned, 1); errors.Cause(err) != services.InvalidSlotError {
+		t.Errorf("Expected InvalidSlotError for daily item but got %v", err)
+	}
+}
+
+func addonItem() *models.Item {
+	item := pricedItem()
+	item.Addons = []models.ItemAddon{
+		{Id: 1, Title: "Breakfast", Price: models.NewMoney(15, "EUR"), Charge: models.AddonChargeUnit},
+		{Id: 2, Title: "Cleaning", Price: models.NewMoney(40, "EUR"), Charge: models.AddonChargeBooking},
+		{Id: 3, Title: "Sauna", Price: models.NewMoney(30, "EUR"), Charge: models.AddonChargeUnit, Disabled: true},
+	}
+	return item
+}
+
+func TestPricing_QuoteAddons_Totals(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	lines, total, err := pricing.QuoteAddons(addonItem(), []models.AddonSelection{
+		{AddonId: 1, Quantity: 4},
+		{AddonId: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lines) != 2 || lines[0].Total.Amount != 60 || lines[1].Total.Amount != 40 || lines[1].Quantity != 1 || total.Amount != 100 {
+		t.Errorf("Expected breakfast 60 and cleaning 40 (total 100) but got %#v, total %v", lines, total)
+	}
+
+	if lines[0].Title != "Breakfast" || *lines[0].AddonId != 1 {
+		t.Errorf("Expected snapshot of breakfast add-on but got %#v", lines[0])
+	}
+}
+
+func TestPricing_QuoteAddons_InvalidSelection(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	invalid := [][]models.AddonSelection{
+		{{AddonId: 9}},
+		{{AddonId: 3}},
+		{{AddonId: 2, Quantity: 2}},
+		{{AddonId: 1}, {AddonId: 1}},
+	}
+	for _, selections := range invalid {
+		if _, _, err := pricing.QuoteAddons(addonItem(), selections); errors.Cause(err) != services.InvalidAddonError {
+			t.Errorf("Expected InvalidAddonError for %#v but got %v", selections, err)
+		}
+	}
+}
+
+func TestPricing_QuoteDiscount(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	percent := &models.PromoCode{DiscountType: models.PromoDiscountPercent, Discount: 15}
+	if discount := pricing.QuoteDiscount(percent, models.NewMoney(1250, "EUR")); discount != models.NewMoney(188, "EUR") {
+		t.Errorf("Expected 15%% of 1250 rounded to 188 but got %v", discount)
+	}
+
+	fixed := &models.PromoCode{DiscountType: models.PromoDiscountFixed, Discount: 5000, Currency: "EUR"}
+	if discount := pricing.QuoteDiscount(fixed, models.NewMoney(3000, "EUR")); discount != models.NewMoney(3000, "EUR") {
+		t.Errorf("Expected fixed discount capped to price 3000 but got %v", discount)
+	}
+}
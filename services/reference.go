@@ -0,0 +1,54 @@
+package services
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// characters of reference codes, those easily mistaken for each other
+// (0 and O, 1, I and L) are left out
+const referenceAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// reference code is written as 3 and 4 characters separated by dash
+const (
+	referencePrefixLength = 3
+	referenceLength       = 7
+)
+
+// NewReference returns random reference code of reservation (e.g. K7F-92QX).
+// Codes are not sequential, uniqueness is left to caller
+func NewReference() (string, error) {
+	max := big.NewInt(int64(len(referenceAlphabet)))
+
+	code := make([]byte, 0, referenceLength+1)
+	for idx := 0; idx < referenceLength; idx++ {
+		if idx == referencePrefixLength {
+			code = append(code, '-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "Error generating reference code")
+		}
+		code = append(code, referenceAlphabet[n.Int64()])
+	}
+	return string(code), nil
+}
+
+// NormalizeReference returns code as it was generated. Case, spaces and dashes
+// of typed code are ignored, codes with characters outside of alphabet are invalid
+func NormalizeReference(code string) (string, bool) {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(code) != referenceLength {
+		return "", false
+	}
+	for _, c := range code {
+		if !strings.ContainsRune(referenceAlphabet, c) {
+			return "", false
+		}
+	}
+	return code[:referencePrefixLength] + "-" + code[referencePrefixLength:], true
+}
@@ -0,0 +1,42 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/alesbrelih/go-reservation-api/services"
+)
+
+func TestReference_NewReference(t *testing.T) {
+	seen := map[string]bool{}
+	for idx := 0; idx < 100; idx++ {
+		code, err := services.NewReference()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if normalized, ok := services.NormalizeReference(code); !ok || normalized != code {
+			t.Fatalf("Expected generated code %v to be normalized but got %v", code, normalized)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 100 {
+		t.Errorf("Expected random codes but got %v distinct of 100", len(seen))
+	}
+}
+
+func TestReference_NormalizeReference(t *testing.T) {
+	cases := map[string]string{
+		"K7F-92QX":  "K7F-92QX",
+		"k7f92qx":   "K7F-92QX",
+		" k7f 92qx": "K7F-92QX",
+		"K7F-92Q":   "",
+		"K7F-92Q0":  "",
+		"K7F-92QXY": "",
+		"":          "",
+	}
+	for code, expected := range cases {
+		normalized, ok := services.NormalizeReference(code)
+		if ok != (expected != "") || normalized != expected {
+			t.Errorf("Expected %q to normalize to %q but got %q (%v)", code, expected, normalized, ok)
+		}
+	}
+}
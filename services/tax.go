@@ -0,0 +1,80 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+// Splits gross amounts of reservation (prices include tax) into net and tax.
+// Reservation amount is taxed by item tax class, add-on line items by their own.
+// Amounts are summed per tax class first, tax is then rounded once per class
+// (half up). Rates valid on reservation date are used, amounts without tax
+// class are not taxed. Tax class without rate fails with MissingTaxRateError
+func (p *pricingService) QuoteTax(item *models.Item, reservation models.Money, addons []models.AddonLineItem,
+	rates models.TaxRates, date time.Time) (*models.TaxBreakdown, error) {
+	if item == nil {
+		return nil, MissingItemError
+	}
+
+	// untaxed amounts are kept under class 0
+	gross := map[int64]models.Money{}
+	add := func(taxClassId *int64, amount models.Money) error {
+		var id int64
+		if taxClassId != nil {
+			id = *taxClassId
+		}
+		sum, err := gross[id].Add(amount)
+		if err != nil {
+			return err
+		}
+		gross[id] = sum
+		return nil
+	}
+
+	if err := add(item.TaxClassId, reservation); err != nil {
+		return nil, err
+	}
+	for _, line := range addons {
+		if err := add(line.TaxClassId, line.Total); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]int64, 0, len(gross))
+	for id := range gross {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	lines := make([]models.TaxLine, 0, len(ids))
+	for _, id := range ids {
+		line := models.TaxLine{Gross: gross[id]}
+		if id != 0 {
+			rate, ok := rates.RateAt(id, date)
+			if !ok {
+				return nil, errors.Wrapf(MissingTaxRateError, "Tax class %d of item %d has no rate on %v",
+					id, item.Id, date.Format("2006-01-02"))
+			}
+			taxClassId := id
+			line.TaxClassId = &taxClassId
+			line.Rate = rate.Rate
+		}
+		line.Tax = models.NewMoney(includedTax(line.Gross.Amount, line.Rate), line.Gross.Currency)
+		line.Net = models.NewMoney(line.Gross.Amount-line.Tax.Amount, line.Gross.Currency)
+		lines = append(lines, line)
+	}
+
+	return models.NewTaxBreakdown(lines)
+}
+
+// returns tax included in gross amount at rate (basis points), rounded half up
+func includedTax(gross int64, rate int64) int64 {
+	if gross <= 0 || rate == 0 {
+		return 0
+	}
+	divisor := 10000 + rate
+	return (2*gross*rate + divisor) / (2 * divisor)
+}
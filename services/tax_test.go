@@ -0,0 +1,76 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/pkg/errors"
+)
+
+func taxRates() models.TaxRates {
+	until := time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)
+	return models.TaxRates{
+		{Id: 1, TaxClassId: 1, Rate: 2200, ValidFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), ValidTo: &until},
+		{Id: 2, TaxClassId: 1, Rate: 2500, ValidFrom: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{Id: 3, TaxClassId: 2, Rate: 950, ValidFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestPricing_QuoteTax_SplitsByTaxClass(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := &models.Item{Id: 1, TaxClassId: int64Ptr(1)}
+	addons := []models.AddonLineItem{
+		{Title: "Breakfast", Total: models.NewMoney(1095, "EUR"), TaxClassId: int64Ptr(2)},
+		{Title: "Parking", Total: models.NewMoney(500, "EUR")},
+	}
+
+	tax, err := pricing.QuoteTax(item, models.NewMoney(12200, "EUR"), addons, taxRates(), time.Date(2022, 6, 30, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tax.Lines) != 3 || tax.Lines[0].TaxClassId != nil || tax.Lines[0].Tax.Amount != 0 {
+		t.Fatalf("Expected untaxed line first out of 3 but got %#v", tax.Lines)
+	}
+	if tax.Lines[1].Rate != 2200 || tax.Lines[1].Net.Amount != 10000 || tax.Lines[1].Tax.Amount != 2200 {
+		t.Errorf("Expected 22%% tax 2200 of 12200 but got %#v", tax.Lines[1])
+	}
+	if tax.Lines[2].Rate != 950 || tax.Lines[2].Tax.Amount != 95 {
+		t.Errorf("Expected 9.5%% tax 95 of 1095 but got %#v", tax.Lines[2])
+	}
+	if tax.Gross != models.NewMoney(13795, "EUR") || tax.Tax.Amount != 2295 || tax.Net.Amount != 11500 {
+		t.Errorf("Expected gross 13795 with tax 2295 but got %#v", tax)
+	}
+}
+
+func TestPricing_QuoteTax_RateValidOnReservationDate(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := &models.Item{Id: 1, TaxClassId: int64Ptr(1)}
+	tax, err := pricing.QuoteTax(item, models.NewMoney(1000, "EUR"), nil, taxRates(), time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1000 * 2500 / 12500 = 200
+	if tax.Lines[0].Rate != 2500 || tax.Tax.Amount != 200 {
+		t.Errorf("Expected new 25%% rate with tax 200 but got %#v", tax.Lines[0])
+	}
+}
+
+func TestPricing_QuoteTax_MissingRate(t *testing.T) {
+	pricing := services.NewPricingService()
+
+	item := &models.Item{Id: 1, TaxClassId: int64Ptr(3)}
+	_, err := pricing.QuoteTax(item, models.NewMoney(1000, "EUR"), nil, taxRates(), time.Now())
+	if errors.Cause(err) != services.MissingTaxRateError {
+		t.Errorf("Expected MissingTaxRateError but got %v", err)
+	}
+}
@@ -3,39 +3,59 @@ package stores
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/alesbrelih/go-reservation-api/db"
 	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/pkg/errors"
 )
 
-func NewAcceptedStoreSql(dbFactory db.DbFactory) AcceptedStore {
+func NewAcceptedStoreSql(dbFactory db.DbFactory, pricing services.PricingService) AcceptedStore {
 	return &acceptedStoreSql{
 		dbFactory: dbFactory,
+		pricing:   pricing,
 	}
 }
 
 type AcceptedStore interface {
 	GetAll(ctx context.Context) (models.AcceptedList, error)
 	ProcessInquiry(ctx context.Context, accepted *models.Accepted) (int64, error)
+	AssignUnit(ctx context.Context, id int64, unitId int64) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type acceptedStoreSql struct {
 	dbFactory db.DbFactory
+	pricing   services.PricingService
 }
 
 func (a *acceptedStoreSql) GetAll(ctx context.Context) (models.AcceptedList, error) {
 	db := a.dbFactory.Connect()
 	defer db.Close()
 
+	return selectAcceptedList(ctx, db, "")
+}
+
+// selects accepted reservations matching condition (on accepted aliased as a),
+// all of them without condition, from last accepted with their add-on lines,
+// tax breakdowns and bundle components
+func selectAcceptedList(ctx context.Context, db queryer, where string, args ...interface{}) (models.AcceptedList, error) {
+	if where != "" {
+		where = "WHERE " + where
+	}
+
 	// TODO: add index to date_accepted
-	q := `SELECT id, inquirer, inquirer_email, inquirer_phone,
-				item_id, item_title, item_price, date_reservation 
+	q := `SELECT a.id, a.reference, a.inquirer, a.inquirer_email, a.inquirer_phone,
+				a.item_id, a.item_title, a.item_price, COALESCE(a.total_price, a.item_price),
+				a.date_reservation, a.date_end, COALESCE(a.inquiry_id, 0), a.addons_total, a.currency,
+				COALESCE(a.promo_code, ''), a.discount, a.unit_id, COALESCE(u.label, ''), a.cancellation_requested_at
 			FROM accepted a
+				LEFT JOIN item_unit u ON u.id = a.unit_id
+			` + where + `
 			ORDER BY a.date_accepted DESC`
 
-	rows, err := db.QueryContext(ctx, q)
+	rows, err := db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error querying all accepted from db")
 	}
@@ -43,40 +63,300 @@ func (a *acceptedStoreSql) GetAll(ctx context.Context) (models.AcceptedList, err
 	var acceptedList []*models.Accepted
 	for rows.Next() {
 		accepted := &models.Accepted{}
-		if err := rows.Scan(&accepted.Id, &accepted.Inquirer, &accepted.InquirerEmail,
-			&accepted.InquirerPhone, &accepted.ItemId, &accepted.ItemTitle, &accepted.ItemPrice, &accepted.DateReservation); err != nil {
+		var currency string
+		if err := rows.Scan(&accepted.Id, &accepted.Reference, &accepted.Inquirer, &accepted.InquirerEmail,
+			&accepted.InquirerPhone, &accepted.ItemId, &accepted.ItemTitle, &accepted.ItemPrice.Amount,
+			&accepted.TotalPrice.Amount, &accepted.DateReservation, &accepted.DateEnd,
+			&accepted.InquiryId, &accepted.AddonsTotal.Amount, &currency,
+			&accepted.PromoCode, &accepted.Discount.Amount, &accepted.UnitId, &accepted.UnitLabel,
+			&accepted.CancellationRequestedAt); err != nil {
 			return nil, errors.Wrap(err, "Error scaning accepted info to model")
 		}
+		accepted.ItemPrice.Currency = currency
+		accepted.TotalPrice.Currency = currency
+		accepted.AddonsTotal.Currency = currency
+		accepted.Discount.Currency = currency
 		acceptedList = append(acceptedList, accepted)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error querying all accepted from db")
+	}
+
+	ids := make([]int64, 0, len(acceptedList))
+	for _, accepted := range acceptedList {
+		ids = append(ids, accepted.Id)
+	}
+
+	addons, err := selectAddonLines(ctx, db, acceptedAddonTable, acceptedAddonOwner, ids)
+	if err != nil {
+		return nil, err
+	}
+	taxes, err := selectTaxLines(ctx, db, acceptedTaxTable, acceptedTaxOwner, ids)
+	if err != nil {
+		return nil, err
+	}
+	components, err := selectAcceptedComponents(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, accepted := range acceptedList {
+		accepted.Addons = addons[accepted.Id]
+		accepted.Tax = taxes[accepted.Id]
+		accepted.Components = components[accepted.Id]
+	}
+
 	return acceptedList, nil
 }
 
+// ProcessInquiry saves reservation made from given data. Reservation made for
+// inquiry (InquiryId) accepts it and is made from inquiry as it was stored,
+// only notes are taken from given data. Inquiries that are not pending anymore
+// are reported as InquiryTransitionError
 func (a *acceptedStoreSql) ProcessInquiry(ctx context.Context, accepted *models.Accepted) (int64, error) {
 	db := a.dbFactory.Connect()
 	defer db.Close()
 
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error initializing transaction for ProcessInquiry in accepted store")
+	}
+	defer tx.Rollback()
+
+	if accepted.InquiryId != 0 {
+		saved, err := acceptInquiry(ctx, tx, a.pricing, accepted.InquiryId, accepted.Notes)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, errors.Wrap(err, "Error commiting processed inquiry")
+		}
+		*accepted = *saved
+		return saved.Id, nil
+	}
+
+	// single date reservation is one night stay
+	dateEnd := accepted.DateReservation.AddDate(0, 0, 1)
+	if accepted.DateEnd != nil {
+		dateEnd = *accepted.DateEnd
+	}
+	units := nights(*accepted.DateReservation, dateEnd)
+
+	// prices are in item currency, without item in currency of given prices
+	currency := accepted.ItemPrice.OrCurrency(accepted.TotalPrice.OrCurrency(models.DefaultCurrency).Currency).Currency
+
+	// reservations without item are not taxed and have no unit
+	item := &models.Item{}
+	var unitId *int64
+	if accepted.ItemId != 0 {
+		if item, err = selectItem(ctx, tx, accepted.ItemId); err != nil {
+			return 0, errors.Wrap(err, "Error retrieving item of processed inquiry")
+		}
+		currency = item.Price.Currency
+
+		if item.IsSlotBooked() {
+			// single slot unless reservation end says otherwise
+			units = 1
+			if accepted.DateEnd != nil {
+				units = int(accepted.DateEnd.Sub(*accepted.DateReservation) / item.SlotLength())
+			}
+
+			dateEnd, err = item.SlotEnd(*accepted.DateReservation, units)
+			if err != nil || (accepted.DateEnd != nil && !dateEnd.Equal(*accepted.DateEnd)) {
+				if err == nil {
+					err = models.ErrSlotNotAligned
+				}
+				return 0, invalidSlotError(item.Id, err)
+			}
+		}
+
+		if unitId, err = reserveItem(ctx, tx, item, *accepted.DateReservation, dateEnd); err != nil {
+			return 0, err
+		}
+	}
+
+	itemPrice := accepted.ItemPrice.OrCurrency(currency)
+	totalPrice := accepted.TotalPrice.OrCurrency(currency)
+	if itemPrice.Currency != currency || totalPrice.Currency != currency {
+		return 0, models.ErrCurrencyMismatch
+	}
+	if totalPrice.Amount == 0 {
+		totalPrice = itemPrice.Times(int64(units))
+	}
+
+	// reservation without inquiry has no add-ons or discount
+	addonsTotal := models.NewMoney(0, currency)
+	tax, err := acceptedTax(ctx, tx, a.pricing, accepted, item, totalPrice, nil, addonsTotal)
+	if err != nil {
+		return 0, err
+	}
+
+	accepted.DateEnd = &dateEnd
+	accepted.ItemPrice = itemPrice
+	accepted.TotalPrice = totalPrice
+	accepted.AddonsTotal = addonsTotal
+	accepted.Addons = nil
+	accepted.Tax = tax
+	accepted.PromoCode = ""
+	accepted.Discount = models.NewMoney(0, currency)
+	accepted.Components = item.Components
+	accepted.UnitId = unitId
+
+	id, err := insertAccepted(ctx, tx, accepted)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Error commiting processed inquiry")
+	}
+
+	return id, nil
+}
+
+// reserveItem locks item and validates it is still free from start to end
+// (bundle is free when all of its components are). Returns unit assigned to
+// reservation. Bundles reserve capacity of components and get no unit,
+// units of their unit tracked components are set on item components instead
+func reserveItem(ctx context.Context, tx *sql.Tx, item *models.Item, start time.Time, end time.Time) (*int64, error) {
+	var err error
+	switch {
+	case item.IsSlotBooked():
+		err = checkSlotAvailability(ctx, tx, item, start, end)
+	case item.IsBundle():
+		err = checkBundleAvailability(ctx, tx, item, start, end)
+	default:
+		err = checkAvailability(ctx, tx, item.Id, 1, start, end)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if item.IsBundle() {
+		return nil, assignComponentUnits(ctx, tx, item, start, end)
+	}
+	return assignUnit(ctx, tx, item, start, end)
+}
+
+// insertAccepted saves resolved accepted reservation with its add-on, tax and
+// bundle component lines. Prices are final, total price includes add-ons.
+// Reservation of inquiry gets reference code of inquiry, others new one
+func insertAccepted(ctx context.Context, tx *sql.Tx, accepted *models.Accepted) (int64, error) {
+	// reference is never taken from request
+	accepted.Reference = ""
+	if accepted.InquiryId != 0 {
+		q := "SELECT reference FROM inquiry WHERE id = $1"
+		if err := tx.QueryRowContext(ctx, q, accepted.InquiryId).Scan(&accepted.Reference); err != nil {
+			return 0, errors.Wrap(err, "Error retrieving reference code of inquiry")
+		}
+	}
+
 	q := `INSERT INTO accepted 
 				(inquirer, inquirer_email, inquirer_phone, 
 					inquirer_comment, item_id, item_title, item_price,
-					notes, date_reservation, date_inquiry_created,
-					date_accepted)
+					total_price, notes, date_reservation, date_end,
+					date_inquiry_created, inquiry_id, addons_total, currency, promo_code, discount, unit_id, reference, date_accepted)
 			VALUES 
-				($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now() at time zone 'utc')
+				($1, $2, $3, $4, NULLIF($5, 0), $6, $7, $8, $9, $10, $11, $12, NULLIF($13, 0), $14, $15, NULLIF($16, ''), $17, $18, $19,
+					now() at time zone 'utc')
 			RETURNING id`
 
-	var id int64
-	err := db.QueryRowContext(ctx, q, accepted.Inquirer, accepted.InquirerEmail, accepted.InquirerPhone,
-		accepted.InquirerComment, accepted.ItemId, accepted.ItemTitle, accepted.ItemPrice,
-		accepted.Notes, accepted.DateReservation, accepted.DateInquiryCreated).Scan(&id)
+	id, err := insertWithReference(ctx, tx, acceptedReferenceIndex, &accepted.Reference, q,
+		accepted.Inquirer, accepted.InquirerEmail, accepted.InquirerPhone,
+		accepted.InquirerComment, accepted.ItemId, accepted.ItemTitle, accepted.ItemPrice.Amount,
+		accepted.TotalPrice.Amount, accepted.Notes, accepted.DateReservation, accepted.DateEnd, accepted.DateInquiryCreated,
+		accepted.InquiryId, accepted.AddonsTotal.Amount, accepted.TotalPrice.Currency, accepted.PromoCode,
+		accepted.Discount.Amount, accepted.UnitId)
 
 	if err != nil {
 		return 0, errors.Wrap(err, "Error processing inquiry to accepted inside DB")
 	}
 
+	if err := insertAddonLines(ctx, tx, acceptedAddonTable, acceptedAddonOwner, id, accepted.Addons); err != nil {
+		return 0, err
+	}
+
+	if err := insertTaxLines(ctx, tx, acceptedTaxTable, acceptedTaxOwner, id, accepted.Tax); err != nil {
+		return 0, err
+	}
+
+	if err := insertAcceptedComponents(ctx, tx, id, accepted.Components); err != nil {
+		return 0, err
+	}
+
+	if accepted.InquiryId != 0 {
+		if err := attachInquiryMessages(ctx, tx, accepted.InquiryId, id); err != nil {
+			return 0, err
+		}
+		if err := attachInquiryAccess(ctx, tx, accepted.InquiryId, id); err != nil {
+			return 0, err
+		}
+	}
+
 	return id, nil
 }
 
+// AssignUnit replaces unit assigned to accepted reservation. Unit must be
+// active unit of reserved item, free in period of reservation
+func (a *acceptedStoreSql) AssignUnit(ctx context.Context, id int64, unitId int64) error {
+	db := a.dbFactory.Connect()
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for AssignUnit in accepted store")
+	}
+	defer tx.Rollback()
+
+	item := &models.Item{}
+	var start, end time.Time
+	q := `SELECT i.id, i.booking_mode, a.date_reservation,
+				COALESCE(a.date_end, a.date_reservation + interval '1 day')
+			FROM accepted a
+				JOIN item i ON i.id = a.item_id
+			WHERE a.id = $1
+			FOR UPDATE OF a`
+	if err := tx.QueryRowContext(ctx, q, id).Scan(&item.Id, &item.BookingMode, &start, &end); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return errors.Wrap(err, "Error retrieving accepted for unit assignment")
+	}
+
+	// serializes with reservations of item being accepted
+	if _, err := lockItem(ctx, tx, item.Id); err != nil {
+		return err
+	}
+
+	var status string
+	q = "SELECT status FROM item_unit WHERE id = $1 AND item_id = $2"
+	if err := tx.QueryRowContext(ctx, q, unitId, item.Id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return errors.Wrap(err, "Error retrieving assigned unit")
+	}
+
+	var taken bool
+	q = `SELECT EXISTS (SELECT 1 FROM unit_booking a
+				WHERE a.unit_id = $1 AND a.accepted_id <> $4 AND ` + unitOverlap(item) + `)`
+	if err := tx.QueryRowContext(ctx, q, unitId, start.UTC(), end.UTC(), id).Scan(&taken); err != nil {
+		return errors.Wrap(err, "Error checking assigned unit reservations")
+	}
+	if taken || status != models.UnitActive {
+		return ErrUnitUnavailable
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE accepted SET unit_id = $2 WHERE id = $1", id, unitId); err != nil {
+		return errors.Wrap(err, "Error assigning unit to accepted")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting unit assignment")
+	}
+	return nil
+}
+
 func (a *acceptedStoreSql) Delete(ctx context.Context, id int64) error {
 	db := a.dbFactory.Connect()
 	defer db.Close()
@@ -104,3 +384,30 @@ func (a *acceptedStoreSql) Delete(ctx context.Context, id int64) error {
 	tx.Commit()
 	return nil
 }
+
+// returns tax breakdown of accepted reservation. Breakdown of inquiry is frozen
+// and reused as long as it covers the same gross amount, otherwise (price was
+// changed or there is no inquiry) it is computed by rates valid on reservation date
+func acceptedTax(ctx context.Context, db queryer, pricing services.PricingService, accepted *models.Accepted, item *models.Item,
+	reservation models.Money, addons []models.AddonLineItem, addonsTotal models.Money) (*models.TaxBreakdown, error) {
+	gross, err := reservation.Add(addonsTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	if accepted.InquiryId != 0 {
+		taxes, err := selectTaxLines(ctx, db, inquiryTaxTable, inquiryTaxOwner, []int64{accepted.InquiryId})
+		if err != nil {
+			return nil, err
+		}
+		if tax := taxes[accepted.InquiryId]; tax != nil && tax.Gross == gross {
+			return tax, nil
+		}
+	}
+
+	rates, err := selectTaxRates(ctx, db, item.TenantId)
+	if err != nil {
+		return nil, err
+	}
+	return pricing.QuoteTax(item, reservation, addons, rates, *accepted.DateReservation)
+}
@@ -0,0 +1,361 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+// returned when self-service access does not exist, expired or was revoked
+var ErrAccessInvalid = errors.New("Access link is invalid, expired or revoked")
+
+// returned when customer changes inquiry that was rejected, expired or cancelled
+var ErrReservationClosed = errors.New("Reservation is closed and can not be changed")
+
+// message recorded when customer asks for cancellation without reason
+const cancellationRequested = "Customer requested cancellation"
+
+func NewAccessStore(db db.DbFactory) AccessStore {
+	return &accessStoreSql{
+		db: db,
+	}
+}
+
+// AccessStore keeps self-service access of customers. Staff issues and revokes
+// access, customers use valid access (by id) to see and change their reservation
+type AccessStore interface {
+	IssueInquiryAccess(ctx context.Context, inquiryId int64, expiresAt time.Time) (*models.ReservationAccess, error)
+	IssueAcceptedAccess(ctx context.Context, acceptedId int64, expiresAt time.Time) (*models.ReservationAccess, error)
+	GetInquiryAccess(ctx context.Context, inquiryId int64) (models.ReservationAccesses, error)
+	GetAcceptedAccess(ctx context.Context, acceptedId int64) (models.ReservationAccesses, error)
+	RevokeInquiryAccess(ctx context.Context, inquiryId int64) error
+	RevokeAcceptedAccess(ctx context.Context, acceptedId int64) error
+	GetReservation(ctx context.Context, accessId int64, now time.Time) (*models.CustomerReservation, error)
+	UpdateContact(ctx context.Context, accessId int64, now time.Time, contact *models.CustomerContact) error
+	RequestCancellation(ctx context.Context, accessId int64, now time.Time, reason string) error
+}
+
+type accessStoreSql struct {
+	db db.DbFactory
+}
+
+// IssueInquiryAccess creates access to inquiry (and reservation accepted from it).
+// Missing inquiry is reported as sql.ErrNoRows
+func (a *accessStoreSql) IssueInquiryAccess(ctx context.Context, inquiryId int64, expiresAt time.Time) (*models.ReservationAccess, error) {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	access := &models.ReservationAccess{InquiryId: &inquiryId}
+	q := `SELECT COALESCE(inq.email, ''), (SELECT a.id FROM accepted a WHERE a.inquiry_id = inq.id ORDER BY a.id LIMIT 1)
+			FROM inquiry inq
+			WHERE inq.id = $1`
+	if err := myDb.QueryRowContext(ctx, q, inquiryId).Scan(&access.Email, &access.AcceptedId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "Error retrieving inquiry of access")
+	}
+
+	return access, insertAccess(ctx, myDb, access, expiresAt)
+}
+
+// IssueAcceptedAccess creates access to accepted reservation.
+// Missing reservation is reported as sql.ErrNoRows
+func (a *accessStoreSql) IssueAcceptedAccess(ctx context.Context, acceptedId int64, expiresAt time.Time) (*models.ReservationAccess, error) {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	access := &models.ReservationAccess{AcceptedId: &acceptedId}
+	q := "SELECT COALESCE(inquirer_email, ''), inquiry_id FROM accepted WHERE id = $1"
+	if err := myDb.QueryRowContext(ctx, q, acceptedId).Scan(&access.Email, &access.InquiryId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "Error retrieving accepted of access")
+	}
+
+	return access, insertAccess(ctx, myDb, access, expiresAt)
+}
+
+func (a *accessStoreSql) GetInquiryAccess(ctx context.Context, inquiryId int64) (models.ReservationAccesses, error) {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	if err := checkExists(ctx, myDb, "SELECT EXISTS (SELECT 1 FROM inquiry WHERE id = $1)", inquiryId); err != nil {
+		return nil, err
+	}
+	return selectAccess(ctx, myDb, "inquiry_id = $1", inquiryId)
+}
+
+func (a *accessStoreSql) GetAcceptedAccess(ctx context.Context, acceptedId int64) (models.ReservationAccesses, error) {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	if err := checkExists(ctx, myDb, "SELECT EXISTS (SELECT 1 FROM accepted WHERE id = $1)", acceptedId); err != nil {
+		return nil, err
+	}
+	return selectAccess(ctx, myDb, "accepted_id = $1", acceptedId)
+}
+
+// RevokeInquiryAccess revokes every access of inquiry that was not revoked yet
+func (a *accessStoreSql) RevokeInquiryAccess(ctx context.Context, inquiryId int64) error {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	if err := checkExists(ctx, myDb, "SELECT EXISTS (SELECT 1 FROM inquiry WHERE id = $1)", inquiryId); err != nil {
+		return err
+	}
+	return revokeAccess(ctx, myDb, "inquiry_id = $1", inquiryId)
+}
+
+// RevokeAcceptedAccess revokes every access of accepted reservation that was not revoked yet
+func (a *accessStoreSql) RevokeAcceptedAccess(ctx context.Context, acceptedId int64) error {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	if err := checkExists(ctx, myDb, "SELECT EXISTS (SELECT 1 FROM accepted WHERE id = $1)", acceptedId); err != nil {
+		return err
+	}
+	return revokeAccess(ctx, myDb, "accepted_id = $1", acceptedId)
+}
+
+// GetReservation returns reservation of access as seen by customer, accepted
+// reservation is returned once inquiry is accepted
+func (a *accessStoreSql) GetReservation(ctx context.Context, accessId int64, now time.Time) (*models.CustomerReservation, error) {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	inquiryId, acceptedId, err := resolveAccess(ctx, myDb, accessId, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var q string
+	var ownerId int64
+	var where string
+	if acceptedId != nil {
+		q = `SELECT reference, inquirer, COALESCE(inquirer_email, ''), COALESCE(inquirer_phone, ''), COALESCE(item_title, ''),
+					date_reservation, date_end, COALESCE(total_price, item_price, 0), addons_total, currency,
+					'` + models.InquiryAccepted + `', '', cancellation_requested_at
+				FROM accepted
+				WHERE id = $1`
+		ownerId, where = *acceptedId, "m.accepted_id = $1"
+	} else {
+		q = `SELECT reference, inquirer, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(item_title, ''),
+					date_reservation, date_end, COALESCE(total_price, 0), addons_total, currency,
+					status, COALESCE(status_note, ''), NULL::timestamp
+				FROM inquiry
+				WHERE id = $1`
+		ownerId, where = *inquiryId, "m.inquiry_id = $1"
+	}
+
+	reservation := &models.CustomerReservation{}
+	var currency string
+	err = myDb.QueryRowContext(ctx, q, ownerId).Scan(&reservation.Reference, &reservation.Inquirer, &reservation.Email,
+		&reservation.Phone, &reservation.ItemTitle, &reservation.DateReservation, &reservation.DateEnd,
+		&reservation.TotalPrice.Amount, &reservation.AddonsTotal.Amount, &currency, &reservation.Status,
+		&reservation.StatusNote, &reservation.CancellationRequestedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAccessInvalid
+		}
+		return nil, errors.Wrap(err, "Error retrieving reservation of access")
+	}
+	reservation.TotalPrice.Currency = currency
+	reservation.AddonsTotal.Currency = currency
+
+	// internal notes are not shown to customer
+	reservation.Messages, err = selectMessages(ctx, myDb, where+" AND m.kind <> '"+models.MessageNote+"'", ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// UpdateContact replaces email and phone of reservation. Inquiries that were
+// rejected, expired or cancelled are reported as ErrReservationClosed
+func (a *accessStoreSql) UpdateContact(ctx context.Context, accessId int64, now time.Time, contact *models.CustomerContact) error {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	tx, err := myDb.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for contact update")
+	}
+	defer tx.Rollback()
+
+	inquiryId, acceptedId, err := resolveAccess(ctx, tx, accessId, now)
+	if err != nil {
+		return err
+	}
+
+	if acceptedId != nil {
+		q := "UPDATE accepted SET inquirer_email = NULLIF($2, ''), inquirer_phone = NULLIF($3, '') WHERE id = $1"
+		if _, err := tx.ExecContext(ctx, q, *acceptedId, contact.Email, contact.Phone); err != nil {
+			return errors.Wrap(err, "Error updating contact of accepted")
+		}
+	}
+
+	if inquiryId != nil {
+		q := `UPDATE inquiry SET email = NULLIF($2, ''), phone = NULLIF($3, '')
+				WHERE id = $1 AND status IN ($4, $5)`
+		res, err := tx.ExecContext(ctx, q, *inquiryId, contact.Email, contact.Phone,
+			models.InquiryPending, models.InquiryAccepted)
+		if err != nil {
+			return errors.Wrap(err, "Error updating contact of inquiry")
+		}
+
+		if num, err := res.RowsAffected(); err != nil || num == 0 {
+			if err != nil {
+				return errors.Wrap(err, "Error retrieving rows affected")
+			}
+			if acceptedId == nil {
+				return ErrReservationClosed
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting contact update")
+	}
+	return nil
+}
+
+// RequestCancellation cancels pending inquiry of access. Cancellation of accepted
+// reservation is only recorded, with reason added to its messages, and is
+// decided by staff. Decided inquiries are reported as InquiryTransitionError
+func (a *accessStoreSql) RequestCancellation(ctx context.Context, accessId int64, now time.Time, reason string) error {
+	myDb := a.db.Connect()
+	defer myDb.Close()
+
+	tx, err := myDb.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for cancellation request")
+	}
+	defer tx.Rollback()
+
+	inquiryId, acceptedId, err := resolveAccess(ctx, tx, accessId, now)
+	if err != nil {
+		return err
+	}
+
+	if acceptedId == nil {
+		status, err := lockInquiry(ctx, tx, *inquiryId)
+		if err != nil {
+			return err
+		}
+		if err := transitionInquiry(ctx, tx, *inquiryId, status, models.InquiryCancelled, reason); err != nil {
+			return err
+		}
+	} else {
+		msg := &models.Message{
+			InquiryId:  inquiryId,
+			AcceptedId: acceptedId,
+			Kind:       models.MessageCustomer,
+			Body:       reason,
+		}
+		if msg.Body == "" {
+			msg.Body = cancellationRequested
+		}
+
+		q := `UPDATE accepted SET cancellation_requested_at = COALESCE(cancellation_requested_at, $2)
+				WHERE id = $1
+				RETURNING inquirer`
+		if err := tx.QueryRowContext(ctx, q, *acceptedId, now.UTC()).Scan(&msg.AuthorName); err != nil {
+			return errors.Wrap(err, "Error requesting cancellation of accepted")
+		}
+		if _, err := insertMessage(ctx, tx, msg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting cancellation request")
+	}
+	return nil
+}
+
+// returns inquiry and accepted reservation of access valid at now. Access that
+// is missing, expired or revoked is reported as ErrAccessInvalid
+func resolveAccess(ctx context.Context, db queryer, accessId int64, now time.Time) (*int64, *int64, error) {
+	q := `SELECT inquiry_id, accepted_id
+			FROM reservation_access
+			WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2`
+
+	var inquiryId, acceptedId *int64
+	if err := db.QueryRowContext(ctx, q, accessId, now.UTC()).Scan(&inquiryId, &acceptedId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrAccessInvalid
+		}
+		return nil, nil, errors.Wrap(err, "Error retrieving access")
+	}
+	return inquiryId, acceptedId, nil
+}
+
+// saves access, filling its id and dates
+func insertAccess(ctx context.Context, db queryer, access *models.ReservationAccess, expiresAt time.Time) error {
+	q := `INSERT INTO reservation_access (inquiry_id, accepted_id, expires_at, date_created)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id`
+
+	access.ExpiresAt = expiresAt.UTC()
+	access.DateCreated = time.Now().UTC()
+	err := db.QueryRowContext(ctx, q, access.InquiryId, access.AcceptedId, access.ExpiresAt,
+		access.DateCreated).Scan(&access.Id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error creating access")
+	}
+	return nil
+}
+
+// links access of inquiry to reservation accepted from it
+func attachInquiryAccess(ctx context.Context, db queryer, inquiryId int64, acceptedId int64) error {
+	q := "UPDATE reservation_access SET accepted_id = $2 WHERE inquiry_id = $1 AND accepted_id IS NULL"
+	if _, err := db.ExecContext(ctx, q, inquiryId, acceptedId); err != nil {
+		return errors.Wrap(err, "Error attaching inquiry access to accepted")
+	}
+	return nil
+}
+
+func revokeAccess(ctx context.Context, db queryer, where string, args ...interface{}) error {
+	q := "UPDATE reservation_access SET revoked_at = now() at time zone 'utc' WHERE revoked_at IS NULL AND " + where
+	if _, err := db.ExecContext(ctx, q, args...); err != nil {
+		return errors.Wrap(err, "Error revoking access")
+	}
+	return nil
+}
+
+// selects access matching condition from newest to oldest
+func selectAccess(ctx context.Context, db queryer, where string, args ...interface{}) (models.ReservationAccesses, error) {
+	q := `SELECT id, inquiry_id, accepted_id, expires_at, revoked_at, date_created
+			FROM reservation_access
+			WHERE ` + where + `
+			ORDER BY date_created DESC, id DESC`
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying access")
+	}
+	defer rows.Close()
+
+	accesses := models.ReservationAccesses{}
+	for rows.Next() {
+		var access models.ReservationAccess
+		err := rows.Scan(&access.Id, &access.InquiryId, &access.AcceptedId, &access.ExpiresAt, &access.RevokedAt,
+			&access.DateCreated)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning access")
+		}
+		accesses = append(accesses, access)
+	}
+
+	return accesses, rows.Err()
+}
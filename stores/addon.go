@@ -0,0 +1,208 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// tables holding add-on line item snapshots and their owner column
+const (
+	inquiryAddonTable  = "inquiry_addon"
+	inquiryAddonOwner  = "inquiry_id"
+	acceptedAddonTable = "accepted_addon"
+	acceptedAddonOwner = "accepted_id"
+)
+
+func (u *itemStoreSql) GetAddons(ctx context.Context, itemId int64) (models.ItemAddons, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	return selectAddons(ctx, myDb, itemId, true)
+}
+
+// CreateAddon saves add-on in item currency. Add-on priced in other
+// currency is rejected with ErrCurrencyMismatch
+func (u *itemStoreSql) CreateAddon(ctx context.Context, addon *models.ItemAddon) (int64, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	currency, err := addonCurrency(ctx, myDb, addon)
+	if err != nil {
+		return 0, err
+	}
+
+	q := `INSERT INTO item_addon (item_id, title, price, currency, charge, disabled, tax_class_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id`
+
+	var id int64
+	err = myDb.QueryRowContext(ctx, q, addon.ItemId, addon.Title, addon.Price.Amount, currency,
+		addon.Charge, addon.Disabled, addon.TaxClassId).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, errors.Wrap(err, "Error creating item add-on")
+	}
+
+	return id, nil
+}
+
+// UpdateAddon changes add-on for future reservations, line items
+// of existing inquiries keep their snapshot
+func (u *itemStoreSql) UpdateAddon(ctx context.Context, addon *models.ItemAddon) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	currency, err := addonCurrency(ctx, myDb, addon)
+	if err != nil {
+		return err
+	}
+
+	q := `UPDATE item_addon
+			SET title = $3, price = $4, currency = $5, charge = $6, disabled = $7, tax_class_id = $8
+			WHERE id = $1 AND item_id = $2`
+
+	res, err := myDb.ExecContext(ctx, q, addon.Id, addon.ItemId, addon.Title, addon.Price.Amount, currency,
+		addon.Charge, addon.Disabled, addon.TaxClassId)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error updating item add-on")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+func (u *itemStoreSql) DeleteAddon(ctx context.Context, itemId int64, addonId int64) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := "DELETE FROM item_addon WHERE id = $1 AND item_id = $2"
+	res, err := myDb.ExecContext(ctx, q, addonId, itemId)
+	if err != nil {
+		return errors.Wrap(err, "Error deleting item add-on")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// resolves currency of add-on from its item. Returns sql.ErrNoRows if item
+// does not exist and ErrCurrencyMismatch if add-on is priced in other currency
+func addonCurrency(ctx context.Context, db queryer, addon *models.ItemAddon) (string, error) {
+	var currency string
+	err := db.QueryRowContext(ctx, "SELECT currency FROM item WHERE id = $1", addon.ItemId).Scan(&currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", err
+		}
+		return "", errors.Wrap(err, "Error retrieving item currency")
+	}
+
+	if addon.Price.OrCurrency(currency).Currency != currency {
+		return "", models.ErrCurrencyMismatch
+	}
+	return currency, nil
+}
+
+// selects add-ons of item. Disabled add-ons are skipped unless withDisabled is set
+func selectAddons(ctx context.Context, db queryer, itemId int64, withDisabled bool) (models.ItemAddons, error) {
+	addons, err := selectAddonsOf(ctx, db, []int64{itemId}, withDisabled)
+	if err != nil {
+		return nil, err
+	}
+	return addons[itemId], nil
+}
+
+// selects add-ons of items grouped by item id, see selectAddons
+func selectAddonsOf(ctx context.Context, db queryer, itemIds []int64, withDisabled bool) (map[int64]models.ItemAddons, error) {
+	q := `SELECT id, item_id, title, price, currency, charge, disabled, tax_class_id
+			FROM item_addon
+			WHERE item_id = ANY($1) AND (NOT disabled OR $2)
+			ORDER BY id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(itemIds), withDisabled)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item add-ons")
+	}
+	defer rows.Close()
+
+	addons := map[int64]models.ItemAddons{}
+	for _, id := range itemIds {
+		addons[id] = models.ItemAddons{}
+	}
+	for rows.Next() {
+		var addon models.ItemAddon
+		err := rows.Scan(&addon.Id, &addon.ItemId, &addon.Title, &addon.Price.Amount, &addon.Price.Currency,
+			&addon.Charge, &addon.Disabled, &addon.TaxClassId)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning item add-on")
+		}
+		addons[addon.ItemId] = append(addons[addon.ItemId], addon)
+	}
+
+	return addons, rows.Err()
+}
+
+// saves add-on line items of owner (inquiry or accepted) into table
+func insertAddonLines(ctx context.Context, db queryer, table string, ownerColumn string, ownerId int64, lines []models.AddonLineItem) error {
+	q := `INSERT INTO ` + table + ` (` + ownerColumn + `, addon_id, title, price, total, currency, charge, quantity, tax_class_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	for _, line := range lines {
+		_, err := db.ExecContext(ctx, q, ownerId, line.AddonId, line.Title, line.Price.Amount, line.Total.Amount,
+			line.Price.Currency, line.Charge, line.Quantity, line.TaxClassId)
+		if err != nil {
+			return errors.Wrap(err, "Error saving add-on line item")
+		}
+	}
+	return nil
+}
+
+// selects add-on line items of owners (inquiries or accepted) from table grouped by owner id
+func selectAddonLines(ctx context.Context, db queryer, table string, ownerColumn string, ownerIds []int64) (map[int64][]models.AddonLineItem, error) {
+	q := `SELECT ` + ownerColumn + `, addon_id, title, price, total, currency, charge, quantity, tax_class_id
+			FROM ` + table + `
+			WHERE ` + ownerColumn + ` = ANY($1)
+			ORDER BY id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(ownerIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying add-on line items")
+	}
+	defer rows.Close()
+
+	lines := map[int64][]models.AddonLineItem{}
+	for rows.Next() {
+		var ownerId int64
+		var line models.AddonLineItem
+		err := rows.Scan(&ownerId, &line.AddonId, &line.Title, &line.Price.Amount, &line.Total.Amount,
+			&line.Price.Currency, &line.Charge, &line.Quantity, &line.TaxClassId)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning add-on line item")
+		}
+		line.Total.Currency = line.Price.Currency
+		lines[ownerId] = append(lines[ownerId], line)
+	}
+
+	return lines, rows.Err()
+}
@@ -0,0 +1,262 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+// booking error codes returned to client
+const (
+	BookingFullyBooked = "fully_booked"
+	BookingInvalidSlot = "invalid_slot"
+	BookingClosed      = "outside_schedule"
+	BookingBlackout    = "blackout"
+)
+
+// BookingError is returned when reservation for item can not be made
+// on requested dates or slots. Controllers use code, dates and slots to build response.
+type BookingError struct {
+	Code    string
+	Message string
+	ItemId  int64
+	Dates   []time.Time
+	Slots   []time.Time
+}
+
+func (b *BookingError) Error() string {
+	return b.Message
+}
+
+// checkAvailability locks item row (so concurrent reservations for same item
+// are serialized) and validates that item still has quantity units of free
+// capacity on every night of stay from check-in (from) to check-out (to)
+func checkAvailability(ctx context.Context, tx *sql.Tx, itemId int64, quantity int64, from time.Time, to time.Time) error {
+
+	capacity, err := lockItem(ctx, tx, itemId)
+	if err != nil {
+		return err
+	}
+
+	// accepted reservation occupies night if it starts on or before it and ends after it
+	q := `SELECT d.day
+			FROM generate_series($2::date, $3::date - 1, interval '1 day') AS d(day)
+				LEFT JOIN item_booking b ON (b.item_id = $1 AND
+					b.date_reservation::date <= d.day::date AND b.date_end::date > d.day::date)
+			GROUP BY d.day
+			HAVING COALESCE(SUM(b.quantity), 0) + $5 > $4
+			ORDER BY d.day`
+
+	rows, err := tx.QueryContext(ctx, q, itemId, from.UTC(), to.UTC(), capacity, quantity)
+	if err != nil {
+		return errors.Wrap(err, "Error counting accepted reservations")
+	}
+	defer rows.Close()
+
+	var booked []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return errors.Wrap(err, "Error scanning fully booked day")
+		}
+		booked = append(booked, day)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "Error reading fully booked days")
+	}
+
+	if len(booked) != 0 {
+		return &BookingError{
+			Code:    BookingFullyBooked,
+			Message: "Item is fully booked on requested dates",
+			ItemId:  itemId,
+			Dates:   booked,
+		}
+	}
+
+	return nil
+}
+
+// checkSlotAvailability locks item row and validates that item still has free
+// capacity in every slot from start to end
+func checkSlotAvailability(ctx context.Context, tx *sql.Tx, item *models.Item, start time.Time, end time.Time) error {
+
+	capacity, err := lockItem(ctx, tx, item.Id)
+	if err != nil {
+		return err
+	}
+
+	slots, err := countSlotBookings(ctx, tx, item.Id, start, end, item.SlotMinutes)
+	if err != nil {
+		return err
+	}
+
+	var booked []time.Time
+	for _, slot := range slots {
+		if slot.Booked >= capacity {
+			booked = append(booked, slot.Start)
+		}
+	}
+
+	if len(booked) != 0 {
+		return &BookingError{
+			Code:    BookingFullyBooked,
+			Message: "Item is fully booked in requested slots",
+			ItemId:  item.Id,
+			Slots:   booked,
+		}
+	}
+
+	return nil
+}
+
+// counts accepted reservations overlapping each slot (slotMinutes long)
+// between from and to. Capacity and price of slots are left empty
+func countSlotBookings(ctx context.Context, db queryer, itemId int64, from time.Time, to time.Time, slotMinutes int) (models.ItemSlots, error) {
+	q := `SELECT s.start, COALESCE(SUM(b.quantity), 0)
+			FROM generate_series($2::timestamp, $3::timestamp - make_interval(mins => $4), make_interval(mins => $4)) AS s(start)
+				LEFT JOIN item_booking b ON (b.item_id = $1 AND
+					b.date_reservation < s.start + make_interval(mins => $4) AND b.date_end > s.start)
+			GROUP BY s.start
+			ORDER BY s.start`
+
+	rows, err := db.QueryContext(ctx, q, itemId, from.UTC(), to.UTC(), slotMinutes)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error counting accepted reservations in slots")
+	}
+	defer rows.Close()
+
+	slotLength := time.Duration(slotMinutes) * time.Minute
+	slots := models.ItemSlots{}
+	for rows.Next() {
+		slot := models.ItemSlot{}
+		if err := rows.Scan(&slot.Start, &slot.Booked); err != nil {
+			return nil, errors.Wrap(err, "Error scanning slot bookings")
+		}
+		slot.Start = slot.Start.UTC()
+		slot.End = slot.Start.Add(slotLength)
+		slots = append(slots, slot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error reading slot bookings")
+	}
+
+	return slots, nil
+}
+
+// locks item row for the rest of transaction and returns its capacity
+func lockItem(ctx context.Context, tx *sql.Tx, itemId int64) (int64, error) {
+	var capacity int64
+	q := "SELECT " + itemCapacity("i") + " FROM item i WHERE i.id = $1 FOR UPDATE"
+	if err := tx.QueryRowContext(ctx, q, itemId).Scan(&capacity); err != nil {
+		return 0, errors.Wrap(err, "Error locking item for availability check")
+	}
+	return capacity, nil
+}
+
+// returns invalid slot booking error for item
+func invalidSlotError(itemId int64, err error) *BookingError {
+	return &BookingError{
+		Code:    BookingInvalidSlot,
+		Message: err.Error(),
+		ItemId:  itemId,
+	}
+}
+
+// checkCalendar validates that every night (or slot) from start to end is
+// inside weekly schedule of item and outside of its blackouts. Blackouts
+// are reported before schedule, listing their reasons
+func checkCalendar(ctx context.Context, db queryer, item *models.Item, start time.Time, end time.Time) error {
+	// whole nights for daily items
+	unit := 24 * time.Hour
+	if item.IsSlotBooked() {
+		unit = item.SlotLength()
+	} else {
+		start = start.UTC().Truncate(unit)
+		end = end.UTC().Truncate(unit)
+	}
+
+	schedule, err := selectSchedule(ctx, db, item.Id)
+	if err != nil {
+		return err
+	}
+
+	blackouts, err := selectBlackouts(ctx, db, item.Id, start, end)
+	if err != nil {
+		return err
+	}
+
+	var closed, blocked []time.Time
+	reasons := []string{}
+	for from := start.UTC(); from.Before(end); from = from.Add(unit) {
+		to := from.Add(unit)
+
+		hit := false
+		for _, b := range blackouts {
+			if b.Overlaps(from, to) {
+				hit = true
+				if b.Reason != "" && !contains(reasons, b.Reason) {
+					reasons = append(reasons, b.Reason)
+				}
+			}
+		}
+
+		switch {
+		case hit:
+			blocked = append(blocked, from)
+		case item.IsSlotBooked() && !schedule.Covers(from, to):
+			closed = append(closed, from)
+		case !item.IsSlotBooked() && !schedule.OpenOn(from):
+			closed = append(closed, from)
+		}
+	}
+
+	if len(blocked) != 0 {
+		msg := "Item is unavailable in requested period"
+		if len(reasons) != 0 {
+			msg += " (" + strings.Join(reasons, ", ") + ")"
+		}
+		return calendarError(item, BookingBlackout, msg, blocked)
+	}
+
+	if len(closed) != 0 {
+		return calendarError(item, BookingClosed, "Item is closed in requested period", closed)
+	}
+
+	return nil
+}
+
+// builds booking error listing nights (daily items) or slots (slot items)
+func calendarError(item *models.Item, code string, msg string, times []time.Time) *BookingError {
+	be := &BookingError{
+		Code:    code,
+		Message: msg,
+		ItemId:  item.Id,
+	}
+	if item.IsSlotBooked() {
+		be.Slots = times
+	} else {
+		be.Dates = times
+	}
+	return be
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// number of nights between check-in and check-out
+func nights(from time.Time, to time.Time) int {
+	checkIn := from.UTC().Truncate(24 * time.Hour)
+	checkOut := to.UTC().Truncate(24 * time.Hour)
+	return int(checkOut.Sub(checkIn).Hours() / 24)
+}
@@ -0,0 +1,181 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// owner columns of booking rules
+const (
+	itemRuleOwner   = "item_id"
+	tenantRuleOwner = "tenant_id"
+)
+
+func NewBookingRuleStore(db db.DbFactory) BookingRuleStore {
+	return &bookingRuleStoreSql{
+		db: db,
+	}
+}
+
+type BookingRuleStore interface {
+	GetItemRules(ctx context.Context, itemId int64) (*models.BookingRules, error)
+	SetItemRules(ctx context.Context, itemId int64, rules *models.BookingRules) error
+	GetTenantRules(ctx context.Context, tenantId int64) (*models.BookingRules, error)
+	SetTenantRules(ctx context.Context, tenantId int64, rules *models.BookingRules) error
+}
+
+type bookingRuleStoreSql struct {
+	db db.DbFactory
+}
+
+func (b *bookingRuleStoreSql) GetItemRules(ctx context.Context, itemId int64) (*models.BookingRules, error) {
+	myDb := b.db.Connect()
+	defer myDb.Close()
+
+	return selectBookingRules(ctx, myDb, itemRuleOwner, itemId)
+}
+
+func (b *bookingRuleStoreSql) SetItemRules(ctx context.Context, itemId int64, rules *models.BookingRules) error {
+	myDb := b.db.Connect()
+	defer myDb.Close()
+
+	return saveBookingRules(ctx, myDb, itemRuleOwner, itemId, rules)
+}
+
+func (b *bookingRuleStoreSql) GetTenantRules(ctx context.Context, tenantId int64) (*models.BookingRules, error) {
+	myDb := b.db.Connect()
+	defer myDb.Close()
+
+	return selectBookingRules(ctx, myDb, tenantRuleOwner, tenantId)
+}
+
+func (b *bookingRuleStoreSql) SetTenantRules(ctx context.Context, tenantId int64, rules *models.BookingRules) error {
+	myDb := b.db.Connect()
+	defer myDb.Close()
+
+	return saveBookingRules(ctx, myDb, tenantRuleOwner, tenantId, rules)
+}
+
+// selects booking rules of owner (item or tenant). Owner without rules has empty rules
+func selectBookingRules(ctx context.Context, db queryer, ownerColumn string, ownerId int64) (*models.BookingRules, error) {
+	q := `SELECT min_notice_hours, max_advance_days, min_stay, max_stay, arrival_weekdays
+			FROM booking_rule
+			WHERE ` + ownerColumn + ` = $1`
+
+	rules := &models.BookingRules{}
+	var weekdays pq.Int64Array
+	err := db.QueryRowContext(ctx, q, ownerId).Scan(&rules.MinNoticeHours, &rules.MaxAdvanceDays,
+		&rules.MinStay, &rules.MaxStay, &weekdays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return rules, nil
+		}
+		return nil, errors.Wrap(err, "Error retrieving booking rules")
+	}
+
+	for _, day := range weekdays {
+		rules.ArrivalWeekdays = append(rules.ArrivalWeekdays, int(day))
+	}
+	return rules, nil
+}
+
+// replaces booking rules of owner (item or tenant). Missing owner is reported as sql.ErrNoRows
+func saveBookingRules(ctx context.Context, db queryer, ownerColumn string, ownerId int64, rules *models.BookingRules) error {
+	var weekdays pq.Int64Array
+	for _, day := range rules.ArrivalWeekdays {
+		weekdays = append(weekdays, int64(day))
+	}
+
+	q := `INSERT INTO booking_rule (` + ownerColumn + `, min_notice_hours, max_advance_days, min_stay, max_stay, arrival_weekdays)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (` + ownerColumn + `) DO UPDATE SET
+				min_notice_hours = EXCLUDED.min_notice_hours,
+				max_advance_days = EXCLUDED.max_advance_days,
+				min_stay = EXCLUDED.min_stay,
+				max_stay = EXCLUDED.max_stay,
+				arrival_weekdays = EXCLUDED.arrival_weekdays`
+
+	_, err := db.ExecContext(ctx, q, ownerId, rules.MinNoticeHours, rules.MaxAdvanceDays,
+		rules.MinStay, rules.MaxStay, weekdays)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error saving booking rules")
+	}
+	return nil
+}
+
+// selects booking rules of item, rules missing on item are taken from its tenant
+func selectItemBookingRules(ctx context.Context, db queryer, item *models.Item) (*models.BookingRules, error) {
+	rules, err := selectItemBookingRulesOf(ctx, db, models.Items{*item})
+	if err != nil {
+		return nil, err
+	}
+	return rules[item.Id], nil
+}
+
+// selects booking rules of items grouped by item id, see selectItemBookingRules
+func selectItemBookingRulesOf(ctx context.Context, db queryer, items models.Items) (map[int64]*models.BookingRules, error) {
+	itemIds := []int64{}
+	tenantIds := []int64{}
+	for _, item := range items {
+		itemIds = append(itemIds, item.Id)
+		if item.TenantId != nil {
+			tenantIds = append(tenantIds, *item.TenantId)
+		}
+	}
+
+	q := `SELECT item_id, tenant_id, min_notice_hours, max_advance_days, min_stay, max_stay, arrival_weekdays
+			FROM booking_rule
+			WHERE item_id = ANY($1) OR tenant_id = ANY($2)`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(itemIds), pq.Array(tenantIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error retrieving booking rules")
+	}
+	defer rows.Close()
+
+	itemRules := map[int64]*models.BookingRules{}
+	tenantRules := map[int64]*models.BookingRules{}
+	for rows.Next() {
+		var itemId, tenantId sql.NullInt64
+		var weekdays pq.Int64Array
+		rules := &models.BookingRules{}
+		err := rows.Scan(&itemId, &tenantId, &rules.MinNoticeHours, &rules.MaxAdvanceDays,
+			&rules.MinStay, &rules.MaxStay, &weekdays)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning booking rules")
+		}
+
+		for _, day := range weekdays {
+			rules.ArrivalWeekdays = append(rules.ArrivalWeekdays, int(day))
+		}
+		if itemId.Valid {
+			itemRules[itemId.Int64] = rules
+		} else {
+			tenantRules[tenantId.Int64] = rules
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error reading booking rules")
+	}
+
+	found := map[int64]*models.BookingRules{}
+	for _, item := range items {
+		rules, ok := itemRules[item.Id]
+		if !ok {
+			rules = &models.BookingRules{}
+		}
+		if item.TenantId != nil {
+			rules = rules.Or(tenantRules[*item.TenantId])
+		}
+		found[item.Id] = rules
+	}
+	return found, nil
+}
@@ -0,0 +1,231 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// returned when bundle components are not distinct daily items or bundle is nested
+var ErrInvalidBundle = errors.New("Bundle must be daily item made of distinct daily items that are not bundles")
+
+func (u *itemStoreSql) GetComponents(ctx context.Context, bundleId int64) (models.BundleComponents, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	return selectComponents(ctx, myDb, bundleId)
+}
+
+// SetComponents replaces components of bundle. Empty components turn bundle
+// back into plain item. Missing bundle or component is reported as sql.ErrNoRows
+func (u *itemStoreSql) SetComponents(ctx context.Context, bundleId int64, components models.BundleComponents) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	tx, err := myDb.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for bundle components update")
+	}
+	defer tx.Rollback()
+
+	var bookingMode string
+	var isComponent bool
+	q := `SELECT booking_mode, EXISTS (SELECT 1 FROM item_bundle_component WHERE item_id = $1)
+			FROM item
+			WHERE id = $1
+			FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, q, bundleId).Scan(&bookingMode, &isComponent); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return errors.Wrap(err, "Error locking bundle item")
+	}
+	if len(components) != 0 && (bookingMode != models.BookingModeDaily || isComponent) {
+		return ErrInvalidBundle
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM item_bundle_component WHERE bundle_id = $1", bundleId); err != nil {
+		return errors.Wrap(err, "Error deleting bundle components")
+	}
+
+	q = "INSERT INTO item_bundle_component (bundle_id, item_id, quantity) VALUES ($1, $2, $3)"
+	for _, c := range components {
+		if _, err := tx.ExecContext(ctx, q, bundleId, c.ItemId, c.Quantity); err != nil {
+			if isForeignKeyViolation(err) {
+				return sql.ErrNoRows
+			}
+			if isUniqueViolation(err) || isCheckViolation(err) {
+				return errors.Wrap(ErrInvalidBundle, err.Error())
+			}
+			return errors.Wrap(err, "Error creating bundle component")
+		}
+	}
+
+	// components are booked by night and can not be bundles themselves
+	var invalid bool
+	q = `SELECT EXISTS (SELECT 1
+				FROM item_bundle_component c
+					JOIN item i ON i.id = c.item_id
+				WHERE c.bundle_id = $1 AND (i.booking_mode <> $2 OR
+					EXISTS (SELECT 1 FROM item_bundle_component n WHERE n.bundle_id = c.item_id)))`
+	if err := tx.QueryRowContext(ctx, q, bundleId, models.BookingModeDaily).Scan(&invalid); err != nil {
+		return errors.Wrap(err, "Error checking bundle components")
+	}
+	if invalid {
+		return ErrInvalidBundle
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting bundle components")
+	}
+	return nil
+}
+
+// selects components of bundle ordered by item id. Plain items have none
+func selectComponents(ctx context.Context, db queryer, bundleId int64) (models.BundleComponents, error) {
+	components, err := selectComponentsOf(ctx, db, []int64{bundleId})
+	if err != nil {
+		return nil, err
+	}
+	return components[bundleId], nil
+}
+
+// selects components of bundles grouped by bundle id, see selectComponents
+func selectComponentsOf(ctx context.Context, db queryer, bundleIds []int64) (map[int64]models.BundleComponents, error) {
+	q := `SELECT c.bundle_id, c.item_id, c.quantity, i.title
+			FROM item_bundle_component c
+				JOIN item i ON i.id = c.item_id
+			WHERE c.bundle_id = ANY($1)
+			ORDER BY c.item_id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(bundleIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying bundle components")
+	}
+	defer rows.Close()
+
+	components := map[int64]models.BundleComponents{}
+	for _, id := range bundleIds {
+		components[id] = models.BundleComponents{}
+	}
+	for rows.Next() {
+		var bundleId int64
+		var c models.BundleComponent
+		if err := rows.Scan(&bundleId, &c.ItemId, &c.Quantity, &c.Title); err != nil {
+			return nil, errors.Wrap(err, "Error scanning bundle component")
+		}
+		components[bundleId] = append(components[bundleId], c)
+	}
+
+	return components, rows.Err()
+}
+
+// checkBundleCalendar validates that no component of bundle is archived and
+// that every component is open and outside of blackouts from start to end
+func checkBundleCalendar(ctx context.Context, db queryer, bundle *models.Item, start time.Time, end time.Time) error {
+	var archived bool
+	q := `SELECT EXISTS (SELECT 1
+				FROM item_bundle_component c
+					JOIN item i ON i.id = c.item_id
+				WHERE c.bundle_id = $1 AND i.archived_at IS NOT NULL)`
+	if err := db.QueryRowContext(ctx, q, bundle.Id).Scan(&archived); err != nil {
+		return errors.Wrap(err, "Error checking archived bundle components")
+	}
+	if archived {
+		return ErrItemArchived
+	}
+
+	for _, c := range bundle.Components {
+		component := &models.Item{Id: c.ItemId, BookingMode: models.BookingModeDaily}
+		if err := checkCalendar(ctx, db, component, start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBundleAvailability locks components of bundle and validates that each
+// of them has free capacity for its quantity on every night of stay. Components
+// are locked in id order, so reservations of bundles sharing items do not deadlock
+func checkBundleAvailability(ctx context.Context, tx *sql.Tx, bundle *models.Item, from time.Time, to time.Time) error {
+	for _, c := range bundle.Components {
+		if err := checkAvailability(ctx, tx, c.ItemId, c.Quantity, from, to); err != nil {
+			if be, ok := err.(*BookingError); ok && c.Title != nil {
+				be.Message = "Bundle component " + *c.Title + " is fully booked on requested dates"
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// assignComponentUnits assigns units of unit tracked components of locked
+// bundle, as many as component quantity. Units are set on bundle components
+func assignComponentUnits(ctx context.Context, tx *sql.Tx, bundle *models.Item, start time.Time, end time.Time) error {
+	for idx := range bundle.Components {
+		c := &bundle.Components[idx]
+		component := &models.Item{Id: c.ItemId, BookingMode: models.BookingModeDaily}
+
+		units, err := assignUnits(ctx, tx, component, c.Quantity, start, end)
+		if err != nil {
+			if be, ok := err.(*BookingError); ok && c.Title != nil {
+				be.Message = "No unit of bundle component " + *c.Title + " is free on requested dates"
+			}
+			return err
+		}
+		c.UnitIds = units
+	}
+	return nil
+}
+
+// saves components reserved by accepted bundle with their assigned units
+func insertAcceptedComponents(ctx context.Context, tx *sql.Tx, acceptedId int64, components models.BundleComponents) error {
+	q := `INSERT INTO accepted_component (accepted_id, item_id, item_title, quantity)
+			VALUES ($1, $2, $3, $4)`
+	qUnits := `INSERT INTO accepted_component_unit (accepted_id, item_id, unit_id)
+			SELECT $1, $2, unnest($3::bigint[])`
+	for _, c := range components {
+		if _, err := tx.ExecContext(ctx, q, acceptedId, c.ItemId, c.Title, c.Quantity); err != nil {
+			return errors.Wrap(err, "Error saving accepted bundle component")
+		}
+		if len(c.UnitIds) == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, qUnits, acceptedId, c.ItemId, pq.Array(c.UnitIds)); err != nil {
+			return errors.Wrap(err, "Error saving units of accepted bundle component")
+		}
+	}
+	return nil
+}
+
+// selects components reserved by accepted bundles (with their units) by accepted id
+func selectAcceptedComponents(ctx context.Context, db queryer, acceptedIds []int64) (map[int64]models.BundleComponents, error) {
+	q := `SELECT c.accepted_id, c.item_id, c.quantity, c.item_title,
+				ARRAY(SELECT cu.unit_id FROM accepted_component_unit cu
+					WHERE cu.accepted_id = c.accepted_id AND cu.item_id = c.item_id ORDER BY cu.unit_id)
+			FROM accepted_component c
+			WHERE c.accepted_id = ANY($1)
+			ORDER BY c.accepted_id, c.item_id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(acceptedIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying accepted bundle components")
+	}
+	defer rows.Close()
+
+	components := map[int64]models.BundleComponents{}
+	for rows.Next() {
+		var acceptedId int64
+		var c models.BundleComponent
+		if err := rows.Scan(&acceptedId, &c.ItemId, &c.Quantity, &c.Title, pq.Array(&c.UnitIds)); err != nil {
+			return nil, errors.Wrap(err, "Error scanning accepted bundle component")
+		}
+		components[acceptedId] = append(components[acceptedId], c)
+	}
+
+	return components, rows.Err()
+}
@@ -0,0 +1,132 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+// returned when category would become its own ancestor
+var ErrCategoryCycle = errors.New("Category can not be moved under itself or its subcategory")
+
+func NewCategoryStore(db db.DbFactory) CategoryStore {
+	return &categoryStoreSql{
+		db: db,
+	}
+}
+
+type CategoryStore interface {
+	GetAll(ctx context.Context) (models.Categories, error)
+	Create(ctx context.Context, category *models.Category) (int64, error)
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type categoryStoreSql struct {
+	db db.DbFactory
+}
+
+func (c *categoryStoreSql) GetAll(ctx context.Context) (models.Categories, error) {
+	myDb := c.db.Connect()
+	defer myDb.Close()
+
+	q := "SELECT id, parent_id, title FROM category ORDER BY title, id"
+	rows, err := myDb.QueryContext(ctx, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying categories")
+	}
+	defer rows.Close()
+
+	categories := models.Categories{}
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.Id, &category.ParentId, &category.Title); err != nil {
+			return nil, errors.Wrap(err, "Error scanning category")
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (c *categoryStoreSql) Create(ctx context.Context, category *models.Category) (int64, error) {
+	myDb := c.db.Connect()
+	defer myDb.Close()
+
+	var id int64
+	q := "INSERT INTO category (parent_id, title) VALUES ($1, $2) RETURNING id"
+	err := myDb.QueryRowContext(ctx, q, category.ParentId, category.Title).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, errors.Wrap(err, "Error creating category")
+	}
+
+	return id, nil
+}
+
+func (c *categoryStoreSql) Update(ctx context.Context, category *models.Category) error {
+	myDb := c.db.Connect()
+	defer myDb.Close()
+
+	if category.ParentId != nil {
+		// new parent must not be category itself or one of its descendants
+		q := `WITH RECURSIVE subtree AS (
+					SELECT id FROM category WHERE id = $1
+					UNION
+					SELECT c.id FROM category c JOIN subtree s ON (c.parent_id = s.id)
+				)
+				SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)`
+
+		var cycle bool
+		if err := myDb.QueryRowContext(ctx, q, category.Id, *category.ParentId).Scan(&cycle); err != nil {
+			return errors.Wrap(err, "Error checking category tree")
+		}
+		if cycle {
+			return ErrCategoryCycle
+		}
+	}
+
+	q := "UPDATE category SET parent_id = $2, title = $3 WHERE id = $1"
+	res, err := myDb.ExecContext(ctx, q, category.Id, category.ParentId, category.Title)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error updating category")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// Delete removes category. Its subcategories become top level
+// categories and its items stay without category
+func (c *categoryStoreSql) Delete(ctx context.Context, id int64) error {
+	myDb := c.db.Connect()
+	defer myDb.Close()
+
+	res, err := myDb.ExecContext(ctx, "DELETE FROM category WHERE id = $1", id)
+	if err != nil {
+		return errors.Wrap(err, "Error deleting category")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
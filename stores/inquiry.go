@@ -3,39 +3,57 @@ package stores
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/alesbrelih/go-reservation-api/db"
 	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
 	"github.com/pkg/errors"
 )
 
-func NewInquiryStore(dbFactory db.DbFactory) InquiryStore {
+func NewInquiryStore(dbFactory db.DbFactory, pricing services.PricingService) InquiryStore {
 	return &inquiryStoreSql{
 		dbFactory: dbFactory,
+		pricing:   pricing,
 	}
 }
 
 type InquiryStore interface {
-	GetAll(ctx context.Context) (models.Inquiries, error)
-	Create(ctx context.Context, inquiry *models.InquiryCreate) error
+	GetAll(ctx context.Context, status string) (models.Inquiries, error)
+	Create(ctx context.Context, inquiry *models.InquiryCreate) (*models.InquiryCreated, error)
+	Accept(ctx context.Context, id int64, notes string) (int64, error)
+	Reject(ctx context.Context, id int64, reason string) error
+	Expire(ctx context.Context, createdBefore time.Time, day time.Time, now time.Time) (models.Inquiries, error)
 	Delete(ctx context.Context, id int64) error
 }
 
 type inquiryStoreSql struct {
 	dbFactory db.DbFactory
+	pricing   services.PricingService
 }
 
-func (i *inquiryStoreSql) GetAll(ctx context.Context) (models.Inquiries, error) {
+// GetAll returns inquiries, only those in status when it is given
+func (i *inquiryStoreSql) GetAll(ctx context.Context, status string) (models.Inquiries, error) {
 	db := i.dbFactory.Connect()
 	defer db.Close()
 
-	q := `SELECT inq.id, inq.inquirer, inq.email, inq.phone,
-				inq.date_reservation, inq.date_created, inq.comment,
-				i.id, i.title, i.price
+	return selectInquiries(ctx, db, "$1 = '' OR inq.status = $1", status)
+}
+
+// selects inquiries matching condition (on inquiry aliased as inq) from newest
+// to oldest, with their add-on lines and tax breakdowns
+func selectInquiries(ctx context.Context, db queryer, where string, args ...interface{}) (models.Inquiries, error) {
+	q := `SELECT inq.id, inq.reference, inq.inquirer, inq.email, inq.phone,
+				inq.date_reservation, inq.date_end, inq.date_created, COALESCE(inq.comment, ''),
+				COALESCE(inq.total_price, 0), inq.addons_total, inq.currency, COALESCE(inq.promo_code, ''), inq.discount,
+				inq.status, inq.status_changed_at, COALESCE(inq.status_note, ''),
+				i.id, i.title, i.price, i.currency
 			FROM inquiry inq 
-				LEFT JOIN item i ON (i.id = inq.item_id)`
+				LEFT JOIN item i ON (i.id = inq.item_id)
+			WHERE ` + where + `
+			ORDER BY inq.date_created DESC`
 
-	rows, err := db.QueryContext(ctx, q)
+	rows, err := db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -45,70 +63,278 @@ func (i *inquiryStoreSql) GetAll(ctx context.Context) (models.Inquiries, error)
 	inquiries := []models.Inquiry{}
 	for rows.Next() {
 		inquiry := models.Inquiry{Item: models.Item{}}
-		err = rows.Scan(&inquiry.Id, &inquiry.Inquirer,
-			&inquiry.Email, &inquiry.Phone, &inquiry.DateReservation,
-			&inquiry.DateCreated, &inquiry.Comment, &inquiry.Item.Id,
-			&inquiry.Item.Title, &inquiry.Item.Price,
+		var currency string
+		err = rows.Scan(&inquiry.Id, &inquiry.Reference, &inquiry.Inquirer,
+			&inquiry.Email, &inquiry.Phone, &inquiry.DateReservation, &inquiry.DateEnd,
+			&inquiry.DateCreated, &inquiry.Comment, &inquiry.TotalPrice.Amount, &inquiry.AddonsTotal.Amount,
+			&currency, &inquiry.PromoCode, &inquiry.Discount.Amount,
+			&inquiry.Status, &inquiry.StatusChangedAt, &inquiry.StatusNote, &inquiry.Item.Id, &inquiry.Item.Title, &inquiry.Item.Price.Amount, &inquiry.Item.Price.Currency,
 		)
 
 		if err != nil {
 			return nil, err
 		}
+		inquiry.TotalPrice.Currency = currency
+		inquiry.AddonsTotal.Currency = currency
+		inquiry.Discount.Currency = currency
 
 		inquiries = append(inquiries, inquiry)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(inquiries))
+	for _, inquiry := range inquiries {
+		ids = append(ids, inquiry.Id)
+	}
+
+	addons, err := selectAddonLines(ctx, db, inquiryAddonTable, inquiryAddonOwner, ids)
+	if err != nil {
+		return nil, err
+	}
+	taxes, err := selectTaxLines(ctx, db, inquiryTaxTable, inquiryTaxOwner, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range inquiries {
+		inquiries[idx].Addons = addons[inquiries[idx].Id]
+		inquiries[idx].Tax = taxes[inquiries[idx].Id]
+	}
+
 	return inquiries, nil
 }
 
-func (i *inquiryStoreSql) Create(ctx context.Context, inquiry *models.InquiryCreate) error {
+func (i *inquiryStoreSql) Create(ctx context.Context, inquiry *models.InquiryCreate) (*models.InquiryCreated, error) {
 	db := i.dbFactory.Connect()
 	defer db.Close()
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return errors.Wrap(err, "Could not initialize Create inqiry transaction")
+		return nil, errors.Wrap(err, "Could not initialize Create inqiry transaction")
+	}
+
+	item, err := selectItem(ctx, tx, inquiry.ItemId)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Wrap(err, "Error retrieving item on inquiry create")
+	}
+	if item.ArchivedAt != nil {
+		tx.Rollback()
+		return nil, ErrItemArchived
+	}
+
+	from, to, price, total, err := i.priceReservation(item, inquiry)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := checkBookingRules(ctx, tx, item, inquiry, from, to); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := checkCalendar(ctx, tx, item, from, to); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// bundle is available when all of its components are
+	switch {
+	case item.IsBundle():
+		if err = checkBundleCalendar(ctx, tx, item, from, to); err == nil {
+			err = checkBundleAvailability(ctx, tx, item, from, to)
+		}
+	case item.IsSlotBooked():
+		err = checkSlotAvailability(ctx, tx, item, from, to)
+	default:
+		err = checkAvailability(ctx, tx, item.Id, 1, from, to)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
-	//TODO: validate when inserting item that dateprices dont overlap!!
-	// AND THEY NEED TO BE REQUIRE
+	addons, addonsTotal, err := i.pricing.QuoteAddons(item, inquiry.Addons)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	item := &models.Item{}
-	q := `SELECT i.id, i.title, COALESCE(ip.price, i.price) 
-			FROM item i
-				LEFT JOIN item_date_range_price ip ON (ip.item_id = i.id AND 
-					ip.date_from <= now() at time zone 'utc' AND ip.date_to >= now() at time zone 'utc')
-			WHERE i.id = $1`
+	// promo code discounts reservation price, add-ons are not discounted
+	var promo *models.PromoCode
+	discount := models.NewMoney(0, item.Price.Currency)
+	if inquiry.PromoCode != "" {
+		promo, err = lockPromoCode(ctx, tx, inquiry.PromoCode, item, inquiry.Email, inquiry.Phone, time.Now().UTC())
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		discount = i.pricing.QuoteDiscount(promo, total)
+		total = models.NewMoney(total.Amount-discount.Amount, total.Currency)
+	}
 
-	// SELECT CORRECT PRICE
-	if err := tx.QueryRowContext(ctx, q, inquiry.ItemId).Scan(&item.Id, &item.Title, &item.Price); err != nil {
-		return errors.Wrap(err, "Error retrieving item on inquiry create")
+	// tax is computed by rates valid on reservation start
+	rates, err := selectTaxRates(ctx, tx, item.TenantId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
-	// get item that belongs to
+	tax, err := i.pricing.QuoteTax(item, total, addons, rates, from)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	q = `INSERT INTO inquiry 
-		(inquirer,email,phone,item_id, item_title, item_price, date_reservation,date_created)
+	if total, err = total.Add(addonsTotal); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var promoCode string
+	if promo != nil {
+		promoCode = promo.Code
+	}
+
+	// item price is price of first night (or slot), total is sum of all of them (less discount)
+	// and add-ons. All prices are in item currency
+	q := `INSERT INTO inquiry 
+		(inquirer,email,phone,item_id, item_title, item_price, total_price, addons_total, currency,
+			date_reservation, date_end, promo_code, discount, comment, reference, date_created)
 		VALUES
-		($1, $2, $3, $4, $5, $6, $7, now() at time zone 'utc')`
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), $13, NULLIF($14, ''), $15, now() at time zone 'utc')
+		RETURNING id`
 
-	_, err = tx.ExecContext(ctx, q, inquiry.Inquirer, inquiry.Email,
-		inquiry.Phone, item.Id, item.Title, item.Price, inquiry.Date)
+	var reference string
+	id, err := insertWithReference(ctx, tx, inquiryReferenceIndex, &reference, q, inquiry.Inquirer, inquiry.Email,
+		inquiry.Phone, item.Id, item.Title, price.Amount, total.Amount, addonsTotal.Amount, item.Price.Currency,
+		from, to, promoCode, discount.Amount, inquiry.Comment)
 	if err != nil {
 		tx.Rollback()
-		return errors.Wrap(err, "Error creating new inquiry")
+		return nil, errors.Wrap(err, "Error creating new inquiry")
 	}
 
-	tx.Commit()
-	return nil
+	// comment starts message thread of inquiry
+	if inquiry.Comment != "" {
+		msg := &models.Message{
+			InquiryId:  &id,
+			Kind:       models.MessageCustomer,
+			AuthorName: inquiry.Inquirer,
+			Body:       inquiry.Comment,
+		}
+		if _, err := insertMessage(ctx, tx, msg); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := insertAddonLines(ctx, tx, inquiryAddonTable, inquiryAddonOwner, id, addons); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := insertTaxLines(ctx, tx, inquiryTaxTable, inquiryTaxOwner, id, tax); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if promo != nil {
+		if err := insertPromoRedemption(ctx, tx, promo.Id, id, discount); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "Error commiting new inquiry")
+	}
+	return &models.InquiryCreated{Id: id, Reference: reference}, nil
 }
 
+// resolves reservation range of inquiry depending on item booking mode and
+// prices it for reserved nights or slots, not for today. Returns start, end,
+// price of first night (slot) and total price
+func (i *inquiryStoreSql) priceReservation(item *models.Item, inquiry *models.InquiryCreate) (time.Time, time.Time, models.Money, models.Money, error) {
+	if item.IsSlotBooked() {
+		if !inquiry.IsSlotReservation() {
+			return time.Time{}, time.Time{}, models.Money{}, models.Money{}, invalidSlotError(item.Id, errors.New("Item is booked by time slots, slot is required"))
+		}
+
+		if _, err := item.SlotEnd(*inquiry.Slot, inquiry.SlotCount()); err != nil {
+			return time.Time{}, time.Time{}, models.Money{}, models.Money{}, invalidSlotError(item.Id, err)
+		}
+
+		quote, err := i.pricing.QuoteSlots(item, *inquiry.Slot, inquiry.SlotCount())
+		if err != nil {
+			return time.Time{}, time.Time{}, models.Money{}, models.Money{}, errors.Wrap(err, "Error resolving item slot price on inquiry create")
+		}
+		return quote.Start, quote.End, quote.Slots[0].Price, quote.Total, nil
+	}
+
+	if inquiry.IsSlotReservation() {
+		return time.Time{}, time.Time{}, models.Money{}, models.Money{}, invalidSlotError(item.Id, errors.New("Item is booked by days, slot can not be reserved"))
+	}
+
+	from, to := inquiry.Stay()
+	quote, err := i.pricing.QuoteStay(item, from, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, models.Money{}, models.Money{}, errors.Wrap(err, "Error resolving item price on inquiry create")
+	}
+	return from, to, quote.Nights[0].Price, quote.Total, nil
+}
+
+// validates that reservation from start to end is in the future and follows
+// booking rules of item
+func checkBookingRules(ctx context.Context, db queryer, item *models.Item, inquiry *models.InquiryCreate, start time.Time, end time.Time) error {
+	rules, err := selectItemBookingRules(ctx, db, item)
+	if err != nil {
+		return err
+	}
+
+	units := nights(start, end)
+	if item.IsSlotBooked() {
+		units = inquiry.SlotCount()
+	}
+
+	err = services.CheckBookingRules(item, rules, start, units, time.Now())
+	if re, ok := err.(*services.BookingRuleError); ok {
+		return &BookingError{
+			Code:    re.Rule,
+			Message: re.Message,
+			ItemId:  item.Id,
+		}
+	}
+	return err
+}
+
+// Delete removes inquiry with its messages and self-service access. Those
+// carried over to accepted reservation stay with reservation
 func (i *inquiryStoreSql) Delete(ctx context.Context, id int64) error {
 	db := i.dbFactory.Connect()
 	defer db.Close()
 
-	q := "DELETE FROM inquiry WHERE id = $1"
-	res, err := db.ExecContext(ctx, q, id)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Could not initialize Delete inquiry transaction")
+	}
+	defer tx.Rollback()
+
+	q := "DELETE FROM reservation_message WHERE inquiry_id = $1 AND accepted_id IS NULL"
+	if _, err := tx.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrap(err, "Error deleting inquiry messages from DB")
+	}
+
+	q = "DELETE FROM reservation_access WHERE inquiry_id = $1 AND accepted_id IS NULL"
+	if _, err := tx.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrap(err, "Error deleting inquiry access from DB")
+	}
+
+	q = "DELETE FROM inquiry WHERE id = $1"
+	res, err := tx.ExecContext(ctx, q, id)
 	if err != nil {
 		return errors.Wrap(err, "Error deleting inquiry from DB")
 	}
@@ -121,5 +347,8 @@ func (i *inquiryStoreSql) Delete(ctx context.Context, id int64) error {
 
 	}
 
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting inquiry delete")
+	}
 	return nil
 }
@@ -0,0 +1,273 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// code of illegal inquiry transition returned to client
+const InquiryInvalidTransition = "invalid_transition"
+
+// InquiryTransitionError is returned when inquiry can not move from its
+// current status (Status) to requested one (Target)
+type InquiryTransitionError struct {
+	InquiryId int64
+	Status    string
+	Target    string
+}
+
+func (e *InquiryTransitionError) Error() string {
+	return fmt.Sprintf("Inquiry is %s and can not be %s", e.Status, e.Target)
+}
+
+func newInquiryTransitionError(id int64, status string, target string) *InquiryTransitionError {
+	return &InquiryTransitionError{
+		InquiryId: id,
+		Status:    status,
+		Target:    target,
+	}
+}
+
+// Accept turns pending inquiry into accepted reservation in single transaction.
+// Reservation is made from inquiry as it was stored (prices, add-ons, discount
+// and tax included), item is checked to still be free and unit is assigned
+func (i *inquiryStoreSql) Accept(ctx context.Context, id int64, notes string) (int64, error) {
+	db := i.dbFactory.Connect()
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error initializing transaction for inquiry accept")
+	}
+	defer tx.Rollback()
+
+	accepted, err := acceptInquiry(ctx, tx, i.pricing, id, notes)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Error commiting inquiry accept")
+	}
+	return accepted.Id, nil
+}
+
+// acceptInquiry locks pending inquiry, saves reservation made from its
+// snapshot and moves inquiry to accepted. Used by Accept and by ProcessInquiry
+// of accepted store, so inquiry is always accepted at its stored price
+func acceptInquiry(ctx context.Context, tx *sql.Tx, pricing services.PricingService, id int64, notes string) (*models.Accepted, error) {
+	status, err := lockInquiry(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanInquiryTransition(status, models.InquiryAccepted) {
+		return nil, newInquiryTransitionError(id, status, models.InquiryAccepted)
+	}
+
+	accepted, err := selectInquirySnapshot(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	accepted.Notes = notes
+
+	if err := lockInquiryPromoCode(ctx, tx, accepted); err != nil {
+		return nil, err
+	}
+
+	// inquiries without item are not taxed and have no unit
+	item := &models.Item{}
+	if accepted.ItemId != 0 {
+		if item, err = selectItem(ctx, tx, accepted.ItemId); err != nil {
+			return nil, errors.Wrap(err, "Error retrieving item of accepted inquiry")
+		}
+		if accepted.UnitId, err = reserveItem(ctx, tx, item, *accepted.DateReservation, *accepted.DateEnd); err != nil {
+			return nil, err
+		}
+		accepted.Components = item.Components
+	}
+
+	reservation := models.NewMoney(accepted.TotalPrice.Amount-accepted.AddonsTotal.Amount, accepted.TotalPrice.Currency)
+	accepted.Tax, err = acceptedTax(ctx, tx, pricing, accepted, item, reservation, accepted.Addons, accepted.AddonsTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	if accepted.Id, err = insertAccepted(ctx, tx, accepted); err != nil {
+		return nil, err
+	}
+
+	if err := transitionInquiry(ctx, tx, id, status, models.InquiryAccepted, ""); err != nil {
+		return nil, err
+	}
+	return accepted, nil
+}
+
+// Reject marks pending inquiry as rejected with optional reason
+func (i *inquiryStoreSql) Reject(ctx context.Context, id int64, reason string) error {
+	db := i.dbFactory.Connect()
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for inquiry reject")
+	}
+	defer tx.Rollback()
+
+	status, err := lockInquiry(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := transitionInquiry(ctx, tx, id, status, models.InquiryRejected, reason); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting inquiry reject")
+	}
+	return nil
+}
+
+// inquiry is past its date once reservation started before cutoff. Daily
+// reservations are compared to start of day ($2) so they can still be answered
+// on arrival day, slots are compared to now ($3)
+const inquiryPastDate = `date_reservation < CASE WHEN COALESCE((SELECT booking_mode FROM item
+	WHERE item.id = inquiry.item_id), 'daily') = 'slot' THEN $3 ELSE $2 END`
+
+// Expire moves pending inquiries of daily items arriving before day, of slot
+// items starting before now or which were created before createdBefore to
+// expired, recording the reason. Returns expired inquiries so inquirers can be notified
+func (i *inquiryStoreSql) Expire(ctx context.Context, createdBefore time.Time, day time.Time, now time.Time) (models.Inquiries, error) {
+	db := i.dbFactory.Connect()
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error initializing transaction for inquiry expiry")
+	}
+	defer tx.Rollback()
+
+	q := `UPDATE inquiry
+			SET status = $4, status_changed_at = $3,
+				status_note = CASE WHEN ` + inquiryPastDate + ` THEN $5 ELSE $6 END
+			WHERE status = $7 AND (` + inquiryPastDate + ` OR date_created < $1)
+			RETURNING id, reference, inquirer, COALESCE(email, ''), COALESCE(phone, ''), date_reservation, date_end,
+				date_created, status, status_changed_at, status_note, item_id, item_title`
+
+	rows, err := tx.QueryContext(ctx, q, createdBefore.UTC(), day.UTC(), now.UTC(), models.InquiryExpired,
+		models.InquiryExpiredPastDate, models.InquiryExpiredUnanswered, models.InquiryPending)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error expiring inquiries")
+	}
+	defer rows.Close()
+
+	inquiries := models.Inquiries{}
+	ids := []int64{}
+	for rows.Next() {
+		var inquiry models.Inquiry
+		var itemId sql.NullInt64
+		err := rows.Scan(&inquiry.Id, &inquiry.Reference, &inquiry.Inquirer, &inquiry.Email, &inquiry.Phone, &inquiry.DateReservation,
+			&inquiry.DateEnd, &inquiry.DateCreated, &inquiry.Status, &inquiry.StatusChangedAt, &inquiry.StatusNote,
+			&itemId, &inquiry.Item.Title)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning expired inquiry")
+		}
+		inquiry.Item.Id = itemId.Int64
+		inquiries = append(inquiries, inquiry)
+		ids = append(ids, inquiry.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error reading expired inquiries")
+	}
+
+	// expired inquiries release their promo code redemptions, same as in transitionInquiry
+	if _, err := tx.ExecContext(ctx, "DELETE FROM promo_redemption WHERE inquiry_id = ANY($1)", pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "Error releasing promo code redemptions of expired inquiries")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "Error commiting inquiry expiry")
+	}
+	return inquiries, nil
+}
+
+// locks inquiry row for the rest of transaction and returns its status.
+// Missing inquiry is reported as sql.ErrNoRows
+func lockInquiry(ctx context.Context, tx *sql.Tx, id int64) (string, error) {
+	var status string
+	if err := tx.QueryRowContext(ctx, "SELECT status FROM inquiry WHERE id = $1 FOR UPDATE", id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return "", err
+		}
+		return "", errors.Wrap(err, "Error locking inquiry")
+	}
+	return status, nil
+}
+
+// transitionInquiry moves inquiry locked in status to target status. Inquiries
+// that were not accepted release their promo code redemption
+func transitionInquiry(ctx context.Context, tx *sql.Tx, id int64, status string, target string, note string) error {
+	if !models.CanInquiryTransition(status, target) {
+		return newInquiryTransitionError(id, status, target)
+	}
+
+	q := `UPDATE inquiry SET status = $2, status_changed_at = $3, status_note = NULLIF($4, '')
+			WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, q, id, target, time.Now().UTC(), note); err != nil {
+		return errors.Wrap(err, "Error updating inquiry status")
+	}
+
+	if target != models.InquiryAccepted {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM promo_redemption WHERE inquiry_id = $1", id); err != nil {
+			return errors.Wrap(err, "Error releasing promo code redemption of inquiry")
+		}
+	}
+	return nil
+}
+
+// selects inquiry as accepted reservation with its add-on lines. Total price
+// includes add-ons and discount, tax is left to caller
+func selectInquirySnapshot(ctx context.Context, db queryer, id int64) (*models.Accepted, error) {
+	q := `SELECT inquirer, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(comment, ''),
+				COALESCE(item_id, 0), COALESCE(item_title, ''), COALESCE(item_price, 0), COALESCE(total_price, 0),
+				addons_total, currency, COALESCE(promo_code, ''), discount,
+				date_reservation, date_end, date_created
+			FROM inquiry
+			WHERE id = $1`
+
+	accepted := &models.Accepted{InquiryId: id}
+	var currency string
+	var start, end, created time.Time
+	err := db.QueryRowContext(ctx, q, id).Scan(&accepted.Inquirer, &accepted.InquirerEmail, &accepted.InquirerPhone,
+		&accepted.InquirerComment, &accepted.ItemId, &accepted.ItemTitle, &accepted.ItemPrice.Amount,
+		&accepted.TotalPrice.Amount, &accepted.AddonsTotal.Amount, &currency, &accepted.PromoCode,
+		&accepted.Discount.Amount, &start, &end, &created)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "Error retrieving inquiry snapshot")
+	}
+	accepted.ItemPrice.Currency = currency
+	accepted.TotalPrice.Currency = currency
+	accepted.AddonsTotal.Currency = currency
+	accepted.Discount.Currency = currency
+	accepted.DateReservation = &start
+	accepted.DateEnd = &end
+	accepted.DateInquiryCreated = &created
+
+	addons, err := selectAddonLines(ctx, db, inquiryAddonTable, inquiryAddonOwner, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	accepted.Addons = addons[id]
+
+	return accepted, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/alesbrelih/go-reservation-api/db"
 	"github.com/alesbrelih/go-reservation-api/models"
 	"github.com/lib/pq"
+	"github.com/pkg/errors"
 )
 
 func NewItemStoreSql(db db.DbFactory) ItemStore {
@@ -16,12 +17,57 @@ func NewItemStoreSql(db db.DbFactory) ItemStore {
 	}
 }
 
+// returned when database rejects item date price ranges (overlapping or inverted)
+var ErrDatePriceConflict = errors.New("Item date price ranges overlap or are inverted")
+
+// returned when item refers to category that does not exist
+var ErrUnknownCategory = errors.New("Item category does not exist")
+
+// returned when item refers to tenant that does not exist
+var ErrUnknownTenant = errors.New("Item tenant does not exist")
+
+// returned when item refers to tax class that does not exist
+var ErrUnknownTaxClass = errors.New("Item tax class does not exist")
+
+// returned when item with reservations is removed permanently
+var ErrItemHasReservations = errors.New("Item has reservations and can only be archived")
+
+// returned when reservation is made for archived item
+var ErrItemArchived = errors.New("Item is archived")
+
 type ItemStore interface {
 	GetAll(ctx context.Context) (models.Items, error)
+	Filter(ctx context.Context, filter *models.ItemFilter) (models.Items, error)
+	GetVisible(ctx context.Context, filter *models.ItemFilter, at time.Time) (models.Items, error)
 	GetOne(ctx context.Context, id int64) (*models.Item, error)
-	Create(ctx context.Context, item *models.Item) (int64, error)
-	Update(ctx context.Context, item *models.Item) error
+	Create(ctx context.Context, item *models.Item, changedBy *int64) (int64, error)
+	Update(ctx context.Context, item *models.Item, changedBy *int64) error
+	GetHistory(ctx context.Context, id int64) (models.ItemHistory, error)
 	Delete(id int64) error
+	Restore(ctx context.Context, id int64) error
+	Purge(ctx context.Context, id int64) error
+	GetAvailability(ctx context.Context, id int64, from time.Time, to time.Time) (models.ItemAvailabilityList, error)
+	SearchAvailable(ctx context.Context, search *models.AvailabilitySearch, at time.Time) ([]models.AvailableItem, error)
+	GetPriceRules(ctx context.Context, itemId int64) (models.ItemPriceRules, error)
+	CreatePriceRule(ctx context.Context, rule *models.ItemPriceRule) (int64, error)
+	UpdatePriceRule(ctx context.Context, rule *models.ItemPriceRule) error
+	DeletePriceRule(ctx context.Context, itemId int64, ruleId int64) error
+	GetSlots(ctx context.Context, item *models.Item, date time.Time) (models.ItemSlots, error)
+	GetSchedule(ctx context.Context, itemId int64) (models.ItemScheduleList, error)
+	SetSchedule(ctx context.Context, itemId int64, schedule models.ItemScheduleList) error
+	GetBlackouts(ctx context.Context, itemId int64) (models.ItemBlackouts, error)
+	CreateBlackout(ctx context.Context, blackout *models.ItemBlackout) (int64, error)
+	DeleteBlackout(ctx context.Context, itemId int64, blackoutId int64) error
+	GetAddons(ctx context.Context, itemId int64) (models.ItemAddons, error)
+	CreateAddon(ctx context.Context, addon *models.ItemAddon) (int64, error)
+	UpdateAddon(ctx context.Context, addon *models.ItemAddon) error
+	DeleteAddon(ctx context.Context, itemId int64, addonId int64) error
+	GetComponents(ctx context.Context, bundleId int64) (models.BundleComponents, error)
+	SetComponents(ctx context.Context, bundleId int64, components models.BundleComponents) error
+	GetUnits(ctx context.Context, itemId int64) (models.ItemUnits, error)
+	CreateUnit(ctx context.Context, unit *models.ItemUnit) (int64, error)
+	UpdateUnit(ctx context.Context, unit *models.ItemUnit) error
+	DeleteUnit(ctx context.Context, itemId int64, unitId int64) error
 }
 
 type itemStoreSql struct {
@@ -33,8 +79,23 @@ func (u *itemStoreSql) GetAll(ctx context.Context) (models.Items, error) {
 	myDb := u.db.Connect()
 	defer myDb.Close()
 
-	query := "SELECT id, title, show_from, show_to, price FROM item"
-	rows, err := myDb.QueryContext(ctx, query)
+	return selectItems(ctx, myDb, "i.archived_at IS NULL")
+}
+
+// selects items (without pricing data) matching where condition.
+// Empty condition selects all items
+func selectItems(ctx context.Context, db queryer, where string, args ...interface{}) (models.Items, error) {
+	query := `SELECT i.id, i.title, i.show_from, i.show_to, i.price, i.currency, ` + itemCapacity("i") + `,
+				i.booking_mode, COALESCE(i.slot_minutes, 0),
+				to_char(i.opens_at, 'HH24:MI'), to_char(i.closes_at, 'HH24:MI'),
+				i.category_id, i.tenant_id, i.tax_class_id, i.archived_at, ` + itemTagsColumn + `
+			FROM item i`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY i.id"
+
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -45,14 +106,27 @@ func (u *itemStoreSql) GetAll(ctx context.Context) (models.Items, error) {
 	for rows.Next() {
 		var item models.Item
 
-		err = rows.Scan(&item.Id, &item.Title, &item.ShowFrom, &item.ShowTo, &item.Price)
+		err = rows.Scan(&item.Id, &item.Title, &item.ShowFrom, &item.ShowTo, &item.Price.Amount, &item.Price.Currency,
+			&item.Capacity, &item.BookingMode, &item.SlotMinutes, &item.OpensAt, &item.ClosesAt,
+			&item.CategoryId, &item.TenantId, &item.TaxClassId, &item.ArchivedAt, pq.Array(&item.Tags))
 		if err != nil {
 			return nil, err
 		}
 
 		items = append(items, item)
 	}
-	return items, nil
+	return items, rows.Err()
+}
+
+// sorted tags of item i as text array
+const itemTagsColumn = "ARRAY(SELECT t.tag FROM item_tag t WHERE t.item_id = i.id ORDER BY t.tag)"
+
+// queryer is implemented by both *sql.DB and *sql.Tx so helpers
+// can be used inside or outside of transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 func (u *itemStoreSql) GetOne(ctx context.Context, id int64) (*models.Item, error) {
@@ -60,63 +134,99 @@ func (u *itemStoreSql) GetOne(ctx context.Context, id int64) (*models.Item, erro
 	myDb := u.db.Connect()
 	defer myDb.Close()
 
-	q := `SELECT i.id, i.title, i.show_from, i.show_to, i.price,
-					idrp.id, idrp.date_from, idrp.date_to, idrp.price
-				FROM item i
-					LEFT JOIN item_date_range_price idrp ON (idrp.item_id = i.id) WHERE i.id = $1`
+	return selectItem(ctx, myDb, id)
+}
 
-	rows, err := myDb.QueryContext(ctx, q, id)
-	defer rows.Close()
+// selects item with its pricing data (date price ranges, active price rules
+// and add-ons) and media
+func selectItem(ctx context.Context, db queryer, id int64) (*models.Item, error) {
+	items, err := selectPricedItems(ctx, db, "i.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &items[0], nil
+}
+
+// selects items matching where (see selectItems) with their pricing data and
+// media. Data of all items is loaded by single query per table
+func selectPricedItems(ctx context.Context, db queryer, where string, args ...interface{}) (models.Items, error) {
+	items, err := selectItems(ctx, db, where, args...)
+	if err != nil || len(items) == 0 {
+		return items, err
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
 
+	prices, err := selectDatePricesOf(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := selectPriceRulesOf(ctx, db, ids, false)
+	if err != nil {
+		return nil, err
+	}
+	media, err := selectItemMediaOf(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	addons, err := selectAddonsOf(ctx, db, ids, false)
+	if err != nil {
+		return nil, err
+	}
+	components, err := selectComponentsOf(ctx, db, ids)
 	if err != nil {
 		return nil, err
 	}
 
-	var item *models.Item
+	for idx := range items {
+		id := items[idx].Id
+		items[idx].DatePrices = prices[id]
+		items[idx].PriceRules = rules[id]
+		items[idx].Media = media[id]
+		items[idx].Addons = addons[id]
+		items[idx].Components = components[id]
+	}
+	return items, nil
+}
+
+// selects date price ranges of items grouped by item id
+func selectDatePricesOf(ctx context.Context, db queryer, itemIds []int64) (map[int64][]models.ItemDatePrice, error) {
+	q := `SELECT id, item_id, date_from, date_to, price, currency
+			FROM item_date_range_price
+			WHERE item_id = ANY($1)
+			ORDER BY date_from, id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(itemIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := map[int64][]models.ItemDatePrice{}
+	for _, id := range itemIds {
+		prices[id] = []models.ItemDatePrice{}
+	}
 	for rows.Next() {
 		var itemId int64
-		var title string
-		var showFrom time.Time
-		var showTo time.Time
-		var price int64
-		var pId sql.NullInt64
-		var pDateFrom sql.NullTime
-		var pDateTo sql.NullTime
-		var pPrice sql.NullInt64
-
-		err = rows.Scan(&itemId, &title, &showFrom, &showTo, &price, &pId, &pDateFrom, &pDateTo, &pPrice)
+		var price models.ItemDatePrice
+		err := rows.Scan(&price.Id, &itemId, &price.DateFrom, &price.DateTo, &price.Price.Amount, &price.Price.Currency)
 		if err != nil {
 			return nil, err
 		}
-
-		if item == nil {
-			item = &models.Item{
-				Id:         itemId,
-				Title:      &title,
-				ShowFrom:   &showFrom,
-				ShowTo:     &showTo,
-				Price:      price,
-				DatePrices: []models.ItemDatePrice{},
-			}
-		}
-		if pId.Valid {
-			item.DatePrices = append(item.DatePrices, models.ItemDatePrice{
-				Id:       pId.Int64,
-				DateFrom: pDateFrom.Time,
-				DateTo:   pDateTo.Time,
-				Price:    pPrice.Int64,
-			})
-		}
+		prices[itemId] = append(prices[itemId], price)
 	}
 
-	if item == nil {
-		return nil, sql.ErrNoRows
-	}
-
-	return item, nil
+	return prices, rows.Err()
 }
 
-func (u *itemStoreSql) Create(ctx context.Context, item *models.Item) (int64, error) {
+// Create saves item and its first version. changedBy is id of user creating item
+func (u *itemStoreSql) Create(ctx context.Context, item *models.Item, changedBy *int64) (int64, error) {
 	myDb := u.db.Connect()
 	defer myDb.Close()
 
@@ -125,18 +235,37 @@ func (u *itemStoreSql) Create(ctx context.Context, item *models.Item) (int64, er
 		return 0, err
 	}
 
+	// missing currency defaults to currency of tenant
 	var id int64
-	q := "INSERT INTO item (title, show_from, show_to, price) VALUES ($1, $2, $3, $4) RETURNING id"
-	err = tx.QueryRowContext(ctx, q, item.Title, item.ShowFrom, item.ShowTo, item.Price).Scan(&id)
+	var currency string
+	q := `INSERT INTO item (title, show_from, show_to, price, capacity,
+				booking_mode, slot_minutes, opens_at, closes_at, category_id, tenant_id, currency, tax_class_id)
+			VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, 0), 1),
+				COALESCE(NULLIF($6, ''), 'daily'), NULLIF($7, 0), $8::time, $9::time, $10, $11,
+				COALESCE(NULLIF($12, ''), (SELECT currency FROM tenant WHERE id = $11), $13), $14)
+			RETURNING id, currency`
+	err = tx.QueryRowContext(ctx, q, item.Title, item.ShowFrom, item.ShowTo, item.Price.Amount, item.Capacity,
+		item.BookingMode, item.SlotMinutes, item.OpensAt, item.ClosesAt, item.CategoryId, item.TenantId,
+		item.Price.Currency, models.DefaultCurrency, item.TaxClassId).Scan(&id, &currency)
 
 	if err != nil {
+		tx.Rollback()
+		return 0, itemReferenceError(err)
+	}
+
+	if err := checkDatePriceCurrency(item.DatePrices, currency); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := setItemTags(ctx, tx, id, item.Tags); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
 	if len(item.DatePrices) != 0 {
-		stmt, err := tx.PrepareContext(ctx, `INSERT INTO item_date_range_price (item_id, date_from, date_to, price)
-				VALUES ($1, $2, $3, $4)`)
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO item_date_range_price (item_id, date_from, date_to, price, currency)
+				VALUES ($1, $2, $3, $4, $5)`)
 		defer stmt.Close()
 		if err != nil {
 			tx.Rollback()
@@ -144,23 +273,33 @@ func (u *itemStoreSql) Create(ctx context.Context, item *models.Item) (int64, er
 		}
 
 		for _, price := range item.DatePrices {
-			_, err = stmt.ExecContext(ctx, id, price.DateFrom, price.DateTo, price.Price)
+			_, err = stmt.ExecContext(ctx, id, price.DateFrom, price.DateTo, price.Price.Amount, currency)
 			if err != nil {
 				tx.Rollback()
-				return 0, err
+				return 0, datePriceError(err)
 			}
 		}
 	}
 
+	if err := insertItemVersion(ctx, tx, id, changedBy); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// overlap constraint is deferred, violations are reported on commit
 	err = tx.Commit()
 	if err != nil {
-		return 0, err
+		return 0, datePriceError(err)
 	}
 
 	return id, nil
 }
 
-func (u *itemStoreSql) Update(ctx context.Context, item *models.Item) error {
+// Update saves item and its new version when title or prices changed.
+// Omitted category, tenant, tax class and tags are kept, category and
+// tax class set to 0 are removed.
+// changedBy is id of user changing item
+func (u *itemStoreSql) Update(ctx context.Context, item *models.Item, changedBy *int64) error {
 	myDb := u.db.Connect()
 	defer myDb.Close()
 
@@ -169,28 +308,65 @@ func (u *itemStoreSql) Update(ctx context.Context, item *models.Item) error {
 		return err
 	}
 
-	stmt := "UPDATE item SET title=$2, show_from=$3, show_to=$4, price=$5 WHERE id = $1"
-	res, err := tx.ExecContext(ctx, stmt, item.Id, item.Title, item.ShowFrom, item.ShowTo, item.Price)
+	var currency string
+	stmt := `UPDATE item SET title=$2, show_from=$3, show_to=$4, price=$5,
+				capacity = COALESCE(NULLIF($6, 0), capacity),
+				booking_mode = COALESCE(NULLIF($7, ''), booking_mode),
+				slot_minutes = COALESCE(NULLIF($8, 0), slot_minutes),
+				opens_at = COALESCE($9::time, opens_at),
+				closes_at = COALESCE($10::time, closes_at),
+				category_id = NULLIF(COALESCE($11, category_id), 0),
+				tenant_id = COALESCE($12, tenant_id),
+				currency = COALESCE(NULLIF($13, ''), currency),
+				tax_class_id = NULLIF(COALESCE($14, tax_class_id), 0)
+			WHERE id = $1
+			RETURNING currency`
+	err = tx.QueryRowContext(ctx, stmt, item.Id, item.Title, item.ShowFrom, item.ShowTo, item.Price.Amount, item.Capacity,
+		item.BookingMode, item.SlotMinutes, item.OpensAt, item.ClosesAt, item.CategoryId, item.TenantId,
+		item.Price.Currency, item.TaxClassId).Scan(&currency)
 	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return itemReferenceError(err)
+	}
+
+	if err := checkDatePriceCurrency(item.DatePrices, currency); err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	num, _ := res.RowsAffected()
-	if num == 0 {
-		return sql.ErrNoRows
+	// add-ons are kept on update, they must not stay in previous currency
+	var mixed bool
+	stmt = "SELECT EXISTS (SELECT 1 FROM item_addon WHERE item_id = $1 AND currency <> $2)"
+	if err := tx.QueryRowContext(ctx, stmt, item.Id, currency).Scan(&mixed); err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "Error checking item add-on currency")
+	}
+	if mixed {
+		tx.Rollback()
+		return models.ErrCurrencyMismatch
+	}
+
+	// omitted tags are kept, empty list removes them
+	if item.Tags != nil {
+		if err := setItemTags(ctx, tx, item.Id, item.Tags); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
 	// delete those that were removed
-	ids := []interface{}{}
+	ids := []int64{}
 	for _, i := range item.DatePrices {
 		if i.Id != 0 {
 			ids = append(ids, i.Id)
 		}
 	}
 
-	stmt = "DELETE FROM item_date_range_price WHERE item_id = $1 AND (id != ANY ($2) OR $3)"
-	res, err = tx.ExecContext(ctx, stmt, item.Id, pq.Array(ids), len(ids) == 0)
+	stmt = "DELETE FROM item_date_range_price WHERE item_id = $1 AND NOT (id = ANY ($2))"
+	_, err = tx.ExecContext(ctx, stmt, item.Id, pq.Array(ids))
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -198,32 +374,102 @@ func (u *itemStoreSql) Update(ctx context.Context, item *models.Item) error {
 
 	for _, i := range item.DatePrices {
 		if i.Id != 0 {
-			// update
+			// update, ids of other items or unknown ids are rejected
 			stmt = `UPDATE item_date_range_price
-				 SET date_from = $2, date_to = $3, price = $4
-				 WHERE id = $1`
-			res, err = tx.ExecContext(ctx, stmt, i.Id, i.DateFrom, i.DateTo, i.Price)
+				 SET date_from = $2, date_to = $3, price = $4, currency = $6
+				 WHERE id = $1 AND item_id = $5`
+			var res sql.Result
+			res, err = tx.ExecContext(ctx, stmt, i.Id, i.DateFrom, i.DateTo, i.Price.Amount, item.Id, currency)
+			if err == nil {
+				var num int64
+				if num, err = res.RowsAffected(); err == nil && num == 0 {
+					tx.Rollback()
+					return sql.ErrNoRows
+				}
+			}
 		} else {
 			// create
-			stmt = "INSERT INTO item_date_range_price (item_id, date_from, date_to, price) VALUES ($1, $2, $3, $4)"
-			res, err = tx.ExecContext(ctx, stmt, item.Id, i.DateFrom, i.DateTo, i.Price)
+			stmt = `INSERT INTO item_date_range_price (item_id, date_from, date_to, price, currency)
+				VALUES ($1, $2, $3, $4, $5)`
+			_, err = tx.ExecContext(ctx, stmt, item.Id, i.DateFrom, i.DateTo, i.Price.Amount, currency)
 		}
 
 		if err != nil {
 			tx.Rollback()
-			return err
+			return datePriceError(err)
 		}
 	}
 
-	tx.Commit()
+	if err := insertItemVersion(ctx, tx, item.Id, changedBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// overlap constraint is deferred, violations are reported on commit
+	if err := tx.Commit(); err != nil {
+		return datePriceError(err)
+	}
+	return nil
+}
+
+// date prices without currency take item currency, others must match it
+func checkDatePriceCurrency(prices []models.ItemDatePrice, currency string) error {
+	for _, dp := range prices {
+		if dp.Price.Currency != "" && dp.Price.Currency != currency {
+			return models.ErrCurrencyMismatch
+		}
+	}
 	return nil
 }
 
+// replaces tags of item with normalized tags
+func setItemTags(ctx context.Context, tx *sql.Tx, itemId int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM item_tag WHERE item_id = $1", itemId); err != nil {
+		return errors.Wrap(err, "Error deleting item tags")
+	}
+
+	tags = models.NormalizeTags(tags)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	q := "INSERT INTO item_tag (item_id, tag) SELECT $1, unnest($2::text[])"
+	if _, err := tx.ExecContext(ctx, q, itemId, pq.Array(tags)); err != nil {
+		return errors.Wrap(err, "Error creating item tags")
+	}
+	return nil
+}
+
+// maps missing category, tenant or tax class of item to
+// ErrUnknownCategory / ErrUnknownTenant / ErrUnknownTaxClass
+func itemReferenceError(err error) error {
+	if isForeignKeyViolation(err) {
+		switch violatedConstraint(err) {
+		case "item_tenant_id_fkey":
+			return errors.Wrap(ErrUnknownTenant, err.Error())
+		case "item_tax_class_id_fkey":
+			return errors.Wrap(ErrUnknownTaxClass, err.Error())
+		}
+		return errors.Wrap(ErrUnknownCategory, err.Error())
+	}
+	return err
+}
+
+// maps constraint violations of item_date_range_price to ErrDatePriceConflict
+func datePriceError(err error) error {
+	if isExclusionViolation(err) || isCheckViolation(err) {
+		return errors.Wrap(ErrDatePriceConflict, err.Error())
+	}
+	return err
+}
+
+// Delete archives item. Archived items are left out of listings and can not
+// be reserved anymore, but stay available to their reservations
 func (u *itemStoreSql) Delete(id int64) error {
 	myDb := u.db.Connect()
 	defer myDb.Close()
 
-	stmt := "DELETE FROM item WHERE id = $1"
+	stmt := "UPDATE item SET archived_at = now() at time zone 'utc' WHERE id = $1 AND archived_at IS NULL"
 	res, err := myDb.Exec(stmt, int64(id))
 	if err != nil {
 		return err
@@ -236,3 +482,169 @@ func (u *itemStoreSql) Delete(id int64) error {
 
 	return nil
 }
+
+// Restore brings archived item back
+func (u *itemStoreSql) Restore(ctx context.Context, id int64) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	stmt := "UPDATE item SET archived_at = NULL WHERE id = $1 AND archived_at IS NOT NULL"
+	res, err := myDb.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return errors.Wrap(err, "Error restoring item")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// Purge removes item permanently. Items referenced by inquiries or accepted
+// reservations are rejected with ErrItemHasReservations
+func (u *itemStoreSql) Purge(ctx context.Context, id int64) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	res, err := myDb.ExecContext(ctx, "DELETE FROM item WHERE id = $1", id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(ErrItemHasReservations, err.Error())
+		}
+		return errors.Wrap(err, "Error deleting item")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// Returns remaining capacity of item for each day between from and to (both inclusive).
+// Availability of bundle is combined from availability of its components
+func (u *itemStoreSql) GetAvailability(ctx context.Context, id int64, from time.Time, to time.Time) (models.ItemAvailabilityList, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	components, err := selectComponents(ctx, myDb, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return selectAvailability(ctx, myDb, id, from, to)
+	}
+
+	parts := make([]models.ItemAvailabilityList, 0, len(components))
+	for _, component := range components {
+		part, err := selectAvailability(ctx, myDb, component.ItemId, from, to)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return models.BundleAvailability(components, parts), nil
+}
+
+// selects remaining capacity of single item for each day between from and to
+func selectAvailability(ctx context.Context, db queryer, id int64, from time.Time, to time.Time) (models.ItemAvailabilityList, error) {
+	q := `SELECT d.day, ` + itemCapacity("i") + `, COALESCE(SUM(b.quantity), 0)
+			FROM item i
+				CROSS JOIN generate_series($2::date, $3::date, interval '1 day') AS d(day)
+				LEFT JOIN item_booking b ON (b.item_id = i.id AND
+					b.date_reservation::date <= d.day::date AND b.date_end::date > d.day::date)
+			WHERE i.id = $1
+			GROUP BY d.day, i.id
+			ORDER BY d.day`
+
+	rows, err := db.QueryContext(ctx, q, id, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item availability")
+	}
+	defer rows.Close()
+
+	availability := models.ItemAvailabilityList{}
+	for rows.Next() {
+		day := models.ItemAvailability{}
+		if err := rows.Scan(&day.Date, &day.Capacity, &day.Booked); err != nil {
+			return nil, errors.Wrap(err, "Error scanning item availability")
+		}
+
+		day.Remaining = day.Capacity - day.Booked
+		if day.Remaining < 0 {
+			day.Remaining = 0
+		}
+		availability = append(availability, day)
+	}
+
+	if len(availability) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return availability, nil
+}
+
+// Returns slots of slot booked item on given day with number of accepted
+// reservations in each of them. Slots outside weekly schedule or inside
+// blackouts are left out. Prices are left to caller
+func (u *itemStoreSql) GetSlots(ctx context.Context, item *models.Item, date time.Time) (models.ItemSlots, error) {
+	starts, err := item.SlotStarts(date)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := models.ItemSlots{}
+	if len(starts) == 0 {
+		return slots, nil
+	}
+
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	end := starts[len(starts)-1].Add(item.SlotLength())
+	booked, err := countSlotBookings(ctx, myDb, item.Id, starts[0], end, item.SlotMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := selectSchedule(ctx, myDb, item.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	blackouts, err := selectBlackouts(ctx, myDb, item.Id, starts[0], end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slot := range booked {
+		if !schedule.Covers(slot.Start, slot.End) || blackedOut(blackouts, slot.Start, slot.End) {
+			continue
+		}
+
+		slot.Capacity = item.Capacity
+		slot.Remaining = slot.Capacity - slot.Booked
+		if slot.Remaining < 0 {
+			slot.Remaining = 0
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, nil
+}
+
+func blackedOut(blackouts models.ItemBlackouts, start time.Time, end time.Time) bool {
+	for _, b := range blackouts {
+		if b.Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
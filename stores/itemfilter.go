@@ -0,0 +1,118 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+)
+
+// Filter returns items matching all conditions of filter.
+//
+// Availability on date is checked against weekly schedule and blackouts of item.
+// Daily items must also have free capacity for the night, slot items are only
+// excluded when closed or blacked out for the whole day (free slots are listed
+// by GetSlots).
+func (u *itemStoreSql) Filter(ctx context.Context, filter *models.ItemFilter) (models.Items, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	where, args := itemFilterCondition(filter)
+	return selectItems(ctx, myDb, where, args...)
+}
+
+// builds sql condition (on item aliased as i) and its arguments from filter
+func itemFilterCondition(filter *models.ItemFilter) (string, []interface{}) {
+	conditions := []string{"i.archived_at IS NULL"}
+	if filter.Archived {
+		conditions[0] = "i.archived_at IS NOT NULL"
+	}
+	args := []interface{}{}
+
+	// returns placeholder of newly added argument
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.CategoryId != nil {
+		conditions = append(conditions, `i.category_id IN (
+				WITH RECURSIVE subtree AS (
+					SELECT id FROM category WHERE id = `+arg(*filter.CategoryId)+`
+					UNION
+					SELECT c.id FROM category c JOIN subtree s ON (c.parent_id = s.id)
+				)
+				SELECT id FROM subtree)`)
+	}
+
+	if tags := models.NormalizeTags(filter.Tags); len(tags) != 0 {
+		conditions = append(conditions, fmt.Sprintf(
+			"(SELECT COUNT(*) FROM item_tag t WHERE t.item_id = i.id AND t.tag = ANY(%s)) = %s",
+			arg(pq.Array(tags)), arg(len(tags))))
+	}
+
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "i.price >= "+arg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "i.price <= "+arg(*filter.MaxPrice))
+	}
+	if filter.Currency != "" {
+		conditions = append(conditions, "i.currency = "+arg(strings.ToUpper(filter.Currency)))
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, "i.title ILIKE "+arg("%"+escapeLike(filter.Search)+"%"))
+	}
+
+	if filter.AvailableOn != nil {
+		day := arg(filter.AvailableOn.UTC().Format("2006-01-02"))
+		conditions = append(conditions,
+			`(NOT EXISTS (SELECT 1 FROM item_schedule s WHERE s.item_id = i.id) OR
+				EXISTS (SELECT 1 FROM item_schedule s WHERE s.item_id = i.id AND s.weekday = EXTRACT(DOW FROM `+day+`::date)))`,
+			`NOT EXISTS (SELECT 1 FROM item_blackout b WHERE b.item_id = i.id AND
+				b.date_from < `+day+`::date + 1 AND b.date_to > `+day+`::date AND
+				(i.booking_mode = 'daily' OR (b.date_from <= `+day+`::date AND b.date_to >= `+day+`::date + 1)))`,
+			`(i.booking_mode = 'slot' OR
+				(SELECT COALESCE(SUM(b.quantity), 0) FROM item_booking b WHERE b.item_id = i.id AND
+					b.date_reservation::date <= `+day+`::date AND b.date_end::date > `+day+`::date) < `+itemCapacity("i")+`)`,
+			`NOT EXISTS (SELECT 1 FROM item_bundle_component c JOIN item ci ON ci.id = c.item_id
+				WHERE c.bundle_id = i.id AND
+					(SELECT COALESCE(SUM(b.quantity), 0) FROM item_booking b WHERE b.item_id = c.item_id AND
+						b.date_reservation::date <= `+day+`::date AND b.date_end::date > `+day+`::date) + c.quantity > `+itemCapacity("ci")+`)`)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// escapes LIKE wildcards so search is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// GetVisible returns items (never archived ones) matching filter whose
+// ShowFrom / ShowTo window includes at. Items are loaded together with
+// pricing data and media so they can be quoted
+func (u *itemStoreSql) GetVisible(ctx context.Context, filter *models.ItemFilter, at time.Time) (models.Items, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	active := *filter
+	active.Archived = false
+	where, args := itemFilterCondition(&active)
+	args = append(args, at.UTC())
+	visible := fmt.Sprintf("(i.show_from IS NULL OR i.show_from <= $%d) AND (i.show_to IS NULL OR i.show_to >= $%d)",
+		len(args), len(args))
+
+	items, err := selectPricedItems(ctx, myDb, where+" AND "+visible, args...)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = models.Items{}
+	}
+	return items, nil
+}
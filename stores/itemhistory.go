@@ -0,0 +1,94 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+// date prices of item as json array, same shape as ItemDatePrice
+const itemHistoryDatePrices = `COALESCE((SELECT jsonb_agg(jsonb_build_object(
+				'id', p.id,
+				'dateFrom', to_char(p.date_from, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+				'dateTo', to_char(p.date_to, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+				'price', jsonb_build_object('amount', p.price, 'currency', p.currency))
+				ORDER BY p.date_from, p.id)
+			FROM item_date_range_price p WHERE p.item_id = i.id), '[]')`
+
+// saves current title and prices of item as its next version. Nothing is
+// saved when they did not change since last version. Must run in same
+// transaction as the change so versions of item are not written concurrently
+func insertItemVersion(ctx context.Context, tx *sql.Tx, itemId int64, changedBy *int64) error {
+	q := `WITH snapshot AS (
+				SELECT i.id, i.title, i.price, i.currency, ` + itemHistoryDatePrices + ` AS date_prices
+				FROM item i
+				WHERE i.id = $1
+			), latest AS (
+				SELECT version, title, price, currency, date_prices
+				FROM item_history
+				WHERE item_id = $1
+				ORDER BY version DESC
+				LIMIT 1
+			)
+			INSERT INTO item_history (item_id, version, changed_by, title, price, currency, date_prices)
+			SELECT s.id, COALESCE((SELECT version FROM latest), 0) + 1, $2, s.title, s.price, s.currency, s.date_prices
+			FROM snapshot s
+			WHERE NOT EXISTS (SELECT 1 FROM latest l
+				WHERE l.title = s.title AND l.price = s.price AND l.currency = s.currency AND l.date_prices = s.date_prices)`
+
+	if _, err := tx.ExecContext(ctx, q, itemId, changedBy); err != nil {
+		return errors.Wrap(err, "Error saving item version")
+	}
+	return nil
+}
+
+// GetHistory returns versions of item from oldest to newest
+func (u *itemStoreSql) GetHistory(ctx context.Context, id int64) (models.ItemHistory, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	var exists bool
+	if err := myDb.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1)", id).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "Error checking item")
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	q := `SELECT h.item_id, h.version, h.changed_at, h.changed_by, u.username,
+				h.title, h.price, h.currency, h.date_prices
+			FROM item_history h
+			LEFT JOIN reservation_user u ON u.id = h.changed_by
+			WHERE h.item_id = $1
+			ORDER BY h.version`
+
+	rows, err := myDb.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item history")
+	}
+	defer rows.Close()
+
+	history := models.ItemHistory{}
+	for rows.Next() {
+		var version models.ItemVersion
+		var datePrices []byte
+		err := rows.Scan(&version.ItemId, &version.Version, &version.ChangedAt, &version.ChangedBy,
+			&version.ChangedByName, &version.Title, &version.Price.Amount, &version.Price.Currency, &datePrices)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning item version")
+		}
+		if err := json.Unmarshal(datePrices, &version.DatePrices); err != nil {
+			return nil, errors.Wrap(err, "Error decoding item version date prices")
+		}
+		history = append(history, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error querying item history")
+	}
+
+	history.SetChanges()
+	return history, nil
+}
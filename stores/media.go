@@ -0,0 +1,118 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func NewMediaStore(db db.DbFactory) MediaStore {
+	return &mediaStoreSql{
+		db: db,
+	}
+}
+
+type MediaStore interface {
+	GetAll(ctx context.Context, itemId int64) (models.ItemMediaList, error)
+	Create(ctx context.Context, media *models.ItemMedia) (int64, error)
+	Delete(ctx context.Context, itemId int64, mediaId int64) (*models.ItemMedia, error)
+}
+
+type mediaStoreSql struct {
+	db db.DbFactory
+}
+
+func (m *mediaStoreSql) GetAll(ctx context.Context, itemId int64) (models.ItemMediaList, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	return selectItemMedia(ctx, myDb, itemId)
+}
+
+func (m *mediaStoreSql) Create(ctx context.Context, media *models.ItemMedia) (int64, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	q := `INSERT INTO item_media
+				(item_id, kind, file_name, content_type, size, key, thumbnail_key, url, thumbnail_url, date_created)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, NULLIF($9, ''), now() at time zone 'utc')
+			RETURNING id, date_created`
+
+	err := myDb.QueryRowContext(ctx, q, media.ItemId, media.Kind, media.FileName, media.ContentType, media.Size,
+		media.Key, media.ThumbnailKey, media.URL, media.ThumbnailURL).Scan(&media.Id, &media.DateCreated)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, errors.Wrap(err, "Error creating item media")
+	}
+
+	return media.Id, nil
+}
+
+// Delete removes media record and returns it so its files can be removed as well
+func (m *mediaStoreSql) Delete(ctx context.Context, itemId int64, mediaId int64) (*models.ItemMedia, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	q := `DELETE FROM item_media WHERE id = $1 AND item_id = $2
+			RETURNING id, item_id, kind, file_name, content_type, size, key,
+				COALESCE(thumbnail_key, ''), url, COALESCE(thumbnail_url, ''), date_created`
+
+	media := &models.ItemMedia{}
+	err := myDb.QueryRowContext(ctx, q, mediaId, itemId).Scan(&media.Id, &media.ItemId, &media.Kind,
+		&media.FileName, &media.ContentType, &media.Size, &media.Key, &media.ThumbnailKey,
+		&media.URL, &media.ThumbnailURL, &media.DateCreated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "Error deleting item media")
+	}
+
+	return media, nil
+}
+
+// selects media of item in upload order
+func selectItemMedia(ctx context.Context, db queryer, itemId int64) (models.ItemMediaList, error) {
+	media, err := selectItemMediaOf(ctx, db, []int64{itemId})
+	if err != nil {
+		return nil, err
+	}
+	return media[itemId], nil
+}
+
+// selects media of items in upload order grouped by item id
+func selectItemMediaOf(ctx context.Context, db queryer, itemIds []int64) (map[int64]models.ItemMediaList, error) {
+	q := `SELECT id, item_id, kind, file_name, content_type, size, key,
+				COALESCE(thumbnail_key, ''), url, COALESCE(thumbnail_url, ''), date_created
+			FROM item_media
+			WHERE item_id = ANY($1)
+			ORDER BY date_created, id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(itemIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item media")
+	}
+	defer rows.Close()
+
+	list := map[int64]models.ItemMediaList{}
+	for _, id := range itemIds {
+		list[id] = models.ItemMediaList{}
+	}
+	for rows.Next() {
+		var media models.ItemMedia
+		err := rows.Scan(&media.Id, &media.ItemId, &media.Kind, &media.FileName, &media.ContentType,
+			&media.Size, &media.Key, &media.ThumbnailKey, &media.URL, &media.ThumbnailURL, &media.DateCreated)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning item media")
+		}
+		list[media.ItemId] = append(list[media.ItemId], media)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,159 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+func NewMessageStore(db db.DbFactory) MessageStore {
+	return &messageStoreSql{
+		db: db,
+	}
+}
+
+type MessageStore interface {
+	GetInquiryMessages(ctx context.Context, inquiryId int64) (models.Messages, error)
+	CreateInquiryMessage(ctx context.Context, msg *models.Message) (int64, error)
+	GetAcceptedMessages(ctx context.Context, acceptedId int64) (models.Messages, error)
+	CreateAcceptedMessage(ctx context.Context, msg *models.Message) (int64, error)
+}
+
+type messageStoreSql struct {
+	db db.DbFactory
+}
+
+// GetInquiryMessages returns thread of inquiry. Missing inquiry is reported as sql.ErrNoRows
+func (m *messageStoreSql) GetInquiryMessages(ctx context.Context, inquiryId int64) (models.Messages, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	if err := checkExists(ctx, myDb, "SELECT EXISTS (SELECT 1 FROM inquiry WHERE id = $1)", inquiryId); err != nil {
+		return nil, err
+	}
+	return selectMessages(ctx, myDb, "m.inquiry_id = $1", inquiryId)
+}
+
+// CreateInquiryMessage adds message to inquiry thread. When inquiry was already
+// accepted, message is part of reservation thread as well
+func (m *messageStoreSql) CreateInquiryMessage(ctx context.Context, msg *models.Message) (int64, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	q := "SELECT a.id FROM accepted a WHERE a.inquiry_id = $1 ORDER BY a.id LIMIT 1"
+	var acceptedId int64
+	if err := myDb.QueryRowContext(ctx, q, *msg.InquiryId).Scan(&acceptedId); err != nil && err != sql.ErrNoRows {
+		return 0, errors.Wrap(err, "Error retrieving accepted of inquiry")
+	}
+	if acceptedId != 0 {
+		msg.AcceptedId = &acceptedId
+	}
+
+	return insertMessage(ctx, myDb, msg)
+}
+
+// GetAcceptedMessages returns thread of accepted reservation, including messages
+// written on its inquiry. Missing reservation is reported as sql.ErrNoRows
+func (m *messageStoreSql) GetAcceptedMessages(ctx context.Context, acceptedId int64) (models.Messages, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	if err := checkExists(ctx, myDb, "SELECT EXISTS (SELECT 1 FROM accepted WHERE id = $1)", acceptedId); err != nil {
+		return nil, err
+	}
+	return selectMessages(ctx, myDb, "m.accepted_id = $1", acceptedId)
+}
+
+// CreateAcceptedMessage adds message to reservation thread (and thread of its inquiry)
+func (m *messageStoreSql) CreateAcceptedMessage(ctx context.Context, msg *models.Message) (int64, error) {
+	myDb := m.db.Connect()
+	defer myDb.Close()
+
+	var inquiryId sql.NullInt64
+	q := "SELECT inquiry_id FROM accepted WHERE id = $1"
+	if err := myDb.QueryRowContext(ctx, q, *msg.AcceptedId).Scan(&inquiryId); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, err
+		}
+		return 0, errors.Wrap(err, "Error retrieving accepted of message")
+	}
+	if inquiryId.Valid {
+		msg.InquiryId = &inquiryId.Int64
+	}
+
+	return insertMessage(ctx, myDb, msg)
+}
+
+// returns sql.ErrNoRows when exists query (with single id argument) is false
+func checkExists(ctx context.Context, db queryer, q string, id int64) error {
+	var exists bool
+	if err := db.QueryRowContext(ctx, q, id).Scan(&exists); err != nil {
+		return errors.Wrap(err, "Error checking existence")
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// saves message. Staff author name is taken from user, given name is used for
+// customer messages. Missing inquiry or accepted is reported as sql.ErrNoRows
+func insertMessage(ctx context.Context, db queryer, msg *models.Message) (int64, error) {
+	q := `INSERT INTO reservation_message (inquiry_id, accepted_id, kind, author_id, author_name, body, date_created)
+			VALUES ($1, $2, $3, $4,
+				COALESCE((SELECT TRIM(u.first_name || ' ' || u.last_name) FROM reservation_user u WHERE u.id = $4), NULLIF($5, '')),
+				$6, $7)
+			RETURNING id`
+
+	var id int64
+	err := db.QueryRowContext(ctx, q, msg.InquiryId, msg.AcceptedId, msg.Kind, msg.AuthorId, msg.AuthorName,
+		msg.Body, time.Now().UTC()).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, errors.Wrap(err, "Error creating message")
+	}
+	return id, nil
+}
+
+// links messages of inquiry to reservation accepted from it
+func attachInquiryMessages(ctx context.Context, db queryer, inquiryId int64, acceptedId int64) error {
+	q := "UPDATE reservation_message SET accepted_id = $2 WHERE inquiry_id = $1 AND accepted_id IS NULL"
+	if _, err := db.ExecContext(ctx, q, inquiryId, acceptedId); err != nil {
+		return errors.Wrap(err, "Error attaching inquiry messages to accepted")
+	}
+	return nil
+}
+
+// selects messages matching condition (on message aliased as m) from oldest to newest
+func selectMessages(ctx context.Context, db queryer, where string, args ...interface{}) (models.Messages, error) {
+	q := `SELECT m.id, m.inquiry_id, m.accepted_id, m.kind, m.author_id, COALESCE(m.author_name, ''),
+				m.body, m.date_created
+			FROM reservation_message m
+			WHERE ` + where + `
+			ORDER BY m.date_created, m.id`
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying messages")
+	}
+	defer rows.Close()
+
+	messages := models.Messages{}
+	for rows.Next() {
+		var msg models.Message
+		err := rows.Scan(&msg.Id, &msg.InquiryId, &msg.AcceptedId, &msg.Kind, &msg.AuthorId, &msg.AuthorName,
+			&msg.Body, &msg.DateCreated)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning message")
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
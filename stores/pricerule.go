@@ -0,0 +1,166 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func (u *itemStoreSql) GetPriceRules(ctx context.Context, itemId int64) (models.ItemPriceRules, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	return selectPriceRules(ctx, myDb, itemId, true)
+}
+
+func (u *itemStoreSql) CreatePriceRule(ctx context.Context, rule *models.ItemPriceRule) (int64, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := `INSERT INTO item_price_rule
+				(item_id, title, priority, weekdays, min_lead_days, max_lead_days,
+					date_from, date_to, modifier_type, modifier, disabled)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id`
+
+	var id int64
+	err := myDb.QueryRowContext(ctx, q, rule.ItemId, rule.Title, rule.Priority, weekdayMask(rule.Weekdays),
+		rule.MinLeadDays, rule.MaxLeadDays, rule.DateFrom, rule.DateTo,
+		rule.ModifierType, rule.Modifier, rule.Disabled).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, errors.Wrap(err, "Error creating item price rule")
+	}
+
+	return id, nil
+}
+
+func (u *itemStoreSql) UpdatePriceRule(ctx context.Context, rule *models.ItemPriceRule) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := `UPDATE item_price_rule
+			SET title = $3, priority = $4, weekdays = $5, min_lead_days = $6, max_lead_days = $7,
+				date_from = $8, date_to = $9, modifier_type = $10, modifier = $11, disabled = $12
+			WHERE id = $1 AND item_id = $2`
+
+	res, err := myDb.ExecContext(ctx, q, rule.Id, rule.ItemId, rule.Title, rule.Priority, weekdayMask(rule.Weekdays),
+		rule.MinLeadDays, rule.MaxLeadDays, rule.DateFrom, rule.DateTo,
+		rule.ModifierType, rule.Modifier, rule.Disabled)
+	if err != nil {
+		return errors.Wrap(err, "Error updating item price rule")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+func (u *itemStoreSql) DeletePriceRule(ctx context.Context, itemId int64, ruleId int64) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := "DELETE FROM item_price_rule WHERE id = $1 AND item_id = $2"
+	res, err := myDb.ExecContext(ctx, q, ruleId, itemId)
+	if err != nil {
+		return errors.Wrap(err, "Error deleting item price rule")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// selects pricing rules of item in evaluation order.
+// Disabled rules are skipped unless withDisabled is set
+func selectPriceRules(ctx context.Context, db queryer, itemId int64, withDisabled bool) (models.ItemPriceRules, error) {
+	rules, err := selectPriceRulesOf(ctx, db, []int64{itemId}, withDisabled)
+	if err != nil {
+		return nil, err
+	}
+	return rules[itemId], nil
+}
+
+// selects pricing rules of items grouped by item id, see selectPriceRules
+func selectPriceRulesOf(ctx context.Context, db queryer, itemIds []int64, withDisabled bool) (map[int64]models.ItemPriceRules, error) {
+	q := `SELECT id, item_id, title, priority, weekdays, min_lead_days, max_lead_days,
+				date_from, date_to, modifier_type, modifier, disabled
+			FROM item_price_rule
+			WHERE item_id = ANY($1) AND (NOT disabled OR $2)
+			ORDER BY priority, id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(itemIds), withDisabled)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item price rules")
+	}
+	defer rows.Close()
+
+	rules := map[int64]models.ItemPriceRules{}
+	for _, id := range itemIds {
+		rules[id] = models.ItemPriceRules{}
+	}
+	for rows.Next() {
+		var rule models.ItemPriceRule
+		var mask int16
+		var minLead, maxLead sql.NullInt32
+		var dateFrom, dateTo *time.Time
+
+		err := rows.Scan(&rule.Id, &rule.ItemId, &rule.Title, &rule.Priority, &mask, &minLead, &maxLead,
+			&dateFrom, &dateTo, &rule.ModifierType, &rule.Modifier, &rule.Disabled)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning item price rule")
+		}
+
+		rule.Weekdays = weekdaysFromMask(mask)
+		rule.MinLeadDays = nullIntPtr(minLead)
+		rule.MaxLeadDays = nullIntPtr(maxLead)
+		rule.DateFrom = dateFrom
+		rule.DateTo = dateTo
+		rules[rule.ItemId] = append(rules[rule.ItemId], rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// weekdays are stored as bitmask, bit n is set for time.Weekday n. 0 means every day
+func weekdayMask(weekdays []int) int16 {
+	var mask int16
+	for _, wd := range weekdays {
+		mask |= 1 << uint(wd)
+	}
+	return mask
+}
+
+func weekdaysFromMask(mask int16) []int {
+	weekdays := []int{}
+	for wd := 0; wd < 7; wd++ {
+		if mask&(1<<uint(wd)) != 0 {
+			weekdays = append(weekdays, wd)
+		}
+	}
+	return weekdays
+}
+
+func nullIntPtr(n sql.NullInt32) *int {
+	if !n.Valid {
+		return nil
+	}
+	value := int(n.Int32)
+	return &value
+}
@@ -0,0 +1,354 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// returned when promo code with same code already exists
+var ErrPromoCodeExists = errors.New("Promo code already exists")
+
+// returned when redeemed promo code is deleted
+var ErrPromoCodeRedeemed = errors.New("Promo code was redeemed and can not be deleted, disable it instead")
+
+// PromoCodeError is returned when promo code of inquiry can not be applied.
+// Code is one of models.Promo* reasons
+type PromoCodeError struct {
+	Code      string
+	Message   string
+	PromoCode string
+}
+
+func (p *PromoCodeError) Error() string {
+	return p.Message
+}
+
+var promoErrorMessages = map[string]string{
+	models.PromoNotFound:    "Promo code does not exist",
+	models.PromoInactive:    "Promo code is not active",
+	models.PromoExhausted:   "Promo code was already used up",
+	models.PromoNotEligible: "Promo code can not be used for this item",
+}
+
+func newPromoCodeError(reason string, code string) *PromoCodeError {
+	return &PromoCodeError{
+		Code:      reason,
+		Message:   promoErrorMessages[reason],
+		PromoCode: code,
+	}
+}
+
+func NewPromoStore(db db.DbFactory) PromoStore {
+	return &promoStoreSql{
+		db: db,
+	}
+}
+
+type PromoStore interface {
+	GetAll(ctx context.Context) (models.PromoCodes, error)
+	Create(ctx context.Context, promo *models.PromoCode) (int64, error)
+	Update(ctx context.Context, promo *models.PromoCode) error
+	Delete(ctx context.Context, id int64) error
+	GetRedemptions(ctx context.Context, id int64) (models.PromoRedemptions, error)
+}
+
+type promoStoreSql struct {
+	db db.DbFactory
+}
+
+func (p *promoStoreSql) GetAll(ctx context.Context) (models.PromoCodes, error) {
+	myDb := p.db.Connect()
+	defer myDb.Close()
+
+	return selectPromoCodes(ctx, myDb, "")
+}
+
+func (p *promoStoreSql) Create(ctx context.Context, promo *models.PromoCode) (int64, error) {
+	myDb := p.db.Connect()
+	defer myDb.Close()
+
+	tx, err := myDb.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error initializing transaction for promo code create")
+	}
+	defer tx.Rollback()
+
+	q := `INSERT INTO promo_code (code, discount_type, discount, currency, valid_from, valid_to,
+				max_uses, max_uses_per_customer, disabled)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9)
+			RETURNING id`
+
+	var id int64
+	err = tx.QueryRowContext(ctx, q, models.NormalizePromoCode(promo.Code), promo.DiscountType, promo.Discount,
+		promo.Currency, promo.ValidFrom, promo.ValidTo, promo.MaxUses, promo.MaxUsesPerCustomer,
+		promo.Disabled).Scan(&id)
+	if err != nil {
+		return 0, promoCodeError(err)
+	}
+
+	if err := setPromoItems(ctx, tx, id, promo.ItemIds); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Error commiting promo code")
+	}
+	return id, nil
+}
+
+// Update changes promo code for future inquiries, discounts of
+// existing redemptions are kept
+func (p *promoStoreSql) Update(ctx context.Context, promo *models.PromoCode) error {
+	myDb := p.db.Connect()
+	defer myDb.Close()
+
+	tx, err := myDb.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for promo code update")
+	}
+	defer tx.Rollback()
+
+	q := `UPDATE promo_code
+			SET code = $2, discount_type = $3, discount = $4, currency = NULLIF($5, ''),
+				valid_from = $6, valid_to = $7, max_uses = $8, max_uses_per_customer = $9, disabled = $10
+			WHERE id = $1`
+
+	res, err := tx.ExecContext(ctx, q, promo.Id, models.NormalizePromoCode(promo.Code), promo.DiscountType,
+		promo.Discount, promo.Currency, promo.ValidFrom, promo.ValidTo, promo.MaxUses, promo.MaxUsesPerCustomer,
+		promo.Disabled)
+	if err != nil {
+		return promoCodeError(err)
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	if err := setPromoItems(ctx, tx, promo.Id, promo.ItemIds); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting promo code")
+	}
+	return nil
+}
+
+func (p *promoStoreSql) Delete(ctx context.Context, id int64) error {
+	myDb := p.db.Connect()
+	defer myDb.Close()
+
+	res, err := myDb.ExecContext(ctx, "DELETE FROM promo_code WHERE id = $1", id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(ErrPromoCodeRedeemed, err.Error())
+		}
+		return errors.Wrap(err, "Error deleting promo code")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// GetRedemptions returns redemptions of promo code, latest first
+func (p *promoStoreSql) GetRedemptions(ctx context.Context, id int64) (models.PromoRedemptions, error) {
+	myDb := p.db.Connect()
+	defer myDb.Close()
+
+	q := `SELECT r.id, r.promo_code_id, r.inquiry_id, inq.inquirer, r.discount, r.currency, r.date_created
+			FROM promo_redemption r
+				JOIN inquiry inq ON (inq.id = r.inquiry_id)
+			WHERE r.promo_code_id = $1
+			ORDER BY r.date_created DESC, r.id DESC`
+
+	rows, err := myDb.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying promo code redemptions")
+	}
+	defer rows.Close()
+
+	redemptions := models.PromoRedemptions{}
+	for rows.Next() {
+		var redemption models.PromoRedemption
+		err := rows.Scan(&redemption.Id, &redemption.PromoCodeId, &redemption.InquiryId, &redemption.Inquirer,
+			&redemption.Discount.Amount, &redemption.Discount.Currency, &redemption.DateCreated)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning promo code redemption")
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// joins redemption r with its inquiry inq. Pending inquiries hold redemption
+// (released when inquiry is not accepted) but do not count as use of code
+const acceptedRedemption = "JOIN inquiry inq ON (inq.id = r.inquiry_id AND inq.status = '" + models.InquiryAccepted + "')"
+
+// selects promo codes (with items and number of uses) matching where condition.
+// Empty condition selects all codes
+func selectPromoCodes(ctx context.Context, db queryer, where string, args ...interface{}) (models.PromoCodes, error) {
+	q := `SELECT p.id, p.code, p.discount_type, p.discount, COALESCE(p.currency, ''), p.valid_from, p.valid_to,
+				p.max_uses, p.max_uses_per_customer, p.disabled,
+				ARRAY(SELECT pi.item_id FROM promo_code_item pi WHERE pi.promo_code_id = p.id ORDER BY pi.item_id),
+				(SELECT COUNT(*) FROM promo_redemption r ` + acceptedRedemption + ` WHERE r.promo_code_id = p.id)
+			FROM promo_code p`
+	if where != "" {
+		q += " WHERE " + where
+	}
+	q += " ORDER BY p.code"
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying promo codes")
+	}
+	defer rows.Close()
+
+	promos := models.PromoCodes{}
+	for rows.Next() {
+		var promo models.PromoCode
+		err := rows.Scan(&promo.Id, &promo.Code, &promo.DiscountType, &promo.Discount, &promo.Currency,
+			&promo.ValidFrom, &promo.ValidTo, &promo.MaxUses, &promo.MaxUsesPerCustomer, &promo.Disabled,
+			pq.Array(&promo.ItemIds), &promo.Uses)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning promo code")
+		}
+		promos = append(promos, promo)
+	}
+
+	return promos, rows.Err()
+}
+
+// replaces items promo code is restricted to
+func setPromoItems(ctx context.Context, tx *sql.Tx, promoId int64, itemIds []int64) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM promo_code_item WHERE promo_code_id = $1", promoId); err != nil {
+		return errors.Wrap(err, "Error deleting promo code items")
+	}
+
+	if len(itemIds) == 0 {
+		return nil
+	}
+
+	q := "INSERT INTO promo_code_item (promo_code_id, item_id) SELECT DISTINCT $1::bigint, unnest($2::bigint[])"
+	if _, err := tx.ExecContext(ctx, q, promoId, pq.Array(itemIds)); err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error creating promo code items")
+	}
+	return nil
+}
+
+// maps duplicate code to ErrPromoCodeExists
+func promoCodeError(err error) error {
+	if isUniqueViolation(err) {
+		return errors.Wrap(ErrPromoCodeExists, err.Error())
+	}
+	return errors.Wrap(err, "Error saving promo code")
+}
+
+// lockPromoCode locks promo code row (so concurrent redemptions of same code
+// are serialized) and checks that it can be applied to reservation of item by
+// customer (email, or phone when email is missing) at given time.
+// Returns *PromoCodeError when it can not
+func lockPromoCode(ctx context.Context, tx *sql.Tx, code string, item *models.Item, email string, phone string, at time.Time) (*models.PromoCode, error) {
+	code = models.NormalizePromoCode(code)
+
+	var id int64
+	err := tx.QueryRowContext(ctx, "SELECT id FROM promo_code WHERE code = $1 FOR UPDATE", code).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newPromoCodeError(models.PromoNotFound, code)
+		}
+		return nil, errors.Wrap(err, "Error locking promo code")
+	}
+
+	promos, err := selectPromoCodes(ctx, tx, "p.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	promo := &promos[0]
+
+	if reason := promo.Rejection(item.Id, item.Price.Currency, at); reason != "" {
+		return nil, newPromoCodeError(reason, code)
+	}
+
+	if err := checkPromoUses(ctx, tx, promo, email, phone); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+// checkPromoUses returns *PromoCodeError when promo code was already used as
+// many times as it allows, in total or by customer (email, or phone when
+// email is missing). Only accepted inquiries count as uses
+func checkPromoUses(ctx context.Context, tx *sql.Tx, promo *models.PromoCode, email string, phone string) error {
+	if promo.MaxUses != nil && promo.Uses >= *promo.MaxUses {
+		return newPromoCodeError(models.PromoExhausted, promo.Code)
+	}
+
+	if promo.MaxUsesPerCustomer != nil {
+		var uses int
+		q := `SELECT COUNT(*)
+				FROM promo_redemption r ` + acceptedRedemption + `
+				WHERE r.promo_code_id = $1 AND
+					(($2 <> '' AND lower(inq.email) = lower($2)) OR ($2 = '' AND inq.phone = $3))`
+		if err := tx.QueryRowContext(ctx, q, promo.Id, email, phone).Scan(&uses); err != nil {
+			return errors.Wrap(err, "Error counting customer promo code uses")
+		}
+		if uses >= *promo.MaxUsesPerCustomer {
+			return newPromoCodeError(models.PromoExhausted, promo.Code)
+		}
+	}
+	return nil
+}
+
+// lockInquiryPromoCode locks promo code redeemed by pending inquiry (if any)
+// and checks its limits still allow inquiry to be accepted. Pending inquiries
+// do not count as uses, so limits are checked again on accept.
+// Returns *PromoCodeError when code is exhausted
+func lockInquiryPromoCode(ctx context.Context, tx *sql.Tx, inquiry *models.Accepted) error {
+	var id int64
+	q := `SELECT p.id
+			FROM promo_redemption r
+				JOIN promo_code p ON (p.id = r.promo_code_id)
+			WHERE r.inquiry_id = $1
+			FOR UPDATE OF p`
+	if err := tx.QueryRowContext(ctx, q, inquiry.InquiryId).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return errors.Wrap(err, "Error locking promo code of inquiry")
+	}
+
+	promos, err := selectPromoCodes(ctx, tx, "p.id = $1", id)
+	if err != nil {
+		return err
+	}
+	return checkPromoUses(ctx, tx, &promos[0], inquiry.InquirerEmail, inquiry.InquirerPhone)
+}
+
+// records use of promo code on inquiry
+func insertPromoRedemption(ctx context.Context, tx *sql.Tx, promoId int64, inquiryId int64, discount models.Money) error {
+	q := `INSERT INTO promo_redemption (promo_code_id, inquiry_id, discount, currency, date_created)
+			VALUES ($1, $2, $3, $4, now() at time zone 'utc')`
+	if _, err := tx.ExecContext(ctx, q, promoId, inquiryId, discount.Amount, discount.Currency); err != nil {
+		return errors.Wrap(err, "Error saving promo code redemption")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/pkg/errors"
+)
+
+// number of generated codes tried before giving up, collisions are rare
+const referenceAttempts = 5
+
+// unique indexes of reference codes
+const (
+	inquiryReferenceIndex  = "inquiry_reference_idx"
+	acceptedReferenceIndex = "accepted_reference_idx"
+)
+
+func NewReservationStore(db db.DbFactory) ReservationStore {
+	return &reservationStoreSql{
+		db: db,
+	}
+}
+
+// ReservationStore finds inquiries and accepted reservations regardless of their kind
+type ReservationStore interface {
+	GetByReference(ctx context.Context, reference string) (*models.ReservationReference, error)
+}
+
+type reservationStoreSql struct {
+	db db.DbFactory
+}
+
+// GetByReference returns inquiry and accepted reservation with reference code.
+// Code not used by any of them is reported as sql.ErrNoRows
+func (r *reservationStoreSql) GetByReference(ctx context.Context, reference string) (*models.ReservationReference, error) {
+	myDb := r.db.Connect()
+	defer myDb.Close()
+
+	inquiries, err := selectInquiries(ctx, myDb, "inq.reference = $1", reference)
+	if err != nil {
+		return nil, err
+	}
+	acceptedList, err := selectAcceptedList(ctx, myDb, "a.reference = $1", reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(inquiries) == 0 && len(acceptedList) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	found := &models.ReservationReference{Reference: reference}
+	if len(inquiries) != 0 {
+		found.Inquiry = &inquiries[0]
+	}
+	if len(acceptedList) != 0 {
+		found.Accepted = acceptedList[0]
+	}
+	return found, nil
+}
+
+// newReference returns reference code that is not used by any inquiry or
+// accepted reservation yet
+func newReference(ctx context.Context, db queryer) (string, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM inquiry WHERE reference = $1)
+				OR EXISTS (SELECT 1 FROM accepted WHERE reference = $1)`
+
+	for attempt := 0; attempt < referenceAttempts; attempt++ {
+		reference, err := services.NewReference()
+		if err != nil {
+			return "", err
+		}
+
+		var used bool
+		if err := db.QueryRowContext(ctx, q, reference).Scan(&used); err != nil {
+			return "", errors.Wrap(err, "Error checking reference code")
+		}
+		if !used {
+			return reference, nil
+		}
+	}
+	return "", errors.New("Could not generate unused reference code")
+}
+
+// insertWithReference runs insert query q returning id, reference code is
+// passed to it as last argument. Empty reference is replaced by new one.
+// Code taken in the meantime by concurrent insert (violating unique index)
+// is replaced by new code and insert is retried. Failed insert is rolled
+// back to savepoint so transaction stays usable for retry
+func insertWithReference(ctx context.Context, tx *sql.Tx, index string, reference *string, q string, args ...interface{}) (int64, error) {
+	for attempt := 1; ; attempt++ {
+		if *reference == "" {
+			code, err := newReference(ctx, tx)
+			if err != nil {
+				return 0, err
+			}
+			*reference = code
+		}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT reference"); err != nil {
+			return 0, errors.Wrap(err, "Error creating reference savepoint")
+		}
+
+		var id int64
+		err := tx.QueryRowContext(ctx, q, append(args, *reference)...).Scan(&id)
+		if err == nil {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT reference"); err != nil {
+				return 0, errors.Wrap(err, "Error releasing reference savepoint")
+			}
+			return id, nil
+		}
+		if !isUniqueViolation(err) || violatedConstraint(err) != index || attempt == referenceAttempts {
+			return 0, err
+		}
+
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT reference"); err != nil {
+			return 0, errors.Wrap(err, "Error rolling back to reference savepoint")
+		}
+		*reference = ""
+	}
+}
@@ -0,0 +1,153 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+func (u *itemStoreSql) GetSchedule(ctx context.Context, itemId int64) (models.ItemScheduleList, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	return selectSchedule(ctx, myDb, itemId)
+}
+
+// SetSchedule replaces whole weekly schedule of item
+func (u *itemStoreSql) SetSchedule(ctx context.Context, itemId int64, schedule models.ItemScheduleList) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	tx, err := myDb.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error initializing transaction for item schedule update")
+	}
+	defer tx.Rollback()
+
+	if _, err := lockItem(ctx, tx, itemId); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM item_schedule WHERE item_id = $1", itemId); err != nil {
+		return errors.Wrap(err, "Error deleting item schedule")
+	}
+
+	q := `INSERT INTO item_schedule (item_id, weekday, opens_at, closes_at)
+			VALUES ($1, $2, $3::time, $4::time)`
+	for _, s := range schedule {
+		if _, err := tx.ExecContext(ctx, q, itemId, s.Weekday, s.OpensAt, s.ClosesAt); err != nil {
+			return errors.Wrap(err, "Error creating item schedule")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Error commiting item schedule")
+	}
+	return nil
+}
+
+func (u *itemStoreSql) GetBlackouts(ctx context.Context, itemId int64) (models.ItemBlackouts, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	return selectBlackouts(ctx, myDb, itemId, time.Time{}, time.Time{})
+}
+
+func (u *itemStoreSql) CreateBlackout(ctx context.Context, blackout *models.ItemBlackout) (int64, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := `INSERT INTO item_blackout (item_id, date_from, date_to, reason)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id`
+
+	var id int64
+	err := myDb.QueryRowContext(ctx, q, blackout.ItemId, blackout.DateFrom.UTC(), blackout.DateTo.UTC(), blackout.Reason).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, errors.Wrap(err, "Error creating item blackout")
+	}
+
+	return id, nil
+}
+
+func (u *itemStoreSql) DeleteBlackout(ctx context.Context, itemId int64, blackoutId int64) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := "DELETE FROM item_blackout WHERE id = $1 AND item_id = $2"
+	res, err := myDb.ExecContext(ctx, q, blackoutId, itemId)
+	if err != nil {
+		return errors.Wrap(err, "Error deleting item blackout")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// selects weekly schedule of item ordered by weekday and opening time
+func selectSchedule(ctx context.Context, db queryer, itemId int64) (models.ItemScheduleList, error) {
+	q := `SELECT id, item_id, weekday, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI')
+			FROM item_schedule
+			WHERE item_id = $1
+			ORDER BY weekday, opens_at NULLS FIRST, id`
+
+	rows, err := db.QueryContext(ctx, q, itemId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item schedule")
+	}
+	defer rows.Close()
+
+	schedule := models.ItemScheduleList{}
+	for rows.Next() {
+		var s models.ItemSchedule
+		if err := rows.Scan(&s.Id, &s.ItemId, &s.Weekday, &s.OpensAt, &s.ClosesAt); err != nil {
+			return nil, errors.Wrap(err, "Error scanning item schedule")
+		}
+		schedule = append(schedule, s)
+	}
+
+	return schedule, rows.Err()
+}
+
+// selects blackouts of item overlapping period from start to end.
+// Zero start and end select all of them
+func selectBlackouts(ctx context.Context, db queryer, itemId int64, start time.Time, end time.Time) (models.ItemBlackouts, error) {
+	q := `SELECT id, item_id, date_from, date_to, reason
+			FROM item_blackout
+			WHERE item_id = $1 AND ($4 OR (date_from < $3 AND date_to > $2))
+			ORDER BY date_from, id`
+
+	rows, err := db.QueryContext(ctx, q, itemId, start.UTC(), end.UTC(), start.IsZero() && end.IsZero())
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item blackouts")
+	}
+	defer rows.Close()
+
+	blackouts := models.ItemBlackouts{}
+	for rows.Next() {
+		var b models.ItemBlackout
+		if err := rows.Scan(&b.Id, &b.ItemId, &b.DateFrom, &b.DateTo, &b.Reason); err != nil {
+			return nil, errors.Wrap(err, "Error scanning item blackout")
+		}
+		b.DateFrom = b.DateFrom.UTC()
+		b.DateTo = b.DateTo.UTC()
+		blackouts = append(blackouts, b)
+	}
+
+	return blackouts, rows.Err()
+}
@@ -0,0 +1,107 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// SearchAvailable returns visible daily items (bundles included) that are open,
+// outside of blackouts and have room for all guests on every night of stay.
+// Free capacity of all items is computed by single query, matched items are then
+// loaded together with pricing data and booking rules and left out when their
+// rules reject the stay.
+// Slot items are not searched, their free slots are listed by GetSlots
+func (u *itemStoreSql) SearchAvailable(ctx context.Context, search *models.AvailabilitySearch, at time.Time) ([]models.AvailableItem, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	where, args := itemFilterCondition(&models.ItemFilter{CategoryId: search.CategoryId})
+	args = append(args, search.From.UTC(), search.To.UTC(), at.UTC(), nights(search.From, search.To), search.Guests)
+	from, to, now, count, guests := len(args)-4, len(args)-3, len(args)-2, len(args)-1, len(args)
+
+	// free holds remaining capacity of daily items on nights they are open and
+	// not blacked out. Bundle has room as many times as its scarcest component
+	q := fmt.Sprintf(`WITH night AS (
+				SELECT d::date AS day FROM generate_series($%[1]d::date, $%[2]d::date - 1, interval '1 day') AS d
+			), free AS (
+				SELECT i.id AS item_id, n.day, `+itemCapacity("i")+` - COALESCE(SUM(b.quantity), 0) AS remaining
+				FROM item i
+					CROSS JOIN night n
+					LEFT JOIN item_booking b ON (b.item_id = i.id AND
+						b.date_reservation::date <= n.day AND b.date_end::date > n.day)
+				WHERE i.archived_at IS NULL AND i.booking_mode = 'daily' AND
+					(NOT EXISTS (SELECT 1 FROM item_schedule s WHERE s.item_id = i.id) OR
+						EXISTS (SELECT 1 FROM item_schedule s WHERE s.item_id = i.id AND s.weekday = EXTRACT(DOW FROM n.day))) AND
+					NOT EXISTS (SELECT 1 FROM item_blackout bo WHERE bo.item_id = i.id AND
+						bo.date_from < n.day + 1 AND bo.date_to > n.day)
+				GROUP BY i.id, n.day
+			)
+			SELECT i.id, MIN(COALESCE(c.remaining, f.remaining))
+			FROM item i
+				JOIN free f ON f.item_id = i.id
+				CROSS JOIN LATERAL (
+					SELECT CASE WHEN COUNT(cf.item_id) < COUNT(*) THEN 0 ELSE MIN(cf.remaining / bc.quantity) END AS remaining
+					FROM item_bundle_component bc
+						LEFT JOIN free cf ON (cf.item_id = bc.item_id AND cf.day = f.day)
+					WHERE bc.bundle_id = i.id
+				) c
+			WHERE %[6]s AND
+				(i.show_from IS NULL OR i.show_from <= $%[3]d) AND (i.show_to IS NULL OR i.show_to >= $%[3]d)
+			GROUP BY i.id
+			HAVING COUNT(*) = $%[4]d AND MIN(COALESCE(c.remaining, f.remaining)) >= $%[5]d
+			ORDER BY i.id`, from, to, now, count, guests, where)
+
+	rows, err := myDb.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error searching available items")
+	}
+	defer rows.Close()
+
+	remaining := map[int64]int64{}
+	ids := []int64{}
+	for rows.Next() {
+		var id, free int64
+		if err := rows.Scan(&id, &free); err != nil {
+			return nil, errors.Wrap(err, "Error scanning available item")
+		}
+		remaining[id] = free
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error reading available items")
+	}
+
+	found := []models.AvailableItem{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	items, err := selectPricedItems(ctx, myDb, "i.id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	rules, err := selectItemBookingRulesOf(ctx, myDb, items)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range items {
+		item := &items[idx]
+		err = services.CheckBookingRules(item, rules[item.Id], search.From, nights(search.From, search.To), at)
+		if _, ok := err.(*services.BookingRuleError); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found = append(found, models.AvailableItem{Item: *item, Remaining: remaining[item.Id]})
+	}
+	return found, nil
+}
@@ -0,0 +1,47 @@
+package stores
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// postgres error codes, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqForeignKeyViolation = "23503"
+	pqUniqueViolation     = "23505"
+	pqCheckViolation      = "23514"
+	pqExclusionViolation  = "23P01"
+)
+
+func isPqError(err error, code string) bool {
+	pqErr, ok := errors.Cause(err).(*pq.Error)
+	return ok && string(pqErr.Code) == code
+}
+
+// referenced row (e.g. item) does not exist
+func isForeignKeyViolation(err error) bool {
+	return isPqError(err, pqForeignKeyViolation)
+}
+
+// row duplicates unique value of other row (e.g. code)
+func isUniqueViolation(err error) bool {
+	return isPqError(err, pqUniqueViolation)
+}
+
+// returns name of constraint violated by postgres error
+func violatedConstraint(err error) string {
+	if pqErr, ok := errors.Cause(err).(*pq.Error); ok {
+		return pqErr.Constraint
+	}
+	return ""
+}
+
+// row violates check constraint (e.g. inverted date range)
+func isCheckViolation(err error) bool {
+	return isPqError(err, pqCheckViolation)
+}
+
+// row conflicts with other row by exclusion constraint (e.g. overlapping ranges)
+func isExclusionViolation(err error) bool {
+	return isPqError(err, pqExclusionViolation)
+}
@@ -0,0 +1,270 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alesbrelih/go-reservation-api/db"
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// returned when removed tax class is still used by items or add-ons
+var ErrTaxClassInUse = errors.New("Tax class is used by items or add-ons")
+
+// returned when tax rate validity overlaps other rate of same tenant and tax class
+var ErrTaxRateConflict = errors.New("Tax rate validity overlaps other rate of tax class")
+
+// tables holding tax breakdown lines and their owner column
+const (
+	inquiryTaxTable  = "inquiry_tax"
+	inquiryTaxOwner  = "inquiry_id"
+	acceptedTaxTable = "accepted_tax"
+	acceptedTaxOwner = "accepted_id"
+)
+
+func NewTaxStore(db db.DbFactory) TaxStore {
+	return &taxStoreSql{
+		db: db,
+	}
+}
+
+type TaxStore interface {
+	GetClasses(ctx context.Context) (models.TaxClasses, error)
+	CreateClass(ctx context.Context, class *models.TaxClass) (int64, error)
+	UpdateClass(ctx context.Context, class *models.TaxClass) error
+	DeleteClass(ctx context.Context, id int64) error
+	GetRates(ctx context.Context, tenantId int64) (models.TaxRates, error)
+	CreateRate(ctx context.Context, rate *models.TaxRate) (int64, error)
+	DeleteRate(ctx context.Context, tenantId int64, rateId int64) error
+}
+
+type taxStoreSql struct {
+	db db.DbFactory
+}
+
+func (t *taxStoreSql) GetClasses(ctx context.Context) (models.TaxClasses, error) {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	rows, err := myDb.QueryContext(ctx, "SELECT id, title FROM tax_class ORDER BY title, id")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying tax classes")
+	}
+	defer rows.Close()
+
+	classes := models.TaxClasses{}
+	for rows.Next() {
+		var class models.TaxClass
+		if err := rows.Scan(&class.Id, &class.Title); err != nil {
+			return nil, errors.Wrap(err, "Error scanning tax class")
+		}
+		classes = append(classes, class)
+	}
+
+	return classes, rows.Err()
+}
+
+func (t *taxStoreSql) CreateClass(ctx context.Context, class *models.TaxClass) (int64, error) {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	var id int64
+	q := "INSERT INTO tax_class (title) VALUES ($1) RETURNING id"
+	if err := myDb.QueryRowContext(ctx, q, class.Title).Scan(&id); err != nil {
+		return 0, errors.Wrap(err, "Error creating tax class")
+	}
+
+	return id, nil
+}
+
+func (t *taxStoreSql) UpdateClass(ctx context.Context, class *models.TaxClass) error {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	res, err := myDb.ExecContext(ctx, "UPDATE tax_class SET title = $2 WHERE id = $1", class.Id, class.Title)
+	if err != nil {
+		return errors.Wrap(err, "Error updating tax class")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// DeleteClass removes tax class with its rates. Breakdowns of existing
+// reservations keep their rates
+func (t *taxStoreSql) DeleteClass(ctx context.Context, id int64) error {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	res, err := myDb.ExecContext(ctx, "DELETE FROM tax_class WHERE id = $1", id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(ErrTaxClassInUse, err.Error())
+		}
+		return errors.Wrap(err, "Error deleting tax class")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+func (t *taxStoreSql) GetRates(ctx context.Context, tenantId int64) (models.TaxRates, error) {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	return selectTaxRates(ctx, myDb, &tenantId)
+}
+
+// CreateRate saves rate of tenant. Missing tenant or tax class is
+// reported as sql.ErrNoRows
+func (t *taxStoreSql) CreateRate(ctx context.Context, rate *models.TaxRate) (int64, error) {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	q := `INSERT INTO tax_rate (tenant_id, tax_class_id, rate, valid_from, valid_to)
+			VALUES ($1, $2, $3, $4::date, $5::date)
+			RETURNING id`
+
+	var id int64
+	err := myDb.QueryRowContext(ctx, q, rate.TenantId, rate.TaxClassId, rate.Rate,
+		rate.ValidFrom, rate.ValidTo).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		if isExclusionViolation(err) || isCheckViolation(err) {
+			return 0, errors.Wrap(ErrTaxRateConflict, err.Error())
+		}
+		return 0, errors.Wrap(err, "Error creating tax rate")
+	}
+
+	return id, nil
+}
+
+func (t *taxStoreSql) DeleteRate(ctx context.Context, tenantId int64, rateId int64) error {
+	myDb := t.db.Connect()
+	defer myDb.Close()
+
+	q := "DELETE FROM tax_rate WHERE id = $1 AND tenant_id = $2"
+	res, err := myDb.ExecContext(ctx, q, rateId, tenantId)
+	if err != nil {
+		return errors.Wrap(err, "Error deleting tax rate")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// selects tax rates of tenant ordered by tax class and validity.
+// Items without tenant have no rates
+func selectTaxRates(ctx context.Context, db queryer, tenantId *int64) (models.TaxRates, error) {
+	rates := models.TaxRates{}
+	if tenantId == nil {
+		return rates, nil
+	}
+
+	q := `SELECT id, tenant_id, tax_class_id, rate, valid_from, valid_to
+			FROM tax_rate
+			WHERE tenant_id = $1
+			ORDER BY tax_class_id, valid_from`
+
+	rows, err := db.QueryContext(ctx, q, *tenantId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying tax rates")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.TaxRate
+		err := rows.Scan(&rate.Id, &rate.TenantId, &rate.TaxClassId, &rate.Rate, &rate.ValidFrom, &rate.ValidTo)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning tax rate")
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// saves tax breakdown lines of owner (inquiry or accepted) into table
+func insertTaxLines(ctx context.Context, db queryer, table string, ownerColumn string, ownerId int64, tax *models.TaxBreakdown) error {
+	if tax == nil {
+		return nil
+	}
+
+	q := `INSERT INTO ` + table + ` (` + ownerColumn + `, tax_class_id, rate, net, tax, gross, currency)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	for _, line := range tax.Lines {
+		_, err := db.ExecContext(ctx, q, ownerId, line.TaxClassId, line.Rate, line.Net.Amount, line.Tax.Amount,
+			line.Gross.Amount, line.Gross.Currency)
+		if err != nil {
+			return errors.Wrap(err, "Error saving tax line")
+		}
+	}
+	return nil
+}
+
+// selects tax breakdowns of owners (inquiries or accepted) from table by owner id.
+// Owners saved before taxes were introduced have no breakdown
+func selectTaxLines(ctx context.Context, db queryer, table string, ownerColumn string, ownerIds []int64) (map[int64]*models.TaxBreakdown, error) {
+	q := `SELECT ` + ownerColumn + `, tax_class_id, rate, net, tax, gross, currency
+			FROM ` + table + `
+			WHERE ` + ownerColumn + ` = ANY($1)
+			ORDER BY id`
+
+	rows, err := db.QueryContext(ctx, q, pq.Array(ownerIds))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying tax lines")
+	}
+	defer rows.Close()
+
+	lines := map[int64][]models.TaxLine{}
+	for rows.Next() {
+		var ownerId int64
+		var currency string
+		var line models.TaxLine
+		err := rows.Scan(&ownerId, &line.TaxClassId, &line.Rate, &line.Net.Amount, &line.Tax.Amount,
+			&line.Gross.Amount, &currency)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error scanning tax line")
+		}
+		line.Net.Currency = currency
+		line.Tax.Currency = currency
+		line.Gross.Currency = currency
+		lines[ownerId] = append(lines[ownerId], line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error querying tax lines")
+	}
+
+	breakdowns := map[int64]*models.TaxBreakdown{}
+	for ownerId, ownerLines := range lines {
+		breakdown, err := models.NewTaxBreakdown(ownerLines)
+		if err != nil {
+			return nil, err
+		}
+		breakdowns[ownerId] = breakdown
+	}
+	return breakdowns, nil
+}
@@ -29,7 +29,7 @@ func (t *tenantStoreSql) GetAll(ctx context.Context) (models.Tenants, error) {
 	myDb := t.db.Connect()
 	defer myDb.Close()
 
-	query := "SELECT id, title, email FROM tenant"
+	query := "SELECT id, title, email, currency FROM tenant"
 	rows, err := myDb.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -41,7 +41,7 @@ func (t *tenantStoreSql) GetAll(ctx context.Context) (models.Tenants, error) {
 	for rows.Next() {
 		var item models.Tenant
 
-		err = rows.Scan(&item.Id, &item.Title, &item.Email)
+		err = rows.Scan(&item.Id, &item.Title, &item.Email, &item.Currency)
 		if err != nil {
 			return nil, err
 		}
@@ -56,7 +56,7 @@ func (t *tenantStoreSql) GetOne(ctx context.Context, id int64) (*models.Tenant,
 	myDb := t.db.Connect()
 	defer myDb.Close()
 
-	stmt := `SELECT t.id, title, t.email, ru.id, ru.first_name, ru.last_name, ru.email
+	stmt := `SELECT t.id, title, t.email, t.currency, ru.id, ru.first_name, ru.last_name, ru.email
 			FROM tenant t
 				LEFT JOIN tenant_has_reservation_user thru ON (thru.tenant_id = t.id)
 				LEFT JOIN reservation_user ru ON (ru.id = thru.reservation_user_id)
@@ -74,22 +74,24 @@ func (t *tenantStoreSql) GetOne(ctx context.Context, id int64) (*models.Tenant,
 		var id int64
 		var title string
 		var email string
+		var currency string
 		var userId int64
 		var userFirstName string
 		var userLastName string
 		var userEmail string
 
-		err := rows.Scan(&id, &title, &email, &userId, &userFirstName, &userLastName, &userEmail)
+		err := rows.Scan(&id, &title, &email, &currency, &userId, &userFirstName, &userLastName, &userEmail)
 		if err != nil {
 			return nil, err
 		}
 
 		if tenant == nil {
 			tenant = &models.Tenant{
-				Id:    id,
-				Title: title,
-				Email: email,
-				Users: []models.User{},
+				Id:       id,
+				Title:    title,
+				Email:    email,
+				Currency: currency,
+				Users:    []models.User{},
 			}
 		}
 		if userId != 0 {
@@ -110,14 +112,15 @@ func (t *tenantStoreSql) Create(item *models.Tenant) (int64, error) {
 	myDb := t.db.Connect()
 	defer myDb.Close()
 
-	stmt := "INSERT INTO tenant (title, email) VALUES ($1, $2, $3)"
-	res, err := myDb.Exec(stmt, item.Title, item.Email)
+	// currency defaults to application default currency
+	var id int64
+	stmt := "INSERT INTO tenant (title, email, currency) VALUES ($1, $2, COALESCE(NULLIF($3, ''), $4)) RETURNING id"
+	err := myDb.QueryRow(stmt, item.Title, item.Email, item.Currency, models.DefaultCurrency).Scan(&id)
 
 	if err != nil {
 		return 0, err
 	}
 
-	id, _ := res.LastInsertId()
 	return id, nil
 }
 
@@ -125,8 +128,8 @@ func (t *tenantStoreSql) Update(item *models.Tenant) error {
 	myDb := t.db.Connect()
 	defer myDb.Close()
 
-	stmt := "UPDATE tenant SET title=$2, email=$3, show_to=$3 WHERE id = $1"
-	res, err := myDb.Exec(stmt, item.Id, item.Title, item.Email)
+	stmt := "UPDATE tenant SET title=$2, email=$3, currency=COALESCE(NULLIF($4, ''), currency) WHERE id = $1"
+	res, err := myDb.Exec(stmt, item.Id, item.Title, item.Email, item.Currency)
 
 	if err != nil {
 		return err
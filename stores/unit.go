@@ -0,0 +1,194 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/pkg/errors"
+)
+
+// returned when unit label is already used by other unit of item
+var ErrUnitLabelExists = errors.New("Item already has unit with this label")
+
+// returned when removed unit is assigned to reservations
+var ErrUnitInUse = errors.New("Unit is assigned to reservations, set it out of service instead")
+
+// returned when assigned unit is out of service or reserved in the same period
+var ErrUnitUnavailable = errors.New("Unit is out of service or assigned to other reservation in that period")
+
+// itemCapacity returns capacity of item with given table alias: number of its
+// active units, items without units use configured capacity
+func itemCapacity(alias string) string {
+	return `(CASE WHEN EXISTS (SELECT 1 FROM item_unit u WHERE u.item_id = ` + alias + `.id)
+				THEN (SELECT COUNT(*) FROM item_unit u WHERE u.item_id = ` + alias + `.id AND u.status = 'active')
+				ELSE ` + alias + `.capacity END)`
+}
+
+func (u *itemStoreSql) GetUnits(ctx context.Context, itemId int64) (models.ItemUnits, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := `SELECT id, item_id, label, status
+			FROM item_unit
+			WHERE item_id = $1
+			ORDER BY label, id`
+
+	rows, err := myDb.QueryContext(ctx, q, itemId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error querying item units")
+	}
+	defer rows.Close()
+
+	units := models.ItemUnits{}
+	for rows.Next() {
+		var unit models.ItemUnit
+		if err := rows.Scan(&unit.Id, &unit.ItemId, &unit.Label, &unit.Status); err != nil {
+			return nil, errors.Wrap(err, "Error scanning item unit")
+		}
+		units = append(units, unit)
+	}
+
+	return units, rows.Err()
+}
+
+// CreateUnit adds unit to item. Missing item is reported as sql.ErrNoRows
+func (u *itemStoreSql) CreateUnit(ctx context.Context, unit *models.ItemUnit) (int64, error) {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := `INSERT INTO item_unit (item_id, label, status)
+			VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'active'))
+			RETURNING id`
+
+	var id int64
+	if err := myDb.QueryRowContext(ctx, q, unit.ItemId, unit.Label, unit.Status).Scan(&id); err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, sql.ErrNoRows
+		}
+		if isUniqueViolation(err) {
+			return 0, errors.Wrap(ErrUnitLabelExists, err.Error())
+		}
+		return 0, errors.Wrap(err, "Error creating item unit")
+	}
+
+	return id, nil
+}
+
+// UpdateUnit changes label and status of unit. Units taken out of service
+// keep their reservations, those have to be reassigned manually
+func (u *itemStoreSql) UpdateUnit(ctx context.Context, unit *models.ItemUnit) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	q := `UPDATE item_unit SET label = $3, status = COALESCE(NULLIF($4, ''), status)
+			WHERE id = $1 AND item_id = $2`
+
+	res, err := myDb.ExecContext(ctx, q, unit.Id, unit.ItemId, unit.Label, unit.Status)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(ErrUnitLabelExists, err.Error())
+		}
+		return errors.Wrap(err, "Error updating item unit")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+func (u *itemStoreSql) DeleteUnit(ctx context.Context, itemId int64, unitId int64) error {
+	myDb := u.db.Connect()
+	defer myDb.Close()
+
+	res, err := myDb.ExecContext(ctx, "DELETE FROM item_unit WHERE id = $1 AND item_id = $2", unitId, itemId)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(ErrUnitInUse, err.Error())
+		}
+		return errors.Wrap(err, "Error deleting item unit")
+	}
+
+	if num, err := res.RowsAffected(); err != nil || num == 0 {
+		if num == 0 {
+			return sql.ErrNoRows
+		}
+		return errors.Wrap(err, "Error retrieving rows affected")
+	}
+
+	return nil
+}
+
+// condition of accepted reservation (a, either accepted or unit_booking row)
+// overlapping period from $2 to $3.
+// Daily reservations overlap by nights, slot reservations by time
+func unitOverlap(item *models.Item) string {
+	if item.IsSlotBooked() {
+		return "a.date_reservation < $3 AND a.date_end > $2"
+	}
+	return "a.date_reservation::date < $3::date AND a.date_end::date > $2::date"
+}
+
+// assignUnit returns first active unit of item (by label) that is not assigned
+// to other accepted reservation from start to end. Items without units get
+// no unit. Item must be locked by caller
+func assignUnit(ctx context.Context, tx *sql.Tx, item *models.Item, start time.Time, end time.Time) (*int64, error) {
+	units, err := assignUnits(ctx, tx, item, 1, start, end)
+	if err != nil || len(units) == 0 {
+		return nil, err
+	}
+	return &units[0], nil
+}
+
+// assignUnits returns quantity of first active units of item (by label) that
+// are not assigned to other accepted reservation or bundle from start to end.
+// Items without units get none. Item must be locked by caller
+func assignUnits(ctx context.Context, tx *sql.Tx, item *models.Item, quantity int64, start time.Time, end time.Time) ([]int64, error) {
+	var hasUnits bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM item_unit WHERE item_id = $1)", item.Id).Scan(&hasUnits); err != nil {
+		return nil, errors.Wrap(err, "Error checking item units")
+	}
+	if !hasUnits {
+		return nil, nil
+	}
+
+	q := `SELECT u.id
+			FROM item_unit u
+			WHERE u.item_id = $1 AND u.status = 'active' AND NOT EXISTS (
+				SELECT 1 FROM unit_booking a WHERE a.unit_id = u.id AND ` + unitOverlap(item) + `)
+			ORDER BY u.label, u.id
+			LIMIT $4`
+
+	rows, err := tx.QueryContext(ctx, q, item.Id, start.UTC(), end.UTC(), quantity)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error assigning item units")
+	}
+	defer rows.Close()
+
+	units := []int64{}
+	for rows.Next() {
+		var unitId int64
+		if err := rows.Scan(&unitId); err != nil {
+			return nil, errors.Wrap(err, "Error scanning assigned item unit")
+		}
+		units = append(units, unitId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error reading assigned item units")
+	}
+
+	if int64(len(units)) < quantity {
+		return nil, &BookingError{
+			Code:    BookingFullyBooked,
+			Message: "No unit of item is free on requested dates",
+			ItemId:  item.Id,
+		}
+	}
+	return units, nil
+}
@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/hashicorp/go-hclog"
+)
+
+// InquiryExpirer expires stale pending inquiries, implemented by stores.InquiryStore.
+// Daily inquiries arriving before day and slot inquiries starting before now are past date
+type InquiryExpirer interface {
+	Expire(ctx context.Context, createdBefore time.Time, day time.Time, now time.Time) (models.Inquiries, error)
+}
+
+// ExpiryPolicy configures how often inquiries are checked and how long
+// they can stay unanswered. Notify sends email to expired inquirers
+type ExpiryPolicy struct {
+	Interval time.Duration
+	MaxAge   time.Duration
+	Notify   bool
+}
+
+func NewInquiryExpiry(store InquiryExpirer, notifier services.Notifier, policy ExpiryPolicy, log hclog.Logger) *InquiryExpiry {
+	return &InquiryExpiry{
+		store:    store,
+		notifier: notifier,
+		policy:   policy,
+		log:      log,
+		now:      time.Now,
+	}
+}
+
+// InquiryExpiry is background worker expiring pending inquiries on every
+// interval. It is started once with Start and stopped with Stop
+type InquiryExpiry struct {
+	store    InquiryExpirer
+	notifier services.Notifier
+	policy   ExpiryPolicy
+	log      hclog.Logger
+	now      func() time.Time
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// Start runs expiry right away and then on every interval until Stop is called
+func (e *InquiryExpiry) Start(ctx context.Context) {
+	ctx, e.cancel = context.WithCancel(ctx)
+	e.done.Add(1)
+
+	go func() {
+		defer e.done.Done()
+
+		ticker := time.NewTicker(e.policy.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := e.Run(ctx); err != nil && ctx.Err() == nil {
+				e.log.Error("Error expiring inquiries", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels running expiry and waits for worker to finish
+func (e *InquiryExpiry) Stop() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	e.done.Wait()
+}
+
+// Run expires inquiries once and notifies inquirers when policy says so.
+// Failed notifications are logged, inquiries stay expired. Returns expired inquiries
+func (e *InquiryExpiry) Run(ctx context.Context) (models.Inquiries, error) {
+	now := e.now().UTC()
+	expired, err := e.store.Expire(ctx, now.Add(-e.policy.MaxAge), models.TruncateDay(now), now)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(expired) != 0 {
+		e.log.Info("Expired inquiries", "count", len(expired))
+	}
+
+	if !e.policy.Notify {
+		return expired, nil
+	}
+
+	for idx := range expired {
+		inquiry := &expired[idx]
+		if inquiry.Email == "" {
+			continue
+		}
+		if err := e.notifier.Notify(ctx, expiredNotification(inquiry)); err != nil {
+			e.log.Error("Error notifying inquirer of expired inquiry", "inquiry", inquiry.Id, "error", err)
+		}
+	}
+	return expired, nil
+}
+
+func expiredNotification(inquiry *models.Inquiry) *services.Notification {
+	item := "your reservation"
+	if inquiry.Item.Title != nil && *inquiry.Item.Title != "" {
+		item = *inquiry.Item.Title
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nyour inquiry %s for %s from %s to %s has expired (%s).\n"+
+		"If you are still interested, please send us a new inquiry.\n",
+		inquiry.Inquirer, inquiry.Reference, item, inquiry.DateReservation.Format("2006-01-02"),
+		inquiry.DateEnd.Format("2006-01-02"), inquiry.StatusNote)
+
+	return &services.Notification{
+		To:      inquiry.Email,
+		Subject: "Your reservation inquiry has expired",
+		Body:    body,
+	}
+}
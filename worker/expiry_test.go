@@ -0,0 +1,142 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alesbrelih/go-reservation-api/models"
+	"github.com/alesbrelih/go-reservation-api/services"
+	"github.com/alesbrelih/go-reservation-api/worker"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/mock"
+)
+
+type MyFakeExpirer struct {
+	mock.Mock
+}
+
+func (h *MyFakeExpirer) Expire(ctx context.Context, createdBefore time.Time, day time.Time, now time.Time) (models.Inquiries, error) {
+	args := h.Called(ctx, createdBefore, day, now)
+	return args.Get(0).(models.Inquiries), args.Error(1)
+}
+
+type MyFakeNotifier struct {
+	mock.Mock
+}
+
+func (h *MyFakeNotifier) Notify(ctx context.Context, n *services.Notification) error {
+	args := h.Called(ctx, n)
+	return args.Error(0)
+}
+
+func expiredInquiries() models.Inquiries {
+	title := "Lake house"
+	day := time.Date(2021, 8, 12, 0, 0, 0, 0, time.UTC)
+	return models.Inquiries{
+		{Id: 1, Reference: "K7F-92QX", Inquirer: "John Doe", Email: "john.doe@doe.com", Item: models.Item{Title: &title},
+			DateReservation: day, DateEnd: day.AddDate(0, 0, 2), StatusNote: models.InquiryExpiredPastDate},
+		{Id: 2, Inquirer: "Jane Doe", Phone: "+38640123456", DateReservation: day, DateEnd: day.AddDate(0, 0, 1)},
+	}
+}
+
+func TestInquiryExpiry_Run_NotifiesInquirersWithEmail(t *testing.T) {
+	store := &MyFakeExpirer{}
+	store.On("Expire", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expiredInquiries(), nil)
+	notifier := &MyFakeNotifier{}
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(nil)
+
+	policy := worker.ExpiryPolicy{Interval: time.Hour, MaxAge: 48 * time.Hour, Notify: true}
+	expiry := worker.NewInquiryExpiry(store, notifier, policy, hclog.NewNullLogger())
+
+	expired, err := expiry.Run(context.Background())
+	if err != nil || len(expired) != 2 {
+		t.Fatalf("Expected two expired inquiries but got %v %v", expired, err)
+	}
+
+	createdBefore := store.Calls[0].Arguments.Get(1).(time.Time)
+	now := store.Calls[0].Arguments.Get(3).(time.Time)
+	if now.Sub(createdBefore) != 48*time.Hour {
+		t.Errorf("Expected inquiries older than max age to expire but got %v %v", createdBefore, now)
+	}
+
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+	n := notifier.Calls[0].Arguments.Get(1).(*services.Notification)
+	if n.To != "john.doe@doe.com" || !strings.Contains(n.Body, "Lake house") || !strings.Contains(n.Body, "K7F-92QX") ||
+		!strings.Contains(n.Body, models.InquiryExpiredPastDate) {
+		t.Errorf("Unexpected notification %#v", n)
+	}
+}
+
+func TestInquiryExpiry_Run_DailyInquiryArrivingTodayNotPastDate(t *testing.T) {
+	store := &MyFakeExpirer{}
+	store.On("Expire", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(models.Inquiries{}, nil)
+
+	policy := worker.ExpiryPolicy{Interval: time.Hour, MaxAge: 48 * time.Hour}
+	expiry := worker.NewInquiryExpiry(store, &MyFakeNotifier{}, policy, hclog.NewNullLogger())
+
+	if _, err := expiry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	day := store.Calls[0].Arguments.Get(2).(time.Time)
+	now := store.Calls[0].Arguments.Get(3).(time.Time)
+	arrival := models.TruncateDay(now)
+	if arrival.Before(day) {
+		t.Errorf("Expected daily inquiry arriving today to stay pending but day cutoff is %v", day)
+	}
+	if !arrival.AddDate(0, 0, -1).Before(day) {
+		t.Errorf("Expected daily inquiry arriving yesterday to expire but day cutoff is %v", day)
+	}
+}
+
+func TestInquiryExpiry_Run_WithoutNotify(t *testing.T) {
+	store := &MyFakeExpirer{}
+	store.On("Expire", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expiredInquiries(), nil)
+	notifier := &MyFakeNotifier{}
+
+	policy := worker.ExpiryPolicy{Interval: time.Hour, MaxAge: time.Hour}
+	expiry := worker.NewInquiryExpiry(store, notifier, policy, hclog.NewNullLogger())
+
+	if _, err := expiry.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+}
+
+func TestInquiryExpiry_Run_NotificationFailureKeepsExpired(t *testing.T) {
+	store := &MyFakeExpirer{}
+	store.On("Expire", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expiredInquiries(), nil)
+	notifier := &MyFakeNotifier{}
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(errors.New("mail server down"))
+
+	policy := worker.ExpiryPolicy{Interval: time.Hour, MaxAge: time.Hour, Notify: true}
+	expiry := worker.NewInquiryExpiry(store, notifier, policy, hclog.NewNullLogger())
+
+	expired, err := expiry.Run(context.Background())
+	if err != nil || len(expired) != 2 {
+		t.Errorf("Expected expired inquiries despite failed notification but got %v %v", expired, err)
+	}
+}
+
+func TestInquiryExpiry_StartStop(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	store := &MyFakeExpirer{}
+	store.On("Expire", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(models.Inquiries{}, nil).
+		Run(func(mock.Arguments) { ran <- struct{}{} })
+
+	policy := worker.ExpiryPolicy{Interval: time.Hour, MaxAge: time.Hour}
+	expiry := worker.NewInquiryExpiry(store, &MyFakeNotifier{}, policy, hclog.NewNullLogger())
+
+	expiry.Start(context.Background())
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Error("Expected expiry to run on start")
+	}
+	expiry.Stop()
+
+	store.AssertNumberOfCalls(t, "Expire", 1)
+}